@@ -39,6 +39,13 @@ type Rows interface {
 	Err() error
 }
 
+// RowsCloser is implemented by the Rows adapters that can be closed before all the rows are read, e.g. on the scan
+// error, to make the connection ready for use again. Rows that are read till the end are closed automatically.
+type RowsCloser interface {
+	// Close closes the rows, it is safe to call Close after the rows are already closed.
+	Close()
+}
+
 // Queryable is the base interface for different types of db connections that should implement
 // basic querying operations.
 type Queryable interface {
@@ -93,3 +100,9 @@ type ConnPool interface {
 	// connections will be closed when they are released.
 	Close() error
 }
+
+// ConnPoolSizer is implemented by the ConnPool adapters that can report the max size of the connection pool.
+type ConnPoolSizer interface {
+	// MaxConns returns the max number of connections in the pool, zero means the number is not limited.
+	MaxConns() int
+}
@@ -3,6 +3,7 @@ package libpq
 import (
 	"context"
 	"database/sql"
+	"errors"
 
 	"github.com/vgarvardt/gue/v5/adapter"
 )
@@ -15,7 +16,7 @@ type aRow struct {
 // Scan implements adapter.Row.Scan() using github.com/lib/pq
 func (r *aRow) Scan(dest ...any) error {
 	err := r.row.Scan(dest...)
-	if err == sql.ErrNoRows {
+	if errors.Is(err, sql.ErrNoRows) {
 		return adapter.ErrNoRows
 	}
 
@@ -58,6 +59,12 @@ func (r *aRows) Err() error {
 	return r.rows.Err()
 }
 
+// Close implements adapter.RowsCloser.Close() using github.com/lib/pq
+func (r *aRows) Close() {
+	// reading errors are reported by Err, close error is not actionable for the rows read already or abandoned
+	_ = r.rows.Close()
+}
+
 // aTx implements adapter.Tx using github.com/lib/pq
 type aTx struct {
 	tx *sql.Tx
@@ -99,7 +106,7 @@ func (tx *aTx) Query(ctx context.Context, query string, args ...any) (adapter.Ro
 // Rollback implements adapter.Tx.Rollback() using github.com/lib/pq
 func (tx *aTx) Rollback(_ context.Context) error {
 	err := tx.tx.Rollback()
-	if err == sql.ErrTxDone {
+	if errors.Is(err, sql.ErrTxDone) {
 		return adapter.ErrTxClosed
 	}
 
@@ -197,6 +204,11 @@ func (c *connPool) Acquire(ctx context.Context) (adapter.Conn, error) {
 	return NewConn(cc), err
 }
 
+// MaxConns implements adapter.ConnPoolSizer.MaxConns() using github.com/lib/pq
+func (c *connPool) MaxConns() int {
+	return c.pool.Stats().MaxOpenConnections
+}
+
 // Close implements adapter.ConnPool.Close() using github.com/lib/pq
 func (c *connPool) Close() error {
 	return c.pool.Close()
@@ -2,6 +2,7 @@ package pgxv5
 
 import (
 	"context"
+	"errors"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
@@ -18,7 +19,7 @@ type aRow struct {
 // Scan implements adapter.Row.Scan() using github.com/jackc/pgx/v5
 func (r *aRow) Scan(dest ...any) error {
 	err := r.row.Scan(dest...)
-	if err == pgx.ErrNoRows {
+	if errors.Is(err, pgx.ErrNoRows) {
 		return adapter.ErrNoRows
 	}
 
@@ -55,6 +56,11 @@ func (r *aRows) Err() error {
 	return r.rows.Err()
 }
 
+// Close implements adapter.RowsCloser.Close() using github.com/jackc/pgx/v5
+func (r *aRows) Close() {
+	r.rows.Close()
+}
+
 // aTx implements adapter.Tx using github.com/jackc/pgx/v5
 type aTx struct {
 	tx pgx.Tx
@@ -96,7 +102,7 @@ func (tx *aTx) Query(ctx context.Context, query string, args ...any) (adapter.Ro
 // Rollback implements adapter.Tx.Rollback() using github.com/jackc/pgx/v5
 func (tx *aTx) Rollback(ctx context.Context) error {
 	err := tx.tx.Rollback(ctx)
-	if err == pgx.ErrTxClosed {
+	if errors.Is(err, pgx.ErrTxClosed) {
 		return adapter.ErrTxClosed
 	}
 
@@ -195,6 +201,11 @@ func (c *connPool) Acquire(ctx context.Context) (adapter.Conn, error) {
 	return NewConn(cc), err
 }
 
+// MaxConns implements adapter.ConnPoolSizer.MaxConns() using github.com/jackc/pgx/v5
+func (c *connPool) MaxConns() int {
+	return int(c.pool.Config().MaxConns)
+}
+
 // Close implements adapter.ConnPool.Close() using github.com/jackc/pgx/v5
 func (c *connPool) Close() error {
 	c.pool.Close()
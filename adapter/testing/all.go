@@ -4,6 +4,8 @@ import (
 	"context"
 	"database/sql"
 	"os"
+	"path/filepath"
+	"runtime"
 	"sync"
 	"testing"
 
@@ -34,7 +36,7 @@ var AllAdaptersOpenTestPool = map[string]OpenTestPool{
 func truncateAndClose(t testing.TB, pool adapter.ConnPool) {
 	t.Helper()
 
-	_, err := pool.Exec(context.Background(), "TRUNCATE TABLE gue_jobs")
+	_, err := pool.Exec(context.Background(), "TRUNCATE TABLE gue_jobs, gue_paused, gue_workers, gue_attempts")
 	assert.NoError(t, err)
 
 	err = pool.Close()
@@ -62,7 +64,9 @@ func doApplyMigrations(t testing.TB, schema string) {
 		assert.NoError(t, err)
 	}()
 
-	migrationSQL, err := os.ReadFile("./migrations/schema.sql")
+	// resolve schema path relative to this file, so that tests can be run from any package of the module
+	_, thisFile, _, _ := runtime.Caller(0)
+	migrationSQL, err := os.ReadFile(filepath.Join(filepath.Dir(thisFile), "..", "..", "migrations", "schema.sql"))
 	require.NoError(t, err)
 
 	if schema != "" {
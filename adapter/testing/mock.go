@@ -11,6 +11,7 @@ import (
 var (
 	_ adapter.Row        = &Row{}
 	_ adapter.Rows       = &Rows{}
+	_ adapter.RowsCloser = &Rows{}
 	_ adapter.CommandTag = &CommandTag{}
 	_ adapter.Queryable  = &Queryable{}
 	_ adapter.Tx         = &Tx{}
@@ -63,6 +64,11 @@ func (m *Rows) Err() error {
 	return args.Error(0)
 }
 
+// Close mock implementation of adapter.RowsCloser.Close()
+func (m *Rows) Close() {
+	m.Called()
+}
+
 // Queryable mock implementation of adapter.Queryable
 type Queryable struct {
 	mock.Mock
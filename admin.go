@@ -0,0 +1,229 @@
+package gue
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+)
+
+// JobFilter narrows the set of jobs for inspection and management Client methods. Empty filter matches all jobs.
+type JobFilter struct {
+	// IDs limits jobs to the ones with the given IDs.
+	IDs []ulid.ULID
+	// Queues limits jobs to the ones in the given queues.
+	Queues []string
+	// Types limits jobs to the ones of the given types.
+	Types []string
+	// OnlyFailing limits jobs to the ones that failed at least once.
+	OnlyFailing bool
+	// OnlyReady limits jobs to the ones that are eligible for execution (run_at is in the past).
+	OnlyReady bool
+	// AfterID limits jobs to the ones enqueued after the job with the given ID, this works because job IDs are
+	// lexicographically sortable by their creation time.
+	AfterID ulid.ULID
+	// Limit limits the number of jobs returned by Client.ListJobs, ignored by the management methods.
+	Limit int
+}
+
+// QueueStats is the aggregated state of the single queue.
+type QueueStats struct {
+	// Queue is the name of the queue.
+	Queue string
+	// Ready is the number of jobs that are eligible for execution.
+	Ready int64
+	// Scheduled is the number of jobs scheduled to run in the future.
+	Scheduled int64
+	// Failing is the number of jobs that failed at least once and are waiting for retry.
+	Failing int64
+	// OldestReadyRunAt is the scheduled time of the oldest job that is eligible for execution,
+	// not valid if there are no ready jobs in the queue.
+	OldestReadyRunAt sql.NullTime
+}
+
+// ListJobs returns jobs matching the filter ordered by the ID, e.g. by the enqueue time. Jobs are read without
+// locking, so they can be used for inspection only, e.g. calling Job.Delete or Job.Error on them is not allowed.
+func (c *Client) ListJobs(ctx context.Context, filter JobFilter) ([]*Job, error) {
+	where, args := filter.where(time.Now().UTC())
+
+	query := `SELECT ` + jobColumns + ` FROM ` + c.jobsTable + where + ` ORDER BY job_id ASC`
+	if filter.Limit > 0 {
+		query += ` LIMIT ` + strconv.Itoa(filter.Limit)
+	}
+
+	rows, err := c.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not query jobs: %w", err)
+	}
+	defer closeRows(rows)
+
+	var jobs []*Job
+	for rows.Next() {
+		j := new(Job)
+		if err := rows.Scan(j.scanDest()...); err != nil {
+			return nil, fmt.Errorf("could not scan job: %w", err)
+		}
+		jobs = append(jobs, j)
+	}
+
+	return jobs, rows.Err()
+}
+
+// GetJob returns the job by its ID without locking it, see ListJobs for details.
+// Returns adapter.ErrNoRows if the job is not found.
+func (c *Client) GetJob(ctx context.Context, id ulid.ULID) (*Job, error) {
+	j := new(Job)
+	err := c.pool.QueryRow(ctx, `SELECT `+jobColumns+` FROM `+c.jobsTable+` WHERE job_id = $1`, id.String()).
+		Scan(j.scanDest()...)
+	if err != nil {
+		return nil, err
+	}
+
+	return j, nil
+}
+
+// QueueStats returns stats of all the queues that have jobs, ordered by the queue name.
+func (c *Client) QueueStats(ctx context.Context) ([]QueueStats, error) {
+	rows, err := c.pool.Query(ctx, `SELECT
+  queue,
+  COUNT(*) FILTER (WHERE run_at <= $1),
+  COUNT(*) FILTER (WHERE run_at > $1),
+  COUNT(*) FILTER (WHERE error_count > 0),
+  MIN(run_at) FILTER (WHERE run_at <= $1)
+FROM `+c.jobsTable+`
+GROUP BY queue
+ORDER BY queue`, time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("could not query queue stats: %w", err)
+	}
+	defer closeRows(rows)
+
+	var stats []QueueStats
+	for rows.Next() {
+		var s QueueStats
+		if err := rows.Scan(&s.Queue, &s.Ready, &s.Scheduled, &s.Failing, &s.OldestReadyRunAt); err != nil {
+			return nil, fmt.Errorf("could not scan queue stats: %w", err)
+		}
+		stats = append(stats, s)
+	}
+
+	return stats, rows.Err()
+}
+
+// RetryJobs makes jobs matching the filter eligible for the immediate execution, preserving their error count.
+// Jobs that are being worked at the moment are skipped. Returns the number of affected jobs.
+func (c *Client) RetryJobs(ctx context.Context, filter JobFilter) (int64, error) {
+	return c.RescheduleJobs(ctx, filter, time.Now().UTC())
+}
+
+// RescheduleJobs changes the scheduled time of the jobs matching the filter to runAt.
+// Jobs that are being worked at the moment are skipped. Returns the number of affected jobs.
+func (c *Client) RescheduleJobs(ctx context.Context, filter JobFilter, runAt time.Time) (int64, error) {
+	now := time.Now().UTC()
+	where, args := filter.where(now)
+	n := len(args)
+
+	ct, err := c.pool.Exec(
+		ctx,
+		`UPDATE `+c.jobsTable+` SET run_at = $`+strconv.Itoa(n+1)+`, updated_at = $`+strconv.Itoa(n+2)+`,
+aged_run_at = `+agedRunAtSQL(`$`+strconv.Itoa(n+1), `priority`, n+3)+`
+WHERE job_id IN (SELECT job_id FROM `+c.jobsTable+where+` FOR UPDATE SKIP LOCKED)`,
+		append(args, runAt.UTC(), now, c.priorityAging.Microseconds())...,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("could not reschedule jobs: %w", err)
+	}
+
+	return ct.RowsAffected(), nil
+}
+
+// DeleteJobs deletes jobs matching the filter. Jobs that are being worked at the moment are skipped.
+// Be careful with the empty filter as it matches all the jobs. Returns the number of deleted jobs.
+// Attempts log entries of the deleted jobs are deleted as well unless they are kept for the retention period,
+// see WithClientAttemptsLog.
+func (c *Client) DeleteJobs(ctx context.Context, filter JobFilter) (int64, error) {
+	where, args := filter.where(time.Now().UTC())
+
+	deleteSQL := `DELETE FROM ` + c.jobsTable + `
+WHERE job_id IN (SELECT job_id FROM ` + c.jobsTable + where + ` FOR UPDATE SKIP LOCKED)`
+	if !c.attemptsLog || c.attemptsRetention > 0 {
+		ct, err := c.pool.Exec(ctx, deleteSQL, args...)
+		if err != nil {
+			return 0, fmt.Errorf("could not delete jobs: %w", err)
+		}
+
+		return ct.RowsAffected(), nil
+	}
+
+	var n int64
+	if err := c.pool.QueryRow(ctx, `WITH deleted AS (
+`+deleteSQL+`
+RETURNING job_id
+), deleted_attempts AS (
+DELETE FROM `+c.attemptsTable+` WHERE job_id IN (SELECT job_id FROM deleted)
+)
+SELECT COUNT(*) FROM deleted`, args...).Scan(&n); err != nil {
+		return 0, fmt.Errorf("could not delete jobs: %w", err)
+	}
+
+	return n, nil
+}
+
+// PurgeQueue deletes all the jobs from the queue except for the ones being worked at the moment.
+// Returns the number of deleted jobs.
+func (c *Client) PurgeQueue(ctx context.Context, queue string) (int64, error) {
+	return c.DeleteJobs(ctx, JobFilter{Queues: []string{queue}})
+}
+
+// where builds WHERE SQL clause with positional arguments for the filter. Arrays are not used as not all the
+// drivers support them natively.
+func (f JobFilter) where(now time.Time) (string, []any) {
+	var (
+		conditions []string
+		args       []any
+	)
+
+	in := func(column string, values []string) {
+		placeholders := make([]string, len(values))
+		for i := range values {
+			args = append(args, values[i])
+			placeholders[i] = "$" + strconv.Itoa(len(args))
+		}
+		conditions = append(conditions, column+" IN ("+strings.Join(placeholders, ", ")+")")
+	}
+
+	if len(f.IDs) > 0 {
+		ids := make([]string, len(f.IDs))
+		for i := range f.IDs {
+			ids[i] = f.IDs[i].String()
+		}
+		in("job_id", ids)
+	}
+	if len(f.Queues) > 0 {
+		in("queue", f.Queues)
+	}
+	if len(f.Types) > 0 {
+		in("job_type", f.Types)
+	}
+	if f.OnlyFailing {
+		conditions = append(conditions, "error_count > 0")
+	}
+	if f.OnlyReady {
+		args = append(args, now)
+		conditions = append(conditions, "run_at <= $"+strconv.Itoa(len(args)))
+	}
+	if f.AfterID.Compare(ulid.ULID{}) != 0 {
+		args = append(args, f.AfterID.String())
+		conditions = append(conditions, "job_id > $"+strconv.Itoa(len(args)))
+	}
+
+	if len(conditions) == 0 {
+		return "", nil
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
@@ -0,0 +1,162 @@
+package gue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vgarvardt/gue/v5/adapter"
+	adapterTesting "github.com/vgarvardt/gue/v5/adapter/testing"
+)
+
+func TestJobFilter_where(t *testing.T) {
+	now := time.Now()
+
+	where, args := JobFilter{}.where(now)
+	assert.Empty(t, where)
+	assert.Empty(t, args)
+
+	id := ulid.Make()
+	where, args = JobFilter{
+		IDs:         []ulid.ULID{id},
+		Queues:      []string{"q1", "q2"},
+		Types:       []string{"t1"},
+		OnlyFailing: true,
+		OnlyReady:   true,
+		AfterID:     id,
+		Limit:       10,
+	}.where(now)
+	assert.Equal(
+		t,
+		" WHERE job_id IN ($1) AND queue IN ($2, $3) AND job_type IN ($4) AND error_count > 0 AND run_at <= $5 AND job_id > $6",
+		where,
+	)
+	assert.Equal(t, []any{id.String(), "q1", "q2", "t1", now, id.String()}, args)
+}
+
+// newScanErrorRows returns the rows mock that fails to scan the first row of n columns.
+func newScanErrorRows(n int) *adapterTesting.Rows {
+	scanArgs := make([]any, n)
+	for i := range scanArgs {
+		scanArgs[i] = mock.Anything
+	}
+
+	rows := new(adapterTesting.Rows)
+	rows.On("Next").Return(true)
+	rows.On("Scan", scanArgs...).Return(assert.AnError)
+	rows.On("Close").Return()
+
+	return rows
+}
+
+func TestListJobsScanError(t *testing.T) {
+	ctx := context.Background()
+
+	rows := newScanErrorRows(len((&Job{}).scanDest()))
+
+	pool := new(adapterTesting.ConnPool)
+	pool.Queryable.On("Query", ctx, mock.Anything, mock.Anything).Return(rows, nil)
+
+	c, err := NewClient(pool)
+	require.NoError(t, err)
+
+	_, err = c.ListJobs(ctx, JobFilter{})
+	require.ErrorIs(t, err, assert.AnError)
+
+	// rows are closed, so the connection is not left busy
+	rows.AssertCalled(t, "Close")
+}
+
+func TestClientAdmin(t *testing.T) {
+	for name, openFunc := range adapterTesting.AllAdaptersOpenTestPool {
+		t.Run(name, func(t *testing.T) {
+			testClientAdmin(t, openFunc(t))
+		})
+	}
+}
+
+func testClientAdmin(t *testing.T, connPool adapter.ConnPool) {
+	ctx := context.Background()
+
+	c, err := NewClient(connPool)
+	require.NoError(t, err)
+
+	now := time.Now()
+	queue := "admin-" + RandomStringID()
+
+	ready1 := &Job{Queue: queue, Type: "t1", RunAt: now.Add(-time.Minute)}
+	ready2 := &Job{Queue: queue, Type: "t2"}
+	scheduled := &Job{Queue: queue, Type: "t1", RunAt: now.Add(time.Hour)}
+	err = c.EnqueueBatch(ctx, []*Job{ready1, ready2, scheduled})
+	require.NoError(t, err)
+
+	// make one job failing
+	j, err := c.LockJobByID(ctx, ready2.ID)
+	require.NoError(t, err)
+	err = j.Error(ctx, ErrRescheduleJobAt(now.Add(-time.Second), "test"))
+	require.NoError(t, err)
+
+	stats, err := c.QueueStats(ctx)
+	require.NoError(t, err)
+	var qs *QueueStats
+	for i := range stats {
+		if stats[i].Queue == queue {
+			qs = &stats[i]
+		}
+	}
+	require.NotNil(t, qs)
+	assert.Equal(t, int64(2), qs.Ready)
+	assert.Equal(t, int64(1), qs.Scheduled)
+	assert.Equal(t, int64(1), qs.Failing)
+	require.True(t, qs.OldestReadyRunAt.Valid)
+	assert.WithinDuration(t, ready1.RunAt, qs.OldestReadyRunAt.Time, time.Millisecond)
+
+	jobs, err := c.ListJobs(ctx, JobFilter{Queues: []string{queue}})
+	require.NoError(t, err)
+	require.Len(t, jobs, 3)
+	assert.Equal(t, ready1.ID, jobs[0].ID)
+	assert.Equal(t, ready2.ID, jobs[1].ID)
+	assert.Equal(t, scheduled.ID, jobs[2].ID)
+
+	jobs, err = c.ListJobs(ctx, JobFilter{Queues: []string{queue}, OnlyFailing: true})
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, ready2.ID, jobs[0].ID)
+	assert.True(t, jobs[0].LastError.Valid)
+
+	jobs, err = c.ListJobs(ctx, JobFilter{Queues: []string{queue}, AfterID: ready1.ID, Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, ready2.ID, jobs[0].ID)
+
+	got, err := c.GetJob(ctx, scheduled.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "t1", got.Type)
+
+	_, err = c.GetJob(ctx, ulid.Make())
+	assert.ErrorIs(t, err, adapter.ErrNoRows)
+
+	n, err := c.RetryJobs(ctx, JobFilter{IDs: []ulid.ULID{scheduled.ID}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	// locked job must be skipped by the management methods
+	locked, err := c.LockJobByID(ctx, ready1.ID)
+	require.NoError(t, err)
+
+	n, err = c.DeleteJobs(ctx, JobFilter{Queues: []string{queue}, Types: []string{"t1"}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	err = locked.Done(ctx)
+	require.NoError(t, err)
+
+	n, err = c.PurgeQueue(ctx, queue)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+}
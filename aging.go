@@ -0,0 +1,28 @@
+package gue
+
+import (
+	"strconv"
+	"time"
+)
+
+// defaultPriorityAging is the default wait time that raises the job effective priority by one,
+// see WithClientPriorityAging.
+const defaultPriorityAging = time.Millisecond
+
+// agedRunAtColumn is the aged run at SQL expression PriorityAgingPollStrategy orders jobs by. Aged run at is NULL
+// for the jobs enqueued by the clients not aware of it, e.g. during the rolling upgrade, so they are ordered by
+// the run at as if they had the default priority. Expression must match the aged selector index one.
+const agedRunAtColumn = `COALESCE(aged_run_at, run_at)`
+
+// agedRunAt returns the job run at shifted by its priority, so that jobs ordered by the aged run at are ordered
+// by the effective priority that increases by one every aging duration the job waits after its run at.
+// Lower priority jobs get the later aged run at, the higher priority ones - the earlier.
+func agedRunAt(runAt time.Time, priority JobPriority, aging time.Duration) time.Time {
+	return runAt.Add(time.Duration(priority) * aging)
+}
+
+// agedRunAtSQL is the SQL counterpart of agedRunAt for the run at and priority SQL expressions,
+// agingArg is the positional argument holding the aging duration in microseconds.
+func agedRunAtSQL(runAt, priority string, agingArg int) string {
+	return runAt + ` + ` + priority + ` * $` + strconv.Itoa(agingArg) + `::BIGINT * INTERVAL '1 microsecond'`
+}
@@ -0,0 +1,71 @@
+package gue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vgarvardt/gue/v5/adapter"
+	adapterTesting "github.com/vgarvardt/gue/v5/adapter/testing"
+)
+
+func TestAgedRunAt(t *testing.T) {
+	runAt := time.Date(2023, 7, 1, 12, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, runAt, agedRunAt(runAt, JobPriorityDefault, time.Millisecond))
+	assert.Equal(t, runAt.Add(16384*time.Millisecond), agedRunAt(runAt, JobPriorityLow, time.Millisecond))
+	assert.Equal(t, runAt.Add(-32768*time.Second), agedRunAt(runAt, JobPriorityHighest, time.Second))
+	assert.Equal(t, runAt, agedRunAt(runAt, JobPriorityLowest, 0))
+
+	assert.Equal(t, `$1 + priority * $3::BIGINT * INTERVAL '1 microsecond'`, agedRunAtSQL(`$1`, `priority`, 3))
+}
+
+func TestLockQuery_sqlPriorityAging(t *testing.T) {
+	c, err := NewClient(nil)
+	require.NoError(t, err)
+
+	sql, _ := lockQuery{queue: "q", strategy: PriorityAgingPollStrategy}.sql(c, 1)
+	assert.Contains(t, sql, "ORDER BY COALESCE(aged_run_at, run_at), priority ASC\nLIMIT $3 FOR UPDATE SKIP LOCKED")
+}
+
+func TestPriorityAgingPollStrategy(t *testing.T) {
+	for name, openFunc := range adapterTesting.AllAdaptersOpenTestPool {
+		t.Run(name, func(t *testing.T) {
+			testPriorityAgingPollStrategy(t, openFunc(t))
+		})
+	}
+}
+
+func testPriorityAgingPollStrategy(t *testing.T, connPool adapter.ConnPool) {
+	ctx := context.Background()
+	now := time.Now()
+
+	c, err := NewClient(connPool, WithClientPriorityAging(time.Millisecond))
+	require.NoError(t, err)
+
+	// low priority job waited long enough to overtake the fresh default priority one, but not the high priority one
+	jobs := []*Job{
+		{Type: "MyJob", Args: []byte("default"), Priority: JobPriorityDefault, RunAt: now},
+		{Type: "MyJob", Args: []byte("low"), Priority: JobPriorityLow, RunAt: now.Add(-30 * time.Second)},
+		{Type: "MyJob", Args: []byte("high"), Priority: JobPriorityHigh, RunAt: now},
+	}
+	require.NoError(t, c.EnqueueBatch(ctx, jobs))
+
+	var worked []string
+	wm := WorkMap{
+		"MyJob": func(ctx context.Context, j *Job) error {
+			worked = append(worked, string(j.Args))
+			return nil
+		},
+	}
+	w, err := NewWorker(c, wm, WithWorkerPollStrategy(PriorityAgingPollStrategy))
+	require.NoError(t, err)
+
+	for i := 0; i < len(jobs); i++ {
+		require.True(t, w.WorkOne(ctx))
+	}
+	assert.Equal(t, []string{"high", "low", "default"}, worked)
+}
@@ -0,0 +1,238 @@
+package gue
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+
+	"github.com/vgarvardt/gue/v5/adapter"
+)
+
+const (
+	// attemptsPruneInterval is the interval the client prunes attempts log entries older than the retention period at
+	attemptsPruneInterval = time.Minute
+	// attemptsPruneBatchSize is the max number of attempts log entries deleted by the single prune statement
+	attemptsPruneBatchSize = 1000
+)
+
+// JobAttempt is the Job attempt recorded in the attempts log, see WithClientAttemptsLog.
+type JobAttempt struct {
+	// JobID is the ID of the Job.
+	JobID ulid.ULID
+	// Attempt is the number of the attempt, starting from 1.
+	Attempt int32
+	// WorkerID is the ID of the worker that worked the Job, or the client ID for the jobs worked without worker.
+	WorkerID string
+	// StartedAt is the time the attempt was started at.
+	StartedAt time.Time
+	// FinishedAt is the time the attempt finished at.
+	FinishedAt time.Time
+	// Error is the error message the attempt failed with, for the panicked attempt it is the panic value.
+	// It is empty for the successful attempt.
+	Error string
+	// PanicStack is the stacktrace of the panicked attempt, not valid if the attempt did not panic.
+	PanicStack sql.NullString
+}
+
+// panicError is the error the panicked Job is errored with. Its message is the panic value together with
+// the stacktrace, so that both of them are stored into Job last_error.
+type panicError struct {
+	value      string
+	stacktrace string
+}
+
+func newPanicError(r any, stacktrace string) *panicError {
+	return &panicError{value: fmt.Sprint(r), stacktrace: stacktrace}
+}
+
+// Error implements error.Error()
+func (e *panicError) Error() string {
+	return e.stacktrace
+}
+
+// ListJobAttempts returns the attempts of the job recorded in the attempts log ordered by the attempt number.
+// Attempts are recorded only when the attempts log is enabled with WithClientAttemptsLog.
+func (c *Client) ListJobAttempts(ctx context.Context, id ulid.ULID) ([]JobAttempt, error) {
+	rows, err := c.pool.Query(ctx, `SELECT job_id, attempt, worker_id, started_at, finished_at, error, panic_stack
+FROM `+c.attemptsTable+`
+WHERE job_id = $1
+ORDER BY attempt ASC`, id.String())
+	if err != nil {
+		return nil, fmt.Errorf("could not query job attempts: %w", err)
+	}
+	defer closeRows(rows)
+
+	var attempts []JobAttempt
+	for rows.Next() {
+		var a JobAttempt
+		if err := rows.Scan(
+			&a.JobID, &a.Attempt, &a.WorkerID, &a.StartedAt, &a.FinishedAt, &a.Error, &a.PanicStack,
+		); err != nil {
+			return nil, fmt.Errorf("could not scan job attempt: %w", err)
+		}
+		attempts = append(attempts, a)
+	}
+
+	return attempts, rows.Err()
+}
+
+// PruneJobAttempts deletes attempts log entries of the attempts finished before the given time. Entries older than
+// the attempts log retention period are pruned automatically while the client workers are running,
+// see WithClientAttemptsLog.
+// Returns the number of deleted entries.
+func (c *Client) PruneJobAttempts(ctx context.Context, before time.Time) (int64, error) {
+	var total int64
+	for {
+		// delete in batches skipping the locked entries, so that several workers may prune concurrently
+		ct, err := c.pool.Exec(ctx, `DELETE FROM `+c.attemptsTable+`
+WHERE (job_id, attempt) IN (
+  SELECT job_id, attempt FROM `+c.attemptsTable+` WHERE finished_at < $1 LIMIT $2 FOR UPDATE SKIP LOCKED
+)`, before.UTC(), attemptsPruneBatchSize)
+		if err != nil {
+			return total, fmt.Errorf("could not prune job attempts: %w", err)
+		}
+
+		total += ct.RowsAffected()
+		if ct.RowsAffected() < attemptsPruneBatchSize {
+			return total, nil
+		}
+	}
+}
+
+// startAttempt sets the attempt details recorded to the attempts log when the attempt finishes.
+func (j *Job) startAttempt(workerID string, startedAt time.Time) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.workerID = workerID
+	j.startedAt = startedAt
+}
+
+// logAttempt records the attempt to the attempts log in the job transaction, jErr is nil for the successful attempt.
+// It is a no-op if the attempts log is disabled. Must be called with Job.mu held.
+func (j *Job) logAttempt(ctx context.Context, attempt int32, jErr error, finishedAt time.Time) error {
+	if j.attemptsTable == "" {
+		return nil
+	}
+
+	startedAt := j.startedAt
+	if startedAt.IsZero() {
+		startedAt = finishedAt
+	}
+
+	var (
+		msg   string
+		stack sql.NullString
+		pErr  *panicError
+	)
+	if errors.As(jErr, &pErr) {
+		msg, stack = pErr.value, sql.NullString{String: pErr.stacktrace, Valid: true}
+	} else if jErr != nil {
+		msg = jErr.Error()
+	}
+
+	// attempt may be logged already if the job changes were not committed, e.g. the worker crashed
+	if err := j.exec(ctx, false, `INSERT INTO `+j.attemptsTable+`
+(job_id, attempt, worker_id, started_at, finished_at, error, panic_stack)
+VALUES
+($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (job_id, attempt) DO UPDATE
+SET worker_id = excluded.worker_id, started_at = excluded.started_at, finished_at = excluded.finished_at,
+    error = excluded.error, panic_stack = excluded.panic_stack`,
+		j.ID.String(), attempt, j.workerID, startedAt.UTC(), finishedAt, msg, stack,
+	); err != nil {
+		return fmt.Errorf("could not log job attempt: %w", err)
+	}
+
+	return nil
+}
+
+// logSucceeded records the successful attempt of the worked job to the attempts log in the job transaction.
+// It is a no-op unless the attempts are kept after the job is deleted, as otherwise they are deleted with the job.
+func (j *Job) logSucceeded(ctx context.Context) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if !j.attemptsKept || j.tx == nil {
+		return nil
+	}
+
+	return j.logAttempt(ctx, j.ErrorCount+1, nil, time.Now().UTC())
+}
+
+// attemptsPruner is the attempts log pruner shared by all the workers using the same client,
+// so that there is a single pruner per client and not per worker.
+type attemptsPruner struct {
+	mu     sync.Mutex
+	users  int
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// acquireAttemptsPruner starts the client attempts log pruner if it is not running yet and returns the function
+// releasing it. Pruner is stopped when the last worker using it releases it.
+func (c *Client) acquireAttemptsPruner() (release func()) {
+	p := &c.attemptsPruner
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.users++
+	if p.users == 1 {
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		p.cancel, p.done = cancel, done
+		go func() {
+			defer close(done)
+			c.pruneAttempts(ctx)
+		}()
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			p.mu.Lock()
+			p.users--
+			if p.users > 0 {
+				p.mu.Unlock()
+				return
+			}
+			cancel, done := p.cancel, p.done
+			p.cancel, p.done = nil, nil
+			p.mu.Unlock()
+
+			cancel()
+			<-done
+		})
+	}
+}
+
+// pruneAttempts periodically prunes attempts log entries older than the client attempts log retention period
+// until the ctx is done.
+func (c *Client) pruneAttempts(ctx context.Context) {
+	ticker := time.NewTicker(attemptsPruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := c.PruneJobAttempts(ctx, time.Now().Add(-c.attemptsRetention))
+			if err != nil {
+				if ctx.Err() == nil {
+					c.logger.Error("Client failed to prune job attempts", adapter.Err(err))
+				}
+				continue
+			}
+			if n > 0 {
+				c.logger.Debug("Client pruned job attempts", adapter.F("pruned", n))
+			}
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package gue
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vgarvardt/gue/v5/adapter"
+	adapterTesting "github.com/vgarvardt/gue/v5/adapter/testing"
+)
+
+func TestJob_logAttempt(t *testing.T) {
+	ctx := context.Background()
+	startedAt := time.Now().Add(-time.Second)
+	finishedAt := time.Now()
+
+	for name, tc := range map[string]struct {
+		err   error
+		msg   string
+		stack sql.NullString
+	}{
+		"error": {
+			err:   errors.New("the error msg"),
+			msg:   "the error msg",
+			stack: sql.NullString{},
+		},
+		"panic": {
+			err:   newPanicError("the panic msg", "the panic msg\ngoroutine 1 [running]:\n"),
+			msg:   "the panic msg",
+			stack: sql.NullString{String: "the panic msg\ngoroutine 1 [running]:\n", Valid: true},
+		},
+		"success": {
+			err:   nil,
+			msg:   "",
+			stack: sql.NullString{},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			tx := new(adapterTesting.Tx)
+			tx.Queryable.On(
+				"Exec", ctx, mock.Anything,
+				[]any{"01H5Q7BZ7CDGA3KQWQ7PGXF8DK", int32(3), "worker-1", startedAt.UTC(), finishedAt, tc.msg, tc.stack},
+			).Return(nil, nil).Once()
+
+			j := &Job{ID: ulid.MustParse("01H5Q7BZ7CDGA3KQWQ7PGXF8DK"), tx: tx, attemptsTable: `"gue_attempts"`}
+			j.startAttempt("worker-1", startedAt)
+
+			require.NoError(t, j.logAttempt(ctx, 3, tc.err, finishedAt))
+			tx.Queryable.AssertExpectations(t)
+		})
+	}
+
+	// attempts log is disabled
+	tx := new(adapterTesting.Tx)
+	j := &Job{ID: ulid.MustParse("01H5Q7BZ7CDGA3KQWQ7PGXF8DK"), tx: tx}
+	require.NoError(t, j.logAttempt(ctx, 1, errors.New("the error msg"), finishedAt))
+	tx.Queryable.AssertNotCalled(t, "Exec", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestJob_logSucceeded(t *testing.T) {
+	ctx := context.Background()
+
+	for name, kept := range map[string]bool{"kept": true, "deleted with job": false} {
+		t.Run(name, func(t *testing.T) {
+			tx := new(adapterTesting.Tx)
+			tx.Queryable.On("Exec", ctx, mock.Anything, mock.MatchedBy(func(args []any) bool {
+				return args[1] == int32(3) && args[5] == ""
+			})).Return(nil, nil)
+
+			j := &Job{
+				ID:            ulid.MustParse("01H5Q7BZ7CDGA3KQWQ7PGXF8DK"),
+				ErrorCount:    2,
+				tx:            tx,
+				attemptsTable: `"gue_attempts"`,
+				attemptsKept:  kept,
+			}
+			require.NoError(t, j.logSucceeded(ctx))
+
+			if kept {
+				tx.Queryable.AssertNumberOfCalls(t, "Exec", 1)
+			} else {
+				tx.Queryable.AssertNotCalled(t, "Exec", mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestClient_acquireAttemptsPruner(t *testing.T) {
+	c, err := NewClient(nil, WithClientAttemptsLog(time.Hour))
+	require.NoError(t, err)
+
+	release1 := c.acquireAttemptsPruner()
+	done := c.attemptsPruner.done
+	require.NotNil(t, done)
+
+	// the second worker shares the running pruner
+	release2 := c.acquireAttemptsPruner()
+	assert.Equal(t, done, c.attemptsPruner.done)
+	assert.Equal(t, 2, c.attemptsPruner.users)
+
+	release1()
+	release1()
+	assert.Equal(t, 1, c.attemptsPruner.users)
+	select {
+	case <-done:
+		t.Fatal("pruner stopped while still used")
+	default:
+	}
+
+	release2()
+	assert.Equal(t, 0, c.attemptsPruner.users)
+	assert.Nil(t, c.attemptsPruner.done)
+	<-done
+}
+
+func TestPanicError(t *testing.T) {
+	err := newPanicError(errors.New("the panic msg"), "the panic msg\nstacktrace\n[...]\n")
+	assert.Equal(t, "the panic msg\nstacktrace\n[...]\n", err.Error())
+	assert.Equal(t, "the panic msg", err.value)
+}
+
+func TestJobAttemptsLog(t *testing.T) {
+	for name, openFunc := range adapterTesting.AllAdaptersOpenTestPool {
+		t.Run(name, func(t *testing.T) {
+			testJobAttemptsLog(t, openFunc(t))
+		})
+	}
+}
+
+func testJobAttemptsLog(t *testing.T, connPool adapter.ConnPool) {
+	ctx := context.Background()
+
+	c, err := NewClient(connPool, WithClientAttemptsLog(0), WithClientBackoff(NewConstantBackoff(0)))
+	require.NoError(t, err)
+
+	calls := 0
+	wm := WorkMap{
+		"MyJob": func(ctx context.Context, j *Job) error {
+			calls++
+			switch calls {
+			case 1:
+				return errors.New("the first error")
+			case 2:
+				panic("the panic msg")
+			default:
+				return nil
+			}
+		},
+	}
+	w, err := NewWorker(c, wm, WithWorkerID("attempts-worker"))
+	require.NoError(t, err)
+
+	j := &Job{Type: "MyJob"}
+	require.NoError(t, c.Enqueue(ctx, j))
+
+	require.True(t, w.WorkOne(ctx))
+	require.True(t, w.WorkOne(ctx))
+
+	attempts, err := c.ListJobAttempts(ctx, j.ID)
+	require.NoError(t, err)
+	require.Len(t, attempts, 2)
+
+	assert.Equal(t, j.ID, attempts[0].JobID)
+	assert.Equal(t, int32(1), attempts[0].Attempt)
+	assert.Equal(t, "attempts-worker", attempts[0].WorkerID)
+	assert.Equal(t, "the first error", attempts[0].Error)
+	assert.False(t, attempts[0].PanicStack.Valid)
+	assert.False(t, attempts[0].FinishedAt.Before(attempts[0].StartedAt))
+
+	assert.Equal(t, int32(2), attempts[1].Attempt)
+	assert.Equal(t, "the panic msg", attempts[1].Error)
+	require.True(t, attempts[1].PanicStack.Valid)
+	assert.Contains(t, attempts[1].PanicStack.String, "the panic msg")
+
+	// completed job attempts are deleted together with the job
+	require.True(t, w.WorkOne(ctx))
+	attempts, err = c.ListJobAttempts(ctx, j.ID)
+	require.NoError(t, err)
+	assert.Empty(t, attempts)
+
+	// attempts of the jobs deleted with the admin routine are deleted as well
+	calls = 0
+	j = &Job{Type: "MyJob"}
+	require.NoError(t, c.Enqueue(ctx, j))
+	require.True(t, w.WorkOne(ctx))
+
+	n, err := c.DeleteJobs(ctx, JobFilter{IDs: []ulid.ULID{j.ID}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	attempts, err = c.ListJobAttempts(ctx, j.ID)
+	require.NoError(t, err)
+	assert.Empty(t, attempts)
+}
+
+func TestJobAttemptsLogRetention(t *testing.T) {
+	for name, openFunc := range adapterTesting.AllAdaptersOpenTestPool {
+		t.Run(name, func(t *testing.T) {
+			testJobAttemptsLogRetention(t, openFunc(t))
+		})
+	}
+}
+
+func testJobAttemptsLogRetention(t *testing.T, connPool adapter.ConnPool) {
+	ctx := context.Background()
+
+	c, err := NewClient(connPool, WithClientAttemptsLog(time.Hour), WithClientBackoff(BackoffNever))
+	require.NoError(t, err)
+
+	wm := WorkMap{
+		"MyJob": func(ctx context.Context, j *Job) error {
+			return errors.New("the error msg")
+		},
+	}
+	w, err := NewWorker(c, wm)
+	require.NoError(t, err)
+
+	j := &Job{Type: "MyJob"}
+	require.NoError(t, c.Enqueue(ctx, j))
+
+	// successful job attempts are kept for the retention period
+	succeeded := &Job{Type: "MySucceededJob"}
+	require.NoError(t, c.Enqueue(ctx, succeeded))
+	sw, err := NewWorker(
+		c, WorkMap{"MySucceededJob": func(ctx context.Context, j *Job) error { return nil }}, WithWorkerKnownTypesOnly(),
+	)
+	require.NoError(t, err)
+	require.True(t, sw.WorkOne(ctx))
+
+	attempts, err := c.ListJobAttempts(ctx, succeeded.ID)
+	require.NoError(t, err)
+	require.Len(t, attempts, 1)
+	assert.Equal(t, int32(1), attempts[0].Attempt)
+	assert.Empty(t, attempts[0].Error)
+
+	// discarded job attempts are kept for the retention period
+	require.True(t, w.WorkOne(ctx))
+	_, err = c.GetJob(ctx, j.ID)
+	require.ErrorIs(t, err, adapter.ErrNoRows)
+
+	attempts, err = c.ListJobAttempts(ctx, j.ID)
+	require.NoError(t, err)
+	require.Len(t, attempts, 1)
+	assert.Equal(t, "the error msg", attempts[0].Error)
+
+	n, err := c.PruneJobAttempts(ctx, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), n)
+
+	n, err = c.PruneJobAttempts(ctx, time.Now().Add(time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	attempts, err = c.ListJobAttempts(ctx, j.ID)
+	require.NoError(t, err)
+	assert.Empty(t, attempts)
+}
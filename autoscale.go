@@ -0,0 +1,184 @@
+package gue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vgarvardt/gue/v5/adapter"
+)
+
+const (
+	defaultAutoscaleInterval = 10 * time.Second
+
+	// autoscaleUpIdleRatio is the idle polls ratio below which pool is scaled up if there are ready jobs in the queue,
+	// i.e. most of the polls found a job to work on and there are still jobs waiting.
+	autoscaleUpIdleRatio = 0.5
+	// autoscaleDownIdleRatio is the idle polls ratio starting from which pool is scaled down if the queue is empty.
+	autoscaleDownIdleRatio = 0.9
+)
+
+// autoscaleConfig is the worker pool autoscaling configuration, see WithPoolAutoscale.
+type autoscaleConfig struct {
+	enabled  bool
+	min      int
+	max      int
+	interval time.Duration
+
+	// polls and idle polls numbers at the previous evaluation
+	polls     int64
+	idlePolls int64
+}
+
+func (a autoscaleConfig) validate() error {
+	if a.min < 1 {
+		return errors.New("autoscaling min workers number must be positive")
+	}
+	if a.max < a.min {
+		return errors.New("autoscaling max workers number must not be less than min")
+	}
+	if a.interval <= 0 {
+		return errors.New("autoscaling interval must be positive")
+	}
+
+	return nil
+}
+
+// clamp returns size limited by min and max workers number. Max is additionally limited by the DB connection pool
+// size, as every worker holds the connection while working on the job, one connection is left for the other needs,
+// e.g. enqueueing jobs. Connection pool limit wins over min workers number, see autoscaleConfig.validateConns.
+func (a autoscaleConfig) clamp(size, maxConns int) int {
+	if size < a.min {
+		size = a.min
+	}
+	if size > a.max {
+		size = a.max
+	}
+	if maxConns > 0 && size > maxConns-1 {
+		size = maxConns - 1
+	}
+
+	return size
+}
+
+// validateConns checks that the DB connection pool is big enough to run min workers number, see clamp.
+func (a autoscaleConfig) validateConns(maxConns int) error {
+	if maxConns > 0 && maxConns-1 < a.min {
+		return fmt.Errorf(
+			"autoscaling min workers number %d requires at least %d DB connections, connection pool size is %d",
+			a.min, a.min+1, maxConns,
+		)
+	}
+
+	return nil
+}
+
+// maxConns returns the DB connection pool size if the pool adapter reports it, zero otherwise.
+func (w *WorkerPool) maxConns() int {
+	if sizer, ok := w.c.pool.(adapter.ConnPoolSizer); ok {
+		return sizer.MaxConns()
+	}
+
+	return 0
+}
+
+// runAutoscaler evaluates pool size every autoscaling interval until the ctx is done.
+func (w *WorkerPool) runAutoscaler(ctx context.Context) {
+	ticker := time.NewTicker(w.autoscale.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := w.autoscaleOnce(ctx); err != nil {
+				w.logger.Error("Worker pool failed to autoscale", adapter.Err(err))
+			}
+		}
+	}
+}
+
+// autoscaleOnce scales the pool based on the number of ready jobs in the queue and the ratio of the workers polls
+// that found no job since the previous evaluation. Pool is scaled up by the half of its size at most, but not more
+// than the number of ready jobs, and is scaled down by one worker at a time.
+func (w *WorkerPool) autoscaleOnce(ctx context.Context) error {
+	// all the pool workers are configured the same way, so the first one is enough to build the ready jobs query
+	w.sizeMu.Lock()
+	q, ok := w.workers[0].readyQuery()
+	w.sizeMu.Unlock()
+
+	var ready int
+	if ok {
+		var err error
+		if ready, err = w.c.readyJobsCount(ctx, q, w.autoscale.max); err != nil {
+			return err
+		}
+	}
+
+	w.sizeMu.Lock()
+	defer w.sizeMu.Unlock()
+
+	var polls, idlePolls int64
+	for _, worker := range w.workers {
+		polls += worker.polls.Load()
+		idlePolls += worker.idlePolls.Load()
+	}
+
+	deltaPolls, deltaIdlePolls := polls-w.autoscale.polls, idlePolls-w.autoscale.idlePolls
+	w.autoscale.polls, w.autoscale.idlePolls = polls, idlePolls
+
+	// no polls at all means that all the workers are busy working on the long-running jobs
+	var idleRatio float64
+	if deltaPolls > 0 {
+		idleRatio = float64(deltaIdlePolls) / float64(deltaPolls)
+	}
+
+	size := w.size
+	switch {
+	case ready > 0 && idleRatio < autoscaleUpIdleRatio:
+		step := size / 2
+		if step < 1 {
+			step = 1
+		}
+		if step > ready {
+			step = ready
+		}
+		size += step
+	case ready == 0 && idleRatio >= autoscaleDownIdleRatio:
+		size--
+	}
+
+	size = w.autoscale.clamp(size, w.maxConns())
+	if size == w.size {
+		return nil
+	}
+
+	w.logger.Info(
+		"Worker pool autoscaled",
+		adapter.F("from", w.size),
+		adapter.F("to", size),
+		adapter.F("ready-jobs", ready),
+		adapter.F("idle-ratio", idleRatio),
+	)
+	return w.resize(size)
+}
+
+// readyJobsCount returns the number of jobs matching the lock query that are eligible for execution, i.e. the ones
+// the lock query would lock, counting stops at limit.
+func (c *Client) readyJobsCount(ctx context.Context, q lockQuery, limit int) (int, error) {
+	where, args := q.where(c, limit)
+
+	var count int
+	err := c.pool.QueryRow(
+		ctx,
+		`SELECT COUNT(*) FROM (SELECT 1 FROM `+c.jobsTable+` WHERE `+where+` LIMIT $3) ready`,
+		args...,
+	).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("could not count ready jobs: %w", migrateHint(err))
+	}
+
+	return count, nil
+}
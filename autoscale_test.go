@@ -0,0 +1,201 @@
+package gue
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	adapterTesting "github.com/vgarvardt/gue/v5/adapter/testing"
+)
+
+func TestAutoscaleConfig_clamp(t *testing.T) {
+	a := autoscaleConfig{enabled: true, min: 2, max: 10, interval: defaultAutoscaleInterval}
+	require.NoError(t, a.validate())
+
+	assert.Equal(t, 2, a.clamp(0, 0))
+	assert.Equal(t, 5, a.clamp(5, 0))
+	assert.Equal(t, 10, a.clamp(50, 0))
+	assert.Equal(t, 7, a.clamp(50, 8))
+	// connection pool limit wins over min
+	assert.Equal(t, 1, a.clamp(50, 2))
+	assert.Equal(t, 1, a.clamp(0, 2))
+
+	assert.NoError(t, a.validateConns(0))
+	assert.NoError(t, a.validateConns(3))
+	assert.Error(t, a.validateConns(2))
+
+	assert.Error(t, autoscaleConfig{min: 0, max: 1, interval: 1}.validate())
+	assert.Error(t, autoscaleConfig{min: 2, max: 1, interval: 1}.validate())
+	assert.Error(t, autoscaleConfig{min: 1, max: 1}.validate())
+}
+
+type sizedConnPool struct {
+	*adapterTesting.ConnPool
+	maxConns int
+}
+
+func (p sizedConnPool) MaxConns() int {
+	return p.maxConns
+}
+
+func TestNewWorkerPool_autoscaleConns(t *testing.T) {
+	c, err := NewClient(sizedConnPool{ConnPool: new(adapterTesting.ConnPool), maxConns: 3})
+	require.NoError(t, err)
+
+	wp, err := NewWorkerPool(c, dummyWM, 10, WithPoolAutoscale(2, 10))
+	require.NoError(t, err)
+	assert.Equal(t, 2, wp.Size())
+
+	_, err = NewWorkerPool(c, dummyWM, 10, WithPoolAutoscale(3, 10))
+	assert.ErrorContains(t, err, "requires at least 4 DB connections")
+}
+
+func TestWorkerPool_autoscaleOnce(t *testing.T) {
+	ctx := context.Background()
+
+	ready := 0
+	row := new(adapterTesting.Row)
+	row.On("Scan", mock.Anything).Run(func(args mock.Arguments) {
+		*args.Get(0).(*int) = ready
+	}).Return(nil)
+
+	pool := new(adapterTesting.ConnPool)
+	// ready jobs are counted with the same conditions the jobs are locked with
+	pool.Queryable.On("QueryRow", ctx, mock.MatchedBy(func(sql string) bool {
+		return strings.Contains(sql, `FROM "gue_paused"`) && strings.Contains(sql, `job_type NOT IN ($4)`)
+	}), mock.MatchedBy(func(args []any) bool {
+		return len(args) == 4 && args[0] == "" && args[2] == 4 && args[3] == "denied"
+	})).Return(row)
+
+	c, err := NewClient(pool)
+	require.NoError(t, err)
+
+	wp, err := NewWorkerPool(c, dummyWM, 1, WithPoolAutoscale(1, 4), WithPoolDeniedTypes("denied"))
+	require.NoError(t, err)
+	require.Equal(t, 1, wp.Size())
+
+	// busy workers and jobs waiting - scale up
+	ready = 10
+	wp.workers[0].polls.Add(10)
+	require.NoError(t, wp.autoscaleOnce(ctx))
+	assert.Equal(t, 2, wp.Size())
+
+	ready = 10
+	wp.workers[0].polls.Add(10)
+	require.NoError(t, wp.autoscaleOnce(ctx))
+	assert.Equal(t, 3, wp.Size())
+
+	// scale up is limited by max workers number
+	for i := 0; i < 3; i++ {
+		wp.workers[0].polls.Add(10)
+		require.NoError(t, wp.autoscaleOnce(ctx))
+	}
+	assert.Equal(t, 4, wp.Size())
+	assert.Len(t, wp.workers, 4)
+
+	// jobs waiting, but most of the polls are idle, e.g. jobs are of the paused type - no scaling
+	wp.workers[0].polls.Add(10)
+	wp.workers[0].idlePolls.Add(8)
+	require.NoError(t, wp.autoscaleOnce(ctx))
+	assert.Equal(t, 4, wp.Size())
+
+	// no jobs and idle workers - scale down one by one to the min workers number
+	ready = 0
+	for _, expected := range []int{3, 2, 1, 1} {
+		wp.workers[0].polls.Add(10)
+		wp.workers[0].idlePolls.Add(10)
+		require.NoError(t, wp.autoscaleOnce(ctx))
+		assert.Equal(t, expected, wp.Size())
+	}
+}
+
+func TestWorker_readyQuery(t *testing.T) {
+	c, err := NewClient(nil)
+	require.NoError(t, err)
+
+	batchFn := func(ctx context.Context, jobs []*Job) []error { return nil }
+
+	w, err := NewWorker(
+		c, WorkMap{"MyJob": dummyWM["MyJob"], "denied": dummyWM["MyJob"]},
+		WithWorkerQueue("queue"),
+		WithWorkerAllowedTypes("MyJob", "batch"),
+		WithWorkerDeniedTypes("denied"),
+		WithWorkerBatchWorkFunc("batch", batchFn, 10, time.Second),
+	)
+	require.NoError(t, err)
+
+	q, ok := w.readyQuery()
+	require.True(t, ok)
+	assert.Equal(t, lockQuery{queue: "queue", types: []string{"MyJob", "batch"}, excludeTypes: []string{"denied"}}, q)
+
+	w, err = NewWorker(
+		c, WorkMap{"MyJob": dummyWM["MyJob"], "denied": dummyWM["MyJob"]},
+		WithWorkerQueue("queue"),
+		WithWorkerKnownTypesOnly(),
+		WithWorkerDeniedTypes("denied"),
+		WithWorkerBatchWorkFunc("batch", batchFn, 10, time.Second),
+	)
+	require.NoError(t, err)
+
+	q, ok = w.readyQuery()
+	require.True(t, ok)
+	assert.Equal(t, lockQuery{queue: "queue", types: []string{"MyJob", "batch"}}, q)
+
+	// no known types to lock
+	w, err = NewWorker(c, WorkMap{}, WithWorkerKnownTypesOnly())
+	require.NoError(t, err)
+
+	_, ok = w.readyQuery()
+	assert.False(t, ok)
+}
+
+func TestWorkerPool_resizeRunning(t *testing.T) {
+	pool := new(adapterTesting.ConnPool)
+	pool.On("Begin", mock.Anything).Return(nil, errors.New("db is down"))
+	pool.Queryable.On("Query", mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("db is down"))
+
+	c, err := NewClient(pool)
+	require.NoError(t, err)
+
+	wp, err := NewWorkerPool(c, dummyWM, 1, WithPoolPollInterval(10*time.Millisecond))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- wp.Run(ctx)
+	}()
+
+	isRunning := func(w *Worker) bool {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		return w.running
+	}
+
+	require.Eventually(t, func() bool { return isRunning(wp.workers[0]) }, time.Second, time.Millisecond)
+
+	require.NoError(t, wp.SetSize(3))
+
+	require.Eventually(t, func() bool {
+		return isRunning(wp.workers[1]) && isRunning(wp.workers[2])
+	}, time.Second, time.Millisecond)
+
+	require.NoError(t, wp.SetSize(1))
+
+	require.Eventually(t, func() bool {
+		return !isRunning(wp.workers[1]) && !isRunning(wp.workers[2])
+	}, time.Second, time.Millisecond)
+	assert.True(t, isRunning(wp.workers[0]))
+
+	cancel()
+	require.NoError(t, <-done)
+	for _, w := range wp.workers {
+		assert.False(t, isRunning(w))
+	}
+}
@@ -1,15 +1,30 @@
 package gue
 
 import (
+	"math"
+	"math/rand"
 	"time"
 
 	exp "github.com/vgarvardt/backoff"
+
+	"github.com/vgarvardt/gue/v5/adapter"
 )
 
 // Backoff is the interface for backoff implementation that will be used to reschedule errored jobs to a later time.
 // If the Backoff implementation returns negative duration - the job will be discarded.
 type Backoff func(retries int) time.Duration
 
+// JobBackoff is the Backoff implementation that can see the failed Job and the error it failed with, e.g. to use
+// longer delays for the rate-limited calls. If it returns negative duration - the job will be discarded.
+type JobBackoff func(j *Job, err error, retries int) time.Duration
+
+// AsJobBackoff adapts the Backoff to the JobBackoff signature, e.g. to set it for the job type or queue.
+func AsJobBackoff(b Backoff) JobBackoff {
+	return func(_ *Job, _ error, retries int) time.Duration {
+		return b(retries)
+	}
+}
+
 var (
 	// DefaultExponentialBackoff is the exponential Backoff implementation with default config applied
 	DefaultExponentialBackoff = NewExponentialBackoff(exp.Config{
@@ -31,10 +46,138 @@ func NewExponentialBackoff(cfg exp.Config) Backoff {
 	return exp.Exponential{Config: cfg}.Backoff
 }
 
-// NewConstantBackoff instantiates new backoff implementation with teh constant retry duration that does not depend
+// NewConstantBackoff instantiates new backoff implementation with the constant retry duration that does not depend
 // on the retry.
 func NewConstantBackoff(d time.Duration) Backoff {
 	return func(int) time.Duration {
 		return d
 	}
 }
+
+// NewLinearBackoff instantiates new Backoff implementation with the retry duration growing by step with every retry,
+// starting from the initial one. Duration is capped at maxDelay if it is positive.
+func NewLinearBackoff(initial, step, maxDelay time.Duration) Backoff {
+	return func(retries int) time.Duration {
+		if retries < 1 {
+			retries = 1
+		}
+
+		return capDelay(initial+step*time.Duration(retries-1), maxDelay)
+	}
+}
+
+// NewFibonacciBackoff instantiates new Backoff implementation with the retry duration growing as Fibonacci sequence
+// of the base durations - base, base, 2*base, 3*base, 5*base and so on. Duration is capped at maxDelay if it is positive.
+func NewFibonacciBackoff(base, maxDelay time.Duration) Backoff {
+	return func(retries int) time.Duration {
+		prev, cur := time.Duration(0), base
+		for i := 1; i < retries; i++ {
+			if (maxDelay > 0 && cur >= maxDelay) || prev+cur < cur {
+				// capped or overflowed
+				break
+			}
+			prev, cur = cur, prev+cur
+		}
+
+		return capDelay(cur, maxDelay)
+	}
+}
+
+// NewDecorrelatedJitterBackoff instantiates new JobBackoff implementation with the "decorrelated jitter" algorithm -
+// the random retry duration between base and three times the previous retry duration. Previous duration is derived
+// from the failed job as the time it was rescheduled for at its last failure, base is used for the first retry.
+// Duration is capped at maxDelay if it is positive.
+func NewDecorrelatedJitterBackoff(base, maxDelay time.Duration) JobBackoff {
+	return func(j *Job, _ error, _ int) time.Duration {
+		prev := base
+		if j.ErrorCount > 0 {
+			// failed job is rescheduled to run at the backoff duration since it was updated
+			if d := j.RunAt.Sub(j.UpdatedAt); d > prev {
+				prev = d
+			}
+		}
+
+		upper := prev * 3
+		if prev > math.MaxInt64/3 {
+			// not capped and would overflow
+			upper = math.MaxInt64
+		}
+		upper = capDelay(upper, maxDelay)
+
+		if upper <= base {
+			return upper
+		}
+		return base + time.Duration(rand.Int63n(int64(upper-base)))
+	}
+}
+
+// NewScheduleBackoff instantiates new Backoff implementation that takes the retry durations from the schedule,
+// e.g. 1 minute, 5 minutes, 30 minutes, 2 hours. The last duration is used for all the retries after the schedule end,
+// use BackoffWithMaxRetries to discard the job instead.
+func NewScheduleBackoff(schedule ...time.Duration) Backoff {
+	return func(retries int) time.Duration {
+		if len(schedule) == 0 {
+			return -1
+		}
+
+		idx := retries - 1
+		if idx < 0 {
+			idx = 0
+		}
+		if idx >= len(schedule) {
+			idx = len(schedule) - 1
+		}
+
+		return schedule[idx]
+	}
+}
+
+// BackoffWithMaxRetries wraps the Backoff so that the job is discarded after it failed maxRetries times.
+func BackoffWithMaxRetries(b Backoff, maxRetries int) Backoff {
+	return func(retries int) time.Duration {
+		if retries > maxRetries {
+			return -1
+		}
+
+		return b(retries)
+	}
+}
+
+func capDelay(d, maxDelay time.Duration) time.Duration {
+	if maxDelay > 0 && d > maxDelay {
+		return maxDelay
+	}
+
+	return d
+}
+
+// backoffFor resolves the backoff applied to the failed job - the one the job was enqueued with, the job type
+// and the job queue ones and the client default one, in this order.
+func (c *Client) backoffFor(j *Job, err error, retries int) time.Duration {
+	if j.BackoffName != "" {
+		if b, ok := c.namedBackoffs[j.BackoffName]; ok {
+			return b(j, err, retries)
+		}
+
+		// log every unknown name only once, as all the failed jobs enqueued with it would log it otherwise
+		if _, logged := c.unknownBackoffs.LoadOrStore(j.BackoffName, struct{}{}); !logged {
+			c.logger.Error(
+				"Job backoff is not registered, falling back to the default one",
+				adapter.F("job-id", j.ID.String()),
+				adapter.F("backoff-name", j.BackoffName),
+			)
+		}
+	}
+
+	if b, ok := c.typeBackoffs[j.Type]; ok {
+		return b(j, err, retries)
+	}
+	if b, ok := c.queueBackoffs[j.Queue]; ok {
+		return b(j, err, retries)
+	}
+	if c.jobBackoff != nil {
+		return c.jobBackoff(j, err, retries)
+	}
+
+	return c.backoff(retries)
+}
@@ -3,10 +3,13 @@ package gue
 import (
 	"context"
 	"errors"
+	"fmt"
+	"math"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap/zaptest"
 
@@ -100,3 +103,130 @@ func testBackoff(t *testing.T, connPool adapter.ConnPool) {
 		require.NoError(t, err)
 	})
 }
+
+func TestNewLinearBackoff(t *testing.T) {
+	b := NewLinearBackoff(time.Second, 2*time.Second, 6*time.Second)
+
+	assert.Equal(t, time.Second, b(1))
+	assert.Equal(t, 3*time.Second, b(2))
+	assert.Equal(t, 5*time.Second, b(3))
+	assert.Equal(t, 6*time.Second, b(4))
+	assert.Equal(t, 6*time.Second, b(100))
+}
+
+func TestNewFibonacciBackoff(t *testing.T) {
+	b := NewFibonacciBackoff(time.Second, 10*time.Second)
+
+	expected := []time.Duration{time.Second, time.Second, 2 * time.Second, 3 * time.Second, 5 * time.Second, 8 * time.Second}
+	for i, d := range expected {
+		assert.Equal(t, d, b(i+1), "retry %d", i+1)
+	}
+	assert.Equal(t, 10*time.Second, b(7))
+	assert.Equal(t, 10*time.Second, b(1000))
+
+	// no overflow without the cap
+	assert.Greater(t, NewFibonacciBackoff(time.Second, 0)(1000), time.Duration(0))
+}
+
+func TestNewDecorrelatedJitterBackoff(t *testing.T) {
+	b := NewDecorrelatedJitterBackoff(time.Second, time.Minute)
+
+	updatedAt := time.Now()
+	for i := 0; i < 100; i++ {
+		// first retry is based on the base duration
+		d := b(&Job{}, nil, 1)
+		assert.GreaterOrEqual(t, d, time.Second)
+		assert.Less(t, d, 3*time.Second)
+
+		// next ones on the previous duration the job was rescheduled for
+		d = b(&Job{ErrorCount: 1, UpdatedAt: updatedAt, RunAt: updatedAt.Add(10 * time.Second)}, nil, 2)
+		assert.GreaterOrEqual(t, d, time.Second)
+		assert.Less(t, d, 30*time.Second)
+
+		d = b(&Job{ErrorCount: 5, UpdatedAt: updatedAt, RunAt: updatedAt.Add(time.Hour)}, nil, 6)
+		assert.GreaterOrEqual(t, d, time.Second)
+		assert.Less(t, d, time.Minute)
+	}
+
+	// not capped without the positive max delay and does not overflow
+	b = NewDecorrelatedJitterBackoff(time.Second, 0)
+	for i := 0; i < 100; i++ {
+		d := b(&Job{ErrorCount: 2, UpdatedAt: updatedAt, RunAt: updatedAt.Add(time.Hour)}, nil, 3)
+		assert.GreaterOrEqual(t, d, time.Second)
+		assert.Less(t, d, 3*time.Hour)
+
+		d = b(&Job{ErrorCount: 2, UpdatedAt: time.Unix(0, 0), RunAt: time.Unix(0, math.MaxInt64)}, nil, 3)
+		assert.GreaterOrEqual(t, d, time.Second)
+	}
+}
+
+func TestNewScheduleBackoff(t *testing.T) {
+	b := NewScheduleBackoff(time.Minute, 5*time.Minute, time.Hour)
+
+	assert.Equal(t, time.Minute, b(0))
+	assert.Equal(t, time.Minute, b(1))
+	assert.Equal(t, 5*time.Minute, b(2))
+	assert.Equal(t, time.Hour, b(3))
+	assert.Equal(t, time.Hour, b(4))
+
+	assert.Less(t, NewScheduleBackoff()(1), time.Duration(0))
+
+	b = BackoffWithMaxRetries(b, 3)
+	assert.Equal(t, time.Hour, b(3))
+	assert.Less(t, b(4), time.Duration(0))
+}
+
+func TestClient_backoffFor(t *testing.T) {
+	c, err := NewClient(
+		nil,
+		WithClientBackoff(NewConstantBackoff(time.Second)),
+		WithClientTypeBackoff("typed", AsJobBackoff(NewConstantBackoff(2*time.Second))),
+		WithClientQueueBackoff("queued", AsJobBackoff(NewConstantBackoff(3*time.Second))),
+		WithClientNamedBackoff("named", AsJobBackoff(NewConstantBackoff(4*time.Second))),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, time.Second, c.backoffFor(&Job{Type: "MyJob"}, nil, 1))
+	assert.Equal(t, 2*time.Second, c.backoffFor(&Job{Type: "typed", Queue: "queued"}, nil, 1))
+	assert.Equal(t, 3*time.Second, c.backoffFor(&Job{Type: "MyJob", Queue: "queued"}, nil, 1))
+	assert.Equal(t, 4*time.Second, c.backoffFor(&Job{Type: "typed", Queue: "queued", BackoffName: "named"}, nil, 1))
+
+	// not registered name falls back to the other backoffs and is logged only once
+	l := new(mockLogger)
+	l.On("Error", "Job backoff is not registered, falling back to the default one", mock.Anything).Once()
+	c.logger = l
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, 2*time.Second, c.backoffFor(&Job{Type: "typed", BackoffName: "unknown"}, nil, 1))
+	}
+	l.AssertExpectations(t)
+
+	errRateLimited := errors.New("rate limited")
+	c, err = NewClient(nil, WithClientJobBackoff(func(j *Job, err error, retries int) time.Duration {
+		if errors.Is(err, errRateLimited) {
+			return time.Minute
+		}
+		return time.Duration(retries) * time.Second
+	}))
+	require.NoError(t, err)
+
+	assert.Equal(t, time.Minute, c.backoffFor(&Job{Type: "MyJob"}, errRateLimited, 1))
+	assert.Equal(t, 2*time.Second, c.backoffFor(&Job{Type: "MyJob"}, errors.New("the error msg"), 2))
+
+	// job type backoff sees the failed job and its error as well
+	typed, err := NewClient(nil, WithClientTypeBackoff("typed", func(j *Job, err error, retries int) time.Duration {
+		if errors.Is(err, errRateLimited) {
+			return time.Hour
+		}
+		return time.Second
+	}))
+	require.NoError(t, err)
+
+	assert.Equal(t, time.Hour, typed.backoffFor(&Job{Type: "typed"}, errRateLimited, 1))
+	assert.Equal(t, time.Second, typed.backoffFor(&Job{Type: "typed"}, errors.New("the error msg"), 1))
+
+	j := c.newLockedJob(nil)
+	j.Type = "MyJob"
+	decision, _ := j.errorDecision(fmt.Errorf("could not call the service: %w", errRateLimited), time.Now(), 1)
+	assert.Equal(t, ErrorActionRetry, decision.Action)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), decision.RunAt, time.Second)
+}
@@ -0,0 +1,340 @@
+package gue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/vgarvardt/gue/v5/adapter"
+)
+
+// jobBatch is the transaction shared by the jobs locked together, see Client.LockJobs.
+// Every job changes are applied within the job savepoint, so that failure of one job does not abort
+// the transaction and does not roll back changes of the other jobs.
+type jobBatch struct {
+	// mu serialises the shared transaction usage, as the batch jobs may be finished concurrently
+	mu      sync.Mutex
+	tx      adapter.Tx
+	pending int
+	// committed are the batch jobs callbacks to be called once the batch transaction is committed
+	committed []func()
+}
+
+// savepointName returns the name of the job savepoint within the batch transaction.
+func (j *Job) savepointName() string {
+	return "gue_job_" + strings.ToLower(j.ID.String())
+}
+
+// openSavepoint creates the job savepoint in the batch transaction before the job is worked, so that the job
+// handler changes made within the Job.Tx are rolled back if the job fails. It is a no-op for not batched jobs.
+func (j *Job) openSavepoint(ctx context.Context) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.batch == nil || j.savepoint || j.tx == nil {
+		return nil
+	}
+
+	j.batch.mu.Lock()
+	defer j.batch.mu.Unlock()
+
+	if _, err := j.tx.Exec(ctx, `SAVEPOINT `+j.savepointName()); err != nil {
+		return fmt.Errorf("could not create job savepoint: %w", err)
+	}
+
+	j.savepoint = true
+	return nil
+}
+
+// execBatch executes the job statement in the batch transaction within the job savepoint. When rollback is true,
+// job handler changes made after the job savepoint was opened are rolled back before the statement is executed.
+// Must be called with Job.mu held.
+func (j *Job) execBatch(ctx context.Context, rollback bool, sql string, args ...any) (err error) {
+	j.batch.mu.Lock()
+	defer j.batch.mu.Unlock()
+
+	name := j.savepointName()
+	if !j.savepoint {
+		if _, err := j.tx.Exec(ctx, `SAVEPOINT `+name); err != nil {
+			return fmt.Errorf("could not create job savepoint: %w", err)
+		}
+		j.savepoint = true
+	} else if rollback {
+		if _, err := j.tx.Exec(ctx, `ROLLBACK TO SAVEPOINT `+name); err != nil {
+			return fmt.Errorf("could not rollback to job savepoint: %w", err)
+		}
+	}
+
+	if _, err = j.tx.Exec(ctx, sql, args...); err != nil {
+		if _, rbErr := j.tx.Exec(ctx, `ROLLBACK TO SAVEPOINT `+name); rbErr != nil {
+			err = fmt.Errorf("could not rollback to job savepoint (original error: %v): %w", err, rbErr)
+		}
+	}
+
+	return j.releaseSavepoint(ctx, err)
+}
+
+// releaseSavepoint releases the job savepoint, must be called with Job.mu and jobBatch.mu held.
+func (j *Job) releaseSavepoint(ctx context.Context, err error) error {
+	if _, relErr := j.tx.Exec(ctx, `RELEASE SAVEPOINT `+j.savepointName()); relErr != nil && err == nil {
+		err = fmt.Errorf("could not release job savepoint: %w", relErr)
+	}
+	j.savepoint = false
+
+	return err
+}
+
+// doneBatch marks the batch job as done and commits the batch transaction when all the batch jobs are done.
+// Must be called with Job.mu held.
+func (j *Job) doneBatch(ctx context.Context) error {
+	j.batch.mu.Lock()
+	defer j.batch.mu.Unlock()
+
+	var err error
+	if j.savepoint {
+		// job was neither deleted nor errored, keep the handler changes as the not batched job does
+		err = j.releaseSavepoint(ctx, nil)
+	}
+
+	j.batch.pending--
+	if j.batch.pending > 0 {
+		return err
+	}
+
+	committed := j.batch.committed
+	j.batch.committed = nil
+	if commitErr := j.tx.Commit(ctx); commitErr != nil {
+		return commitErr
+	}
+	// callbacks are called by the job that committed the batch after all the locks are released
+	j.committed = append(j.committed, committed...)
+	return err
+}
+
+// releaseBatch rolls back the batch job changes made after the job savepoint was opened and marks the job as done.
+// Must be called with Job.mu held.
+func (j *Job) releaseBatch(ctx context.Context) error {
+	var err error
+
+	j.batch.mu.Lock()
+	if j.savepoint {
+		if _, rbErr := j.tx.Exec(ctx, `ROLLBACK TO SAVEPOINT `+j.savepointName()); rbErr != nil {
+			err = fmt.Errorf("could not rollback to job savepoint: %w", rbErr)
+		}
+	}
+	j.batch.mu.Unlock()
+
+	if doneErr := j.doneBatch(ctx); doneErr != nil && err == nil {
+		err = doneErr
+	}
+	return err
+}
+
+// batchHandler is the BatchWorkFunc registered for the job type with the batch limits.
+type batchHandler struct {
+	fn      BatchWorkFunc
+	maxSize int
+	maxWait time.Duration
+}
+
+// workBatchHandlers tries to lock and work the batch of jobs of every type with the registered BatchWorkFunc.
+func (w *Worker) workBatchHandlers(ctx context.Context) (didWork bool) {
+	for _, jobType := range w.batchTypes {
+		if w.workBatchHandler(ctx, jobType, w.batchHandlers[jobType]) {
+			didWork = true
+		}
+	}
+
+	return didWork
+}
+
+func (w *Worker) workBatchHandler(ctx context.Context, jobType string, h batchHandler) bool {
+	jobs, err := w.poller.Poll(ctx, w.c, PollQuery{Queue: w.queue, Limit: h.maxSize, Types: []string{jobType}})
+	incomplete := err == nil && len(jobs) > 0 && len(jobs) < h.maxSize && oldestWait(jobs, time.Now()) < h.maxWait
+	if incomplete {
+		// batch is not full yet, leave the jobs for the next polls until the oldest one waits for too long
+		if err := releaseJobs(ctx, jobs); err != nil {
+			w.logger.Error("Worker failed to release incomplete batch", adapter.Err(err), adapter.F("job-type", jobType))
+		}
+	}
+
+	// incomplete batch is not the idle poll, as there are ready jobs, they are just not worked yet
+	lockedAt, found := w.polled(ctx, err, len(jobs) > 0)
+	if !found || incomplete {
+		return false
+	}
+
+	w.workingSince.Store(lockedAt.UnixNano())
+	defer w.workingSince.Store(0)
+	w.setCurrentJob(jobs[0], lockedAt)
+	defer w.setCurrentJob(nil, time.Time{})
+
+	processingStartedAt := time.Now()
+	ctx, span := w.tracer.Start(ctx, "Worker.WorkBatch", trace.WithAttributes(
+		attribute.String("job-type", jobType),
+		attribute.Int("batch-size", len(jobs)),
+	))
+	defer span.End()
+
+	ll := w.logger.With(adapter.F("job-type", jobType), adapter.F("batch-size", len(jobs)))
+
+	jobs = w.expireJobs(ctx, ll, jobs)
+	if len(jobs) == 0 {
+		return true
+	}
+
+	errs := w.callBatchWorkFunc(ctx, span, ll, h.fn, jobs, lockedAt)
+	for i, j := range jobs {
+		w.finishBatchJob(ctx, span, ll, j, errs[i], lockedAt, processingStartedAt)
+	}
+
+	return true
+}
+
+// callBatchWorkFunc calls the batch handler and returns the individual job errors.
+// Handler panic or invalid number of returned errors fail all the batch jobs.
+func (w *Worker) callBatchWorkFunc(
+	ctx context.Context,
+	span trace.Span,
+	ll adapter.Logger,
+	fn BatchWorkFunc,
+	jobs []*Job,
+	lockedAt time.Time,
+) (errs []error) {
+	defer func() {
+		if r := recover(); r != nil {
+			stacktrace := w.panicStacktrace(ll, r)
+			span.RecordError(errors.New("batch panicked"), trace.WithAttributes(attribute.String("stacktrace", stacktrace)))
+			ll.Error("Batch panicked", adapter.F("stacktrace", stacktrace))
+
+			panicErr := newPanicError(r, stacktrace)
+			for _, j := range jobs {
+				for _, hook := range w.hooksJobPanicked {
+					hook(ctx, j, panicErr)
+				}
+			}
+
+			errs = batchErrors(len(jobs), panicErr)
+		}
+	}()
+
+	startedAt := time.Now()
+	for _, j := range jobs {
+		w.prepareJob(j)
+		j.startAttempt(w.id, startedAt)
+		w.mWait.Record(
+			ctx,
+			j.waitDuration(lockedAt).Milliseconds(),
+			metric.WithAttributes(attrQueue.String(j.Queue), attrJobType.String(j.Type), attrAttempt.Int64(int64(j.ErrorCount)+1)),
+		)
+
+		for _, hook := range w.hooksJobLocked {
+			hook(ctx, j, nil)
+		}
+	}
+
+	errs = fn(ctx, jobs)
+	if errs == nil {
+		return make([]error, len(jobs))
+	}
+
+	if len(errs) != len(jobs) {
+		err := fmt.Errorf("worker[id=%s] batch handler returned %d errors for %d jobs", w.id, len(errs), len(jobs))
+		span.RecordError(err)
+		ll.Error("Batch handler returned invalid number of errors", adapter.Err(err))
+
+		return batchErrors(len(jobs), err)
+	}
+
+	return errs
+}
+
+// finishBatchJob applies the batch handler result to the single batch job.
+func (w *Worker) finishBatchJob(
+	ctx context.Context,
+	span trace.Span,
+	ll adapter.Logger,
+	j *Job,
+	err error,
+	lockedAt time.Time,
+	processingStartedAt time.Time,
+) {
+	ll = ll.With(adapter.F("job-id", j.ID.String()))
+
+	defer func() {
+		if err := j.Done(ctx); err != nil {
+			span.RecordError(fmt.Errorf("failed to mark job as done: %w", err))
+			ll.Error("Failed to mark job as done", adapter.Err(err))
+		}
+
+		w.mDuration.Record(
+			ctx,
+			time.Since(processingStartedAt).Milliseconds(),
+			metric.WithAttributes(attrJobType.String(j.Type)),
+		)
+	}()
+	defer w.recoverPanic(ctx, ll, j)
+
+	if w.interruptFinished(ctx, ll, j, lockedAt, err) {
+		return
+	}
+	w.finishJob(ctx, span, ll, j, err)
+}
+
+// oldestWait returns the longest wait duration of the jobs, see Job.waitDuration.
+func oldestWait(jobs []*Job, lockedAt time.Time) time.Duration {
+	var wait time.Duration
+	for _, j := range jobs {
+		if jw := j.waitDuration(lockedAt); jw > wait {
+			wait = jw
+		}
+	}
+
+	return wait
+}
+
+// releaseJobs releases the locked jobs without working them. Jobs locked together share the batch transaction
+// that is rolled back once, jobs locked one by one, e.g. by the Poller with the query limit lowered to one,
+// are released individually.
+func releaseJobs(ctx context.Context, jobs []*Job) error {
+	var err error
+	batches := make(map[*jobBatch]struct{})
+	for _, j := range jobs {
+		if j.batch == nil {
+			if relErr := j.release(ctx); relErr != nil && err == nil {
+				err = relErr
+			}
+			continue
+		}
+
+		j.mu.Lock()
+		j.tx = nil
+		j.finish()
+		j.mu.Unlock()
+		batches[j.batch] = struct{}{}
+	}
+
+	for b := range batches {
+		if rbErr := b.tx.Rollback(ctx); rbErr != nil && err == nil {
+			err = rbErr
+		}
+	}
+
+	return err
+}
+
+func batchErrors(n int, err error) []error {
+	errs := make([]error, n)
+	for i := range errs {
+		errs[i] = err
+	}
+
+	return errs
+}
@@ -0,0 +1,342 @@
+package gue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vgarvardt/gue/v5/adapter"
+	adapterTesting "github.com/vgarvardt/gue/v5/adapter/testing"
+)
+
+func TestLockJobs(t *testing.T) {
+	for name, openFunc := range adapterTesting.AllAdaptersOpenTestPool {
+		t.Run(name, func(t *testing.T) {
+			testLockJobs(t, openFunc(t))
+		})
+	}
+}
+
+func testLockJobs(t *testing.T, connPool adapter.ConnPool) {
+	ctx := context.Background()
+
+	c, err := NewClient(connPool)
+	require.NoError(t, err)
+
+	jobs := []*Job{
+		{Type: "MyJob", Priority: JobPriorityLow},
+		{Type: "MyJob", Priority: JobPriorityHigh},
+		{Type: "MyJob"},
+	}
+	require.NoError(t, c.EnqueueBatch(ctx, jobs))
+
+	locked, err := c.LockJobs(ctx, "", 2)
+	require.NoError(t, err)
+	require.Len(t, locked, 2)
+	assert.Equal(t, jobs[1].ID, locked[0].ID)
+	assert.Equal(t, jobs[2].ID, locked[1].ID)
+	assert.Same(t, locked[0].Tx(), locked[1].Tx())
+
+	// locked jobs are skipped by the other workers
+	rest, err := c.LockJobs(ctx, "", 10)
+	require.NoError(t, err)
+	require.Len(t, rest, 1)
+	assert.Equal(t, jobs[0].ID, rest[0].ID)
+	require.NoError(t, rest[0].Done(ctx))
+
+	require.NoError(t, locked[0].Delete(ctx))
+	require.NoError(t, locked[1].Error(ctx, errors.New("the error msg")))
+
+	require.NoError(t, locked[0].Done(ctx))
+	// transaction is committed when all the batch jobs are done
+	require.NotNil(t, locked[1].Tx())
+	require.NoError(t, locked[1].Done(ctx))
+	assert.Nil(t, locked[1].Tx())
+
+	_, err = c.LockJobByID(ctx, jobs[1].ID)
+	assert.Error(t, err)
+
+	j, err := c.LockJobByID(ctx, jobs[2].ID)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), j.ErrorCount)
+	assert.Equal(t, "the error msg", j.LastError.String)
+	require.NoError(t, j.Done(ctx))
+
+	noJobs, err := c.LockJobs(ctx, "some-other-queue", 10)
+	require.NoError(t, err)
+	assert.Empty(t, noJobs)
+}
+
+func TestLockJobsScanError(t *testing.T) {
+	ctx := context.Background()
+
+	rows := newScanErrorRows(len((&Job{}).scanDest()))
+
+	tx := new(adapterTesting.Tx)
+	tx.Queryable.On("Query", ctx, mock.Anything, mock.Anything).Return(rows, nil)
+	tx.On("Rollback", ctx).Return(nil)
+
+	pool := new(adapterTesting.ConnPool)
+	pool.On("Begin", ctx).Return(tx, nil)
+
+	c, err := NewClient(pool)
+	require.NoError(t, err)
+
+	_, err = c.LockJobs(ctx, "", 10)
+	require.ErrorIs(t, err, assert.AnError)
+
+	// rows are closed before the transaction is rolled back, so the connection is not left busy
+	rows.AssertCalled(t, "Close")
+	tx.AssertCalled(t, "Rollback", ctx)
+}
+
+func TestWorkerWorkOneLockBatch(t *testing.T) {
+	for name, openFunc := range adapterTesting.AllAdaptersOpenTestPool {
+		t.Run(name, func(t *testing.T) {
+			t.Run("sequential", func(t *testing.T) {
+				testWorkerWorkOneLockBatch(t, openFunc(t), 1)
+			})
+			t.Run("concurrent", func(t *testing.T) {
+				testWorkerWorkOneLockBatch(t, openFunc(t), 3)
+			})
+		})
+	}
+}
+
+func testWorkerWorkOneLockBatch(t *testing.T, connPool adapter.ConnPool, concurrency int) {
+	ctx := context.Background()
+
+	c, err := NewClient(connPool)
+	require.NoError(t, err)
+
+	wm := WorkMap{
+		"ok": func(ctx context.Context, j *Job) error {
+			return nil
+		},
+		"fail": func(ctx context.Context, j *Job) error {
+			if concurrency == 1 {
+				// failed job changes are rolled back with the job savepoint, the transaction is not aborted
+				if _, err := j.Tx().Exec(ctx, `SELECT * FROM unknown_table`); err == nil {
+					return errors.New("unexpected success")
+				}
+			}
+			return errors.New("the error msg")
+		},
+		"panic": func(ctx context.Context, j *Job) error {
+			panic("the panic msg")
+		},
+	}
+
+	w, err := NewWorker(c, wm, WithWorkerLockBatch(10, concurrency))
+	require.NoError(t, err)
+
+	jobs := []*Job{{Type: "ok"}, {Type: "fail"}, {Type: "panic"}, {Type: "unknown"}, {Type: "ok"}}
+	require.NoError(t, c.EnqueueBatch(ctx, jobs))
+
+	assert.True(t, w.WorkOne(ctx))
+	assert.False(t, w.WorkOne(ctx))
+
+	for _, j := range []*Job{jobs[0], jobs[4]} {
+		_, err := c.LockJobByID(ctx, j.ID)
+		assert.Error(t, err)
+	}
+
+	for _, j := range []*Job{jobs[1], jobs[2], jobs[3]} {
+		locked, err := c.LockJobByID(ctx, j.ID)
+		require.NoError(t, err)
+		assert.Equal(t, int32(1), locked.ErrorCount)
+		assert.True(t, locked.LastError.Valid)
+		require.NoError(t, locked.Done(ctx))
+	}
+}
+
+func TestJob_execBatch(t *testing.T) {
+	ctx := context.Background()
+	id := ulid.MustParse("01H5Q7BZ7CDGA3KQWQ7PGXF8DK")
+	sp := "gue_job_01h5q7bz7cdga3kqwq7pgxf8dk"
+
+	tx := new(adapterTesting.Tx)
+	tx.Queryable.On("Exec", ctx, "SAVEPOINT "+sp, mock.Anything).Return(nil, nil).Once()
+	tx.Queryable.On("Exec", ctx, "ROLLBACK TO SAVEPOINT "+sp, mock.Anything).Return(nil, nil).Twice()
+	tx.Queryable.On("Exec", ctx, mock.MatchedBy(func(sql string) bool {
+		return sql[:6] == "UPDATE"
+	}), mock.Anything).Return(nil, errors.New("update failed")).Once()
+	tx.Queryable.On("Exec", ctx, "RELEASE SAVEPOINT "+sp, mock.Anything).Return(nil, nil).Once()
+	tx.On("Commit", ctx).Return(nil).Once()
+
+	batch := &jobBatch{tx: tx, pending: 1}
+	j := &Job{ID: id, tx: tx, table: "gue_jobs", backoff: AsJobBackoff(DefaultExponentialBackoff), batch: batch}
+
+	require.NoError(t, j.openSavepoint(ctx))
+	assert.True(t, j.savepoint)
+
+	// handler changes are rolled back, then failed statement is rolled back as well, job transaction is committed
+	err := j.Error(ctx, errors.New("the error msg"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "update failed")
+	assert.False(t, j.savepoint)
+	assert.Equal(t, 0, batch.pending)
+	assert.Nil(t, j.Tx())
+
+	tx.AssertExpectations(t)
+	tx.Queryable.AssertExpectations(t)
+}
+
+func TestReleaseJobs(t *testing.T) {
+	ctx := context.Background()
+
+	batchTx := new(adapterTesting.Tx)
+	batchTx.On("Rollback", ctx).Return(nil).Once()
+	batch := &jobBatch{tx: batchTx, pending: 2}
+
+	singleTx := new(adapterTesting.Tx)
+	singleTx.On("Rollback", ctx).Return(nil).Once()
+
+	finished := 0
+	jobs := []*Job{
+		{tx: batchTx, batch: batch, onFinish: func() { finished++ }},
+		{tx: singleTx, onFinish: func() { finished++ }},
+		{tx: batchTx, batch: batch, onFinish: func() { finished++ }},
+	}
+
+	require.NoError(t, releaseJobs(ctx, jobs))
+	assert.Equal(t, 3, finished)
+	for _, j := range jobs {
+		assert.Nil(t, j.Tx())
+	}
+
+	batchTx.AssertExpectations(t)
+	singleTx.AssertExpectations(t)
+}
+
+func TestWorker_workBatchHandlerNotBatchedJob(t *testing.T) {
+	ctx := context.Background()
+
+	handlerCalled := false
+	lockEmptyHook := new(mockHook)
+	w, err := NewWorker(nil, WorkMap{}, WithWorkerBatchWorkFunc("MyJob", func(ctx context.Context, jobs []*Job) []error {
+		handlerCalled = true
+		return nil
+	}, 10, time.Hour), WithWorkerHooksLockEmpty(lockEmptyHook.handler))
+	require.NoError(t, err)
+
+	// poller locks the single job outside the batch, e.g. when the limit is lowered to the fairness key free cap
+	j, tx := newHooksTestJob(BackoffNever)
+	j.RunAt = time.Now()
+	tx.On("Rollback", mock.Anything).Return(nil).Once()
+	w.poller = PollerFunc(func(ctx context.Context, c *Client, q PollQuery) ([]*Job, error) {
+		return []*Job{j}, nil
+	})
+
+	require.NotPanics(t, func() {
+		assert.False(t, w.workBatchHandler(ctx, "MyJob", w.batchHandlers["MyJob"]))
+	})
+	assert.False(t, handlerCalled)
+
+	// incomplete batch is released, but it is not the idle poll
+	assert.Equal(t, int64(0), w.idlePolls.Load())
+	assert.Equal(t, 0, lockEmptyHook.called)
+	assert.Nil(t, j.Tx())
+	tx.AssertCalled(t, "Rollback", mock.Anything)
+	tx.AssertNotCalled(t, "Commit", mock.Anything)
+}
+
+func TestLockQuery_sql(t *testing.T) {
+	c, err := NewClient(nil)
+	require.NoError(t, err)
+
+	sql, args := lockQuery{queue: "q", strategy: RunAtPollStrategy}.sql(c, 10)
+	assert.Contains(t, sql, "ORDER BY run_at, priority ASC\nLIMIT $3 FOR UPDATE SKIP LOCKED")
+	assert.NotContains(t, sql, "job_type IN")
+	require.Len(t, args, 3)
+	assert.Equal(t, "q", args[0])
+	assert.Equal(t, 10, args[2])
+
+	sql, args = lockQuery{queue: "q", types: []string{"a", "b"}, excludeTypes: []string{"c"}}.sql(c, 1)
+	assert.Contains(t, sql, " AND job_type IN ($4, $5) AND job_type NOT IN ($6)\nORDER BY priority ASC")
+	assert.Equal(t, []any{"a", "b", "c"}, args[3:])
+}
+
+func TestWorkerWorkOneBatchWorkFunc(t *testing.T) {
+	for name, openFunc := range adapterTesting.AllAdaptersOpenTestPool {
+		t.Run(name, func(t *testing.T) {
+			testWorkerWorkOneBatchWorkFunc(t, openFunc(t))
+		})
+	}
+}
+
+func testWorkerWorkOneBatchWorkFunc(t *testing.T, connPool adapter.ConnPool) {
+	ctx := context.Background()
+
+	c, err := NewClient(connPool)
+	require.NoError(t, err)
+
+	var (
+		batches [][]*Job
+		failing ulid.ULID
+	)
+	batchFn := func(ctx context.Context, jobs []*Job) []error {
+		batches = append(batches, jobs)
+
+		errs := make([]error, len(jobs))
+		for i, j := range jobs {
+			if j.ID == failing {
+				errs[i] = errors.New("the error msg")
+			}
+		}
+		return errs
+	}
+
+	called := 0
+	wm := WorkMap{
+		"single": func(ctx context.Context, j *Job) error {
+			called++
+			return nil
+		},
+	}
+
+	w, err := NewWorker(c, wm, WithWorkerBatchWorkFunc("bulk", batchFn, 3, time.Hour))
+	require.NoError(t, err)
+
+	jobs := []*Job{{Type: "bulk"}, {Type: "bulk"}}
+	require.NoError(t, c.EnqueueBatch(ctx, jobs))
+	failing = jobs[1].ID
+
+	// incomplete batch waits for more jobs and batch jobs are not worked by the WorkMap handlers
+	assert.False(t, w.WorkOne(ctx))
+	assert.Empty(t, batches)
+
+	jobs = append(jobs, &Job{Type: "bulk"})
+	require.NoError(t, c.Enqueue(ctx, jobs[2]))
+	require.NoError(t, c.Enqueue(ctx, &Job{Type: "single"}))
+
+	assert.True(t, w.WorkOne(ctx))
+	require.Len(t, batches, 1)
+	require.Len(t, batches[0], 3)
+	batchIDs := make([]ulid.ULID, 0, len(batches[0]))
+	for _, j := range batches[0] {
+		batchIDs = append(batchIDs, j.ID)
+	}
+	assert.ElementsMatch(t, []ulid.ULID{jobs[0].ID, jobs[1].ID, jobs[2].ID}, batchIDs)
+
+	assert.True(t, w.WorkOne(ctx))
+	assert.Equal(t, 1, called)
+	assert.False(t, w.WorkOne(ctx))
+
+	for _, j := range []*Job{jobs[0], jobs[2]} {
+		_, err := c.LockJobByID(ctx, j.ID)
+		assert.Error(t, err)
+	}
+
+	j, err := c.LockJobByID(ctx, jobs[1].ID)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), j.ErrorCount)
+	assert.Equal(t, "the error msg", j.LastError.String)
+	require.NoError(t, j.Done(ctx))
+}
@@ -6,6 +6,9 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/oklog/ulid/v2"
@@ -20,9 +23,13 @@ import (
 // specified.
 var ErrMissingType = errors.New("job type must be specified")
 
+const defaultTableName = "gue_jobs"
+
 var (
 	attrJobType = attribute.Key("job-type")
+	attrQueue   = attribute.Key("queue")
 	attrSuccess = attribute.Key("success")
+	attrAttempt = attribute.Key("attempt")
 )
 
 // Client is a Gue client that can add jobs to the queue and remove jobs from
@@ -33,9 +40,46 @@ type Client struct {
 	id      string
 	backoff Backoff
 	meter   metric.Meter
+	schema  string
+	table   string
+
+	checkSchemaVersion bool
+	errorClassifier    ErrorClassifier
+
+	// priorityAging is the wait time that raises the job effective priority by one, see PriorityAgingPollStrategy
+	priorityAging time.Duration
+	// partitionPeriod is the time range of the jobs table partition, see CreateJobPartitions
+	partitionPeriod time.Duration
+
+	// jobBackoff replaces the default backoff when set, backoffs by the job type, queue and name take precedence
+	jobBackoff    JobBackoff
+	typeBackoffs  map[string]JobBackoff
+	queueBackoffs map[string]JobBackoff
+	namedBackoffs map[string]JobBackoff
+	// unknownBackoffs holds the not registered backoff names jobs were enqueued with, that are logged already
+	unknownBackoffs sync.Map
+
+	// jobsTable is the quoted and optionally schema-qualified jobs table name ready to be used in SQL statements
+	jobsTable string
+	// pausedTable is the quoted and optionally schema-qualified paused queues and job types table name
+	pausedTable string
+	// workersTable is the quoted and optionally schema-qualified worker registry table name
+	workersTable string
+	// attemptsTable is the quoted and optionally schema-qualified attempts log table name
+	attemptsTable string
+
+	attemptsLog       bool
+	attemptsRetention time.Duration
+	attemptsPruner    attemptsPruner
 
 	entropy io.Reader
 
+	// lockLatency is the max run_at-to-lock latency per queue reported by the StatsCollector
+	lockLatency   map[string]time.Duration
+	lockLatencyMu sync.Mutex
+
+	hooksJobEnqueued []HookFunc
+
 	mEnqueue metric.Int64Counter
 	mLockJob metric.Int64Counter
 }
@@ -48,6 +92,10 @@ func NewClient(pool adapter.ConnPool, options ...ClientOption) (*Client, error)
 		id:      RandomStringID(),
 		backoff: DefaultExponentialBackoff,
 		meter:   noop.NewMeterProvider().Meter("noop"),
+		table:   defaultTableName,
+
+		priorityAging:   defaultPriorityAging,
+		partitionPeriod: defaultPartitionPeriod,
 		entropy: &ulid.LockedMonotonicReader{
 			MonotonicReader: ulid.Monotonic(rand.Reader, 0),
 		},
@@ -57,14 +105,47 @@ func NewClient(pool adapter.ConnPool, options ...ClientOption) (*Client, error)
 		option(&instance)
 	}
 
+	if instance.table == "" {
+		return nil, errors.New("table name must not be empty")
+	}
+	if instance.partitionPeriod <= 0 {
+		return nil, errors.New("partition period must be positive")
+	}
+	instance.jobsTable = qualifiedIdentifier(instance.schema, instance.table)
+	instance.pausedTable = qualifiedIdentifier(instance.schema, relatedTableName(instance.table, "paused"))
+	instance.workersTable = qualifiedIdentifier(instance.schema, relatedTableName(instance.table, "workers"))
+	instance.attemptsTable = qualifiedIdentifier(instance.schema, relatedTableName(instance.table, "attempts"))
+
 	instance.logger = instance.logger.With(adapter.F("client-id", instance.id))
 
+	if instance.checkSchemaVersion {
+		if err := instance.verifySchemaVersion(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+
 	return &instance, instance.initMetrics()
 }
 
-// Enqueue adds a job to the queue.
+// Enqueue adds a job to the queue. Job with the unique key is enqueued in its own transaction, see Job.UniqueKey.
 func (c *Client) Enqueue(ctx context.Context, j *Job) error {
-	return c.execEnqueue(ctx, j, c.pool)
+	if j.UniqueKey == "" {
+		return c.execEnqueue(ctx, j, c.pool)
+	}
+
+	tx, err := c.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+
+	if err := c.execEnqueue(ctx, j, tx); err != nil {
+		if rbErr := tx.Rollback(ctx); rbErr != nil {
+			c.logger.Error("Could not properly rollback transaction", adapter.Err(rbErr))
+		}
+		return err
+	}
+
+	return tx.Commit(ctx)
 }
 
 // EnqueueTx adds a job to the queue within the scope of the transaction.
@@ -131,11 +212,21 @@ func (c *Client) execEnqueue(ctx context.Context, j *Job, q adapter.Queryable) (
 	if j.ID, err = ulid.New(ulid.Timestamp(now), c.entropy); err != nil {
 		return fmt.Errorf("could not generate new Job ULID ID: %w", err)
 	}
-	_, err = q.Exec(ctx, `INSERT INTO gue_jobs
-(job_id, queue, priority, run_at, job_type, args, created_at, updated_at)
+	j.CreatedAt, j.UpdatedAt = now, now
+	args := []any{
+		j.ID.String(), j.Queue, j.Priority, j.RunAt, j.Type, j.Args, now, j.BackoffName, j.ExpiresAt,
+		agedRunAt(j.RunAt, j.Priority, c.priorityAging), j.FairnessKey,
+	}
+	if j.UniqueKey != "" {
+		err = c.execEnqueueUnique(ctx, j, q, append(args, j.uniqueKey()))
+	} else {
+		_, err = q.Exec(ctx, `INSERT INTO `+c.jobsTable+`
+(job_id, queue, priority, run_at, job_type, args, created_at, updated_at, backoff_name, expires_at, aged_run_at,
+fairness_key)
 VALUES
-($1, $2, $3, $4, $5, $6, $7, $7)
-`, j.ID.String(), j.Queue, j.Priority, j.RunAt, j.Type, j.Args, now)
+($1, $2, $3, $4, $5, $6, $7, $7, $8, $9, $10, $11)
+`, args...)
+	}
 
 	c.logger.Debug(
 		"Tried to enqueue a job",
@@ -146,12 +237,17 @@ VALUES
 
 	c.mEnqueue.Add(ctx, 1, metric.WithAttributes(attrJobType.String(j.Type), attrSuccess.Bool(err == nil)))
 
+	for _, hook := range c.hooksJobEnqueued {
+		hook(ctx, j, err)
+	}
+
 	return err
 }
 
 // LockJob attempts to retrieve a Job from the database in the specified queue.
 // If a job is found, it will be locked on the transactional level, so other workers
 // will be skipping it. If no job is found, nil will be returned instead of an error.
+// Jobs from the paused queue or of the paused type are not locked, see Client.PauseQueue and Client.PauseJobType.
 //
 // This function cares about the priority first to lock top priority jobs first even if there are available ones that
 // should be executed earlier but with the lower priority.
@@ -163,18 +259,13 @@ VALUES
 // After the Job has been worked, you must call either Job.Done() or Job.Error() on it
 // in order to commit transaction to persist Job changes (remove or update it).
 func (c *Client) LockJob(ctx context.Context, queue string) (*Job, error) {
-	sql := `SELECT job_id, queue, priority, run_at, job_type, args, error_count, last_error
-FROM gue_jobs
-WHERE queue = $1 AND run_at <= $2
-ORDER BY priority ASC
-LIMIT 1 FOR UPDATE SKIP LOCKED`
-
-	return c.execLockJob(ctx, true, sql, queue, time.Now().UTC())
+	return c.lockJob(ctx, lockQuery{queue: queue, strategy: PriorityPollStrategy})
 }
 
 // LockJobByID attempts to retrieve a specific Job from the database.
 // If the job is found, it will be locked on the transactional level, so other workers
-// will be skipping it. If the job is not found, an error will be returned
+// will be skipping it. If the job is not found, an error will be returned.
+// The job is locked even if its queue or type is paused.
 //
 // Because Gue uses transaction-level locks, we have to hold the
 // same transaction throughout the process of getting the job, working it,
@@ -183,8 +274,8 @@ LIMIT 1 FOR UPDATE SKIP LOCKED`
 // After the Job has been worked, you must call either Job.Done() or Job.Error() on it
 // in order to commit transaction to persist Job changes (remove or update it).
 func (c *Client) LockJobByID(ctx context.Context, id ulid.ULID) (*Job, error) {
-	sql := `SELECT job_id, queue, priority, run_at, job_type, args, error_count, last_error
-FROM gue_jobs
+	sql := `SELECT ` + jobColumns + `
+FROM ` + c.jobsTable + `
 WHERE job_id = $1 FOR UPDATE SKIP LOCKED`
 
 	return c.execLockJob(ctx, false, sql, id.String())
@@ -193,6 +284,7 @@ WHERE job_id = $1 FOR UPDATE SKIP LOCKED`
 // LockNextScheduledJob attempts to retrieve the earliest scheduled Job from the database in the specified queue.
 // If a job is found, it will be locked on the transactional level, so other workers
 // will be skipping it. If no job is found, nil will be returned instead of an error.
+// Jobs from the paused queue or of the paused type are not locked, see Client.PauseQueue and Client.PauseJobType.
 //
 // This function cares about the scheduled time first to lock earliest to execute jobs first even if there are ones
 // with a higher priority scheduled to a later time but already eligible for execution
@@ -204,13 +296,98 @@ WHERE job_id = $1 FOR UPDATE SKIP LOCKED`
 // After the Job has been worked, you must call either Job.Done() or Job.Error() on it
 // in order to commit transaction to persist Job changes (remove or update it).
 func (c *Client) LockNextScheduledJob(ctx context.Context, queue string) (*Job, error) {
-	sql := `SELECT job_id, queue, priority, run_at, job_type, args, error_count, last_error
-FROM gue_jobs
-WHERE queue = $1 AND run_at <= $2
-ORDER BY run_at, priority ASC
-LIMIT 1 FOR UPDATE SKIP LOCKED`
+	return c.lockJob(ctx, lockQuery{queue: queue, strategy: RunAtPollStrategy})
+}
 
-	return c.execLockJob(ctx, true, sql, queue, time.Now().UTC())
+// LockJobs attempts to retrieve up to n Jobs from the database in the specified queue in the priority order,
+// see Client.LockJob. All the found jobs are locked on the transactional level in a single transaction,
+// so it takes one DB round trip to lock the whole batch. If no job is found, nil will be returned instead of an error.
+//
+// Locked jobs share the same transaction that is committed when all of them are done, so every job
+// must be finished with either Job.Done() or Job.Error(), the same way as the single locked job.
+// Job.Delete() and Job.Error() changes are applied within the job savepoint, so failure of one job does not roll back
+// the others. Shared transaction must not be used concurrently, including Job.Tx() usage by the job handlers.
+func (c *Client) LockJobs(ctx context.Context, queue string, n int) ([]*Job, error) {
+	return c.lockJobs(ctx, lockQuery{queue: queue, strategy: PriorityPollStrategy}, n)
+}
+
+// LockNextScheduledJobs attempts to retrieve up to n earliest scheduled Jobs from the database
+// in the specified queue, see Client.LockNextScheduledJob and Client.LockJobs.
+func (c *Client) LockNextScheduledJobs(ctx context.Context, queue string, n int) ([]*Job, error) {
+	return c.lockJobs(ctx, lockQuery{queue: queue, strategy: RunAtPollStrategy}, n)
+}
+
+// lockQuery describes the ready jobs to lock in the queue, in the order defined by the poll strategy.
+type lockQuery struct {
+	queue    string
+	strategy PollStrategy
+	// types limits the job types to lock when not empty
+	types []string
+	// excludeTypes are the job types to skip
+	excludeTypes []string
+	// fairnessKeys limits the job fairness keys to lock when not empty
+	fairnessKeys []string
+	// excludeFairnessKeys are the job fairness keys to skip
+	excludeFairnessKeys []string
+}
+
+// sql builds the lock query for up to limit jobs with positional arguments.
+func (q lockQuery) sql(c *Client, limit int) (string, []any) {
+	where, args := q.where(c, limit)
+
+	orderBy := `priority ASC`
+	switch q.strategy {
+	case RunAtPollStrategy:
+		orderBy = `run_at, priority ASC`
+	case PriorityAgingPollStrategy:
+		orderBy = agedRunAtColumn + `, priority ASC`
+	}
+
+	return `SELECT ` + jobColumns + `
+FROM ` + c.jobsTable + `
+WHERE ` + where + `
+ORDER BY ` + orderBy + `
+LIMIT $3 FOR UPDATE SKIP LOCKED`, args
+}
+
+// where builds the ready jobs condition with positional arguments, the third argument is the query limit.
+func (q lockQuery) where(c *Client, limit int) (string, []any) {
+	args := []any{q.queue, time.Now().UTC(), limit}
+
+	in := func(values []string) string {
+		placeholders := make([]string, len(values))
+		for i := range values {
+			args = append(args, values[i])
+			placeholders[i] = "$" + strconv.Itoa(len(args))
+		}
+		return "(" + strings.Join(placeholders, ", ") + ")"
+	}
+
+	where := `queue = $1 AND run_at <= $2 AND ` + c.notPausedCondition()
+	if len(q.types) > 0 {
+		where += ` AND job_type IN ` + in(q.types)
+	}
+	if len(q.excludeTypes) > 0 {
+		where += ` AND job_type NOT IN ` + in(q.excludeTypes)
+	}
+	if len(q.fairnessKeys) > 0 {
+		where += ` AND fairness_key IN ` + in(q.fairnessKeys)
+	}
+	if len(q.excludeFairnessKeys) > 0 {
+		where += ` AND fairness_key NOT IN ` + in(q.excludeFairnessKeys)
+	}
+
+	return where, args
+}
+
+func (c *Client) lockJob(ctx context.Context, q lockQuery) (*Job, error) {
+	sql, args := q.sql(c, 1)
+	return c.execLockJob(ctx, true, sql, args...)
+}
+
+func (c *Client) lockJobs(ctx context.Context, q lockQuery, n int) ([]*Job, error) {
+	sql, args := q.sql(c, n)
+	return c.execLockJobs(ctx, sql, args...)
 }
 
 func (c *Client) execLockJob(ctx context.Context, handleErrNoRows bool, sql string, args ...any) (*Job, error) {
@@ -220,29 +397,87 @@ func (c *Client) execLockJob(ctx context.Context, handleErrNoRows bool, sql stri
 		return nil, err
 	}
 
-	j := Job{tx: tx, backoff: c.backoff, logger: c.logger}
+	j := c.newLockedJob(tx)
 
-	err = tx.QueryRow(ctx, sql, args...).Scan(
-		&j.ID,
-		&j.Queue,
-		&j.Priority,
-		&j.RunAt,
-		&j.Type,
-		&j.Args,
-		&j.ErrorCount,
-		&j.LastError,
-	)
+	err = tx.QueryRow(ctx, sql, args...).Scan(j.scanDest()...)
 	if err == nil {
 		c.mLockJob.Add(ctx, 1, metric.WithAttributes(attrJobType.String(j.Type), attrSuccess.Bool(true)))
-		return &j, nil
+		c.recordLockLatency(j.Queue, j.RunAt)
+		return j, nil
 	}
 
 	rbErr := tx.Rollback(ctx)
-	if handleErrNoRows && err == adapter.ErrNoRows {
+	if handleErrNoRows && errors.Is(err, adapter.ErrNoRows) {
 		return nil, rbErr
 	}
 
-	return nil, fmt.Errorf("could not lock a job (rollback result: %v): %w", rbErr, err)
+	return nil, fmt.Errorf("could not lock a job (rollback result: %v): %w", rbErr, migrateHint(err))
+}
+
+func (c *Client) execLockJobs(ctx context.Context, sql string, args ...any) ([]*Job, error) {
+	tx, err := c.pool.Begin(ctx)
+	if err != nil {
+		c.mLockJob.Add(ctx, 1, metric.WithAttributes(attrJobType.String(""), attrSuccess.Bool(false)))
+		return nil, err
+	}
+
+	jobs, err := c.scanLockedJobs(ctx, tx, sql, args...)
+	if err != nil {
+		c.mLockJob.Add(ctx, 1, metric.WithAttributes(attrJobType.String(""), attrSuccess.Bool(false)))
+		rbErr := tx.Rollback(ctx)
+		return nil, fmt.Errorf("could not lock jobs (rollback result: %v): %w", rbErr, migrateHint(err))
+	}
+
+	if len(jobs) == 0 {
+		return nil, tx.Rollback(ctx)
+	}
+
+	batch := &jobBatch{tx: tx, pending: len(jobs)}
+	for _, j := range jobs {
+		j.batch = batch
+		c.mLockJob.Add(ctx, 1, metric.WithAttributes(attrJobType.String(j.Type), attrSuccess.Bool(true)))
+		c.recordLockLatency(j.Queue, j.RunAt)
+	}
+
+	return jobs, nil
+}
+
+func (c *Client) scanLockedJobs(ctx context.Context, tx adapter.Tx, sql string, args ...any) ([]*Job, error) {
+	rows, err := tx.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer closeRows(rows)
+
+	var jobs []*Job
+	for rows.Next() {
+		j := c.newLockedJob(tx)
+		if err := rows.Scan(j.scanDest()...); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, j)
+	}
+
+	return jobs, rows.Err()
+}
+
+// newLockedJob creates the Job to scan the job locked within the transaction into.
+func (c *Client) newLockedJob(tx adapter.Tx) *Job {
+	j := &Job{
+		tx:              tx,
+		table:           c.jobsTable,
+		backoff:         c.backoffFor,
+		errorClassifier: c.errorClassifier,
+		logger:          c.logger,
+		workerID:        c.id,
+		priorityAging:   c.priorityAging,
+	}
+	if c.attemptsLog {
+		j.attemptsTable = c.attemptsTable
+		j.attemptsKept = c.attemptsRetention > 0
+	}
+
+	return j
 }
 
 func (c *Client) initMetrics() (err error) {
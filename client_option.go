@@ -1,6 +1,8 @@
 package gue
 
 import (
+	"time"
+
 	"go.opentelemetry.io/otel/metric"
 
 	"github.com/vgarvardt/gue/v5/adapter"
@@ -31,9 +33,126 @@ func WithClientBackoff(backoff Backoff) ClientOption {
 	}
 }
 
+// WithClientJobBackoff sets backoff implementation that can see the failed job and its error, it replaces the one
+// set with WithClientBackoff. Backoffs set for the job type, queue or the job itself take precedence over it.
+func WithClientJobBackoff(backoff JobBackoff) ClientOption {
+	return func(c *Client) {
+		c.jobBackoff = backoff
+	}
+}
+
+// WithClientTypeBackoff sets backoff implementation that will be applied to errored jobs of the given type.
+// It takes precedence over the queue and client default backoffs. Use AsJobBackoff to set the Backoff.
+func WithClientTypeBackoff(jobType string, backoff JobBackoff) ClientOption {
+	return func(c *Client) {
+		if c.typeBackoffs == nil {
+			c.typeBackoffs = make(map[string]JobBackoff)
+		}
+		c.typeBackoffs[jobType] = backoff
+	}
+}
+
+// WithClientQueueBackoff sets backoff implementation that will be applied to errored jobs from the given queue.
+// It takes precedence over the client default backoff. Use AsJobBackoff to set the Backoff.
+func WithClientQueueBackoff(queue string, backoff JobBackoff) ClientOption {
+	return func(c *Client) {
+		if c.queueBackoffs == nil {
+			c.queueBackoffs = make(map[string]JobBackoff)
+		}
+		c.queueBackoffs[queue] = backoff
+	}
+}
+
+// WithClientNamedBackoff registers backoff implementation under the name, so that it can be chosen for the job
+// on enqueue with Job.BackoffName. It takes precedence over all the other backoffs. Jobs with the backoff name
+// that is not registered fall back to the job type, queue and client default backoffs.
+// Use AsJobBackoff to register the Backoff.
+func WithClientNamedBackoff(name string, backoff JobBackoff) ClientOption {
+	return func(c *Client) {
+		if c.namedBackoffs == nil {
+			c.namedBackoffs = make(map[string]JobBackoff)
+		}
+		c.namedBackoffs[name] = backoff
+	}
+}
+
+// WithClientErrorClassifier sets the ErrorClassifier applied to the jobs locked within current client session,
+// so that the permanent errors are discarded instead of being retried.
+func WithClientErrorClassifier(classifier ErrorClassifier) ClientOption {
+	return func(c *Client) {
+		c.errorClassifier = classifier
+	}
+}
+
+// WithClientPriorityAging overrides default priority aging duration (1ms) - the time the job waits after its run at
+// to raise its effective priority by one when the jobs are locked with PriorityAgingPollStrategy. With the default
+// value JobPriorityLow job overtakes the JobPriorityDefault jobs scheduled more than 16.4 seconds after it. Aging is applied
+// to the jobs enqueued and rescheduled by the client, so all the clients should use the same value.
+func WithClientPriorityAging(d time.Duration) ClientOption {
+	return func(c *Client) {
+		c.priorityAging = d
+	}
+}
+
+// WithClientPartitionPeriod sets the time range of the jobs table partitions created with
+// Client.CreateJobPartitions, default is one day. Partition bounds are aligned to the period in UTC.
+// The period must not be changed once the partitions are created, as the partition end is calculated from it.
+func WithClientPartitionPeriod(d time.Duration) ClientOption {
+	return func(c *Client) {
+		c.partitionPeriod = d
+	}
+}
+
 // WithClientMeter sets metric.Meter instance to the client.
 func WithClientMeter(meter metric.Meter) ClientOption {
 	return func(c *Client) {
 		c.meter = meter
 	}
 }
+
+// WithClientTableName overrides default jobs table name "gue_jobs" with the given value.
+// Use it together with WithClientSchema to host several independent gue installations (e.g. per service or
+// per tenant) in the same database.
+func WithClientTableName(table string) ClientOption {
+	return func(c *Client) {
+		c.table = table
+	}
+}
+
+// WithClientSchema sets the schema name jobs table belongs to. By default, schema is not set and table
+// is being resolved using connection "search_path".
+func WithClientSchema(schema string) ClientOption {
+	return func(c *Client) {
+		c.schema = schema
+	}
+}
+
+// WithClientSchemaVersionCheck enables DB schema version check on client creation - NewClient fails with
+// ErrSchemaVersionMismatch if the schema applied with Migrate does not match LatestSchemaVersion.
+func WithClientSchemaVersionCheck() ClientOption {
+	return func(c *Client) {
+		c.checkSchemaVersion = true
+	}
+}
+
+// WithClientHooksJobEnqueued sets hooks that are called right after the job was enqueued or failed to be enqueued,
+// error field is set in the latter case. For the jobs enqueued within the transaction with Client.EnqueueTx
+// and Client.EnqueueBatchTx hooks are called before the transaction is committed.
+func WithClientHooksJobEnqueued(hooks ...HookFunc) ClientOption {
+	return func(c *Client) {
+		c.hooksJobEnqueued = hooks
+	}
+}
+
+// WithClientAttemptsLog enables the attempts log - every job attempt is recorded with its start and end time,
+// worker ID, error message and panic stacktrace in the job transaction, see Client.ListJobAttempts.
+// With zero retention attempts are deleted together with the job when it completes, is discarded or deleted
+// with Client.DeleteJobs. With positive retention attempts are kept for the retention period after they finished,
+// so that the history of the completed and discarded jobs is available as well, older attempts are pruned
+// automatically by the single pruner per client while its workers are running.
+func WithClientAttemptsLog(retention time.Duration) ClientOption {
+	return func(c *Client) {
+		c.attemptsLog = true
+		c.attemptsRetention = retention
+	}
+}
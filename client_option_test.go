@@ -1,6 +1,7 @@
 package gue
 
 import (
+	"context"
 	"reflect"
 	"testing"
 	"time"
@@ -24,6 +25,29 @@ func TestWithClientID(t *testing.T) {
 	assert.Equal(t, customID, clientWithCustomID.id)
 }
 
+func TestWithClientPriorityAging(t *testing.T) {
+	clientWithDefaultAging, err := NewClient(nil)
+	require.NoError(t, err)
+	assert.Equal(t, defaultPriorityAging, clientWithDefaultAging.priorityAging)
+
+	clientWithCustomAging, err := NewClient(nil, WithClientPriorityAging(time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, time.Second, clientWithCustomAging.priorityAging)
+}
+
+func TestWithClientPartitionPeriod(t *testing.T) {
+	clientWithDefaultPeriod, err := NewClient(nil)
+	require.NoError(t, err)
+	assert.Equal(t, defaultPartitionPeriod, clientWithDefaultPeriod.partitionPeriod)
+
+	clientWithCustomPeriod, err := NewClient(nil, WithClientPartitionPeriod(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, time.Hour, clientWithCustomPeriod.partitionPeriod)
+
+	_, err = NewClient(nil, WithClientPartitionPeriod(0))
+	require.Error(t, err)
+}
+
 func TestWithClientLogger(t *testing.T) {
 	clientWithDefaultLogger, err := NewClient(nil)
 	require.NoError(t, err)
@@ -76,3 +100,55 @@ func TestWithClientMeter(t *testing.T) {
 
 	assert.Equal(t, customMeter, clientWithCustomMeter.meter)
 }
+
+func TestWithClientTableName(t *testing.T) {
+	clientWithDefaultTable, err := NewClient(nil)
+	require.NoError(t, err)
+	assert.Equal(t, defaultTableName, clientWithDefaultTable.table)
+	assert.Equal(t, `"gue_jobs"`, clientWithDefaultTable.jobsTable)
+
+	clientWithCustomTable, err := NewClient(nil, WithClientTableName("my_jobs"))
+	require.NoError(t, err)
+	assert.Equal(t, "my_jobs", clientWithCustomTable.table)
+	assert.Equal(t, `"my_jobs"`, clientWithCustomTable.jobsTable)
+
+	_, err = NewClient(nil, WithClientTableName(""))
+	require.Error(t, err)
+}
+
+func TestWithClientSchema(t *testing.T) {
+	clientWithCustomSchema, err := NewClient(nil, WithClientSchema("tenant_1"))
+	require.NoError(t, err)
+	assert.Equal(t, "tenant_1", clientWithCustomSchema.schema)
+	assert.Equal(t, `"tenant_1"."gue_jobs"`, clientWithCustomSchema.jobsTable)
+
+	clientWithCustomSchemaAndTable, err := NewClient(nil, WithClientSchema(`we"ird`), WithClientTableName("my_jobs"))
+	require.NoError(t, err)
+	assert.Equal(t, `"we""ird"."my_jobs"`, clientWithCustomSchemaAndTable.jobsTable)
+}
+
+func TestWithClientAttemptsLog(t *testing.T) {
+	clientWithoutAttemptsLog, err := NewClient(nil)
+	require.NoError(t, err)
+	assert.False(t, clientWithoutAttemptsLog.attemptsLog)
+	assert.Equal(t, `"gue_attempts"`, clientWithoutAttemptsLog.attemptsTable)
+
+	clientWithAttemptsLog, err := NewClient(
+		nil, WithClientAttemptsLog(time.Hour), WithClientSchema("tenant_1"), WithClientTableName("my_jobs"),
+	)
+	require.NoError(t, err)
+	assert.True(t, clientWithAttemptsLog.attemptsLog)
+	assert.Equal(t, time.Hour, clientWithAttemptsLog.attemptsRetention)
+	assert.Equal(t, `"tenant_1"."my_attempts"`, clientWithAttemptsLog.attemptsTable)
+}
+
+func TestWithClientErrorClassifier(t *testing.T) {
+	classifier := ClassifyErrorIs(context.DeadlineExceeded, ErrorDecision{Action: ErrorActionDiscard})
+
+	c, err := NewClient(nil, WithClientErrorClassifier(classifier))
+	require.NoError(t, err)
+
+	j := c.newLockedJob(nil)
+	require.NotNil(t, j.errorClassifier)
+	assert.Equal(t, ErrorActionDiscard, j.errorClassifier(j, context.DeadlineExceeded).Action)
+}
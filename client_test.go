@@ -58,6 +58,8 @@ func testLockJob(t *testing.T, connPool adapter.ConnPool) {
 	assert.Equal(t, []byte(`{invalid]json>`), j.Args)
 	assert.Equal(t, int32(0), j.ErrorCount)
 	assert.False(t, j.LastError.Valid)
+	assert.WithinDuration(t, newJob.CreatedAt, j.CreatedAt, time.Millisecond)
+	assert.Equal(t, j.CreatedAt, j.UpdatedAt)
 }
 
 func TestLockJobAlreadyLocked(t *testing.T) {
@@ -767,6 +769,67 @@ func TestMultiSchema(t *testing.T) {
 	})
 }
 
+func TestCustomTableAndSchema(t *testing.T) {
+	for name, openFunc := range adapterTesting.AllAdaptersOpenTestPool {
+		t.Run(name, func(t *testing.T) {
+			testCustomTableAndSchema(t, openFunc(t))
+		})
+	}
+}
+
+func testCustomTableAndSchema(t *testing.T, connPool adapter.ConnPool) {
+	ctx := context.Background()
+
+	schema := "gue_custom_" + RandomStringID()
+	_, err := connPool.Exec(ctx, "CREATE SCHEMA "+schema)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, err := connPool.Exec(ctx, "DROP SCHEMA "+schema+" CASCADE")
+		assert.NoError(t, err)
+	})
+
+	c1, err := NewClient(connPool, WithClientSchema(schema), WithClientTableName("jobs_1"))
+	require.NoError(t, err)
+	c2, err := NewClient(connPool, WithClientSchema(schema), WithClientTableName("jobs_2"))
+	require.NoError(t, err)
+
+	for _, c := range []*Client{c1, c2} {
+		_, err := connPool.Exec(ctx, c.SchemaSQL())
+		require.NoError(t, err)
+	}
+
+	newJob := &Job{Type: "MyJob"}
+	err = c1.Enqueue(ctx, newJob)
+	require.NoError(t, err)
+
+	// installations are independent, so the second one should not see the job
+	j2, err := c2.LockJob(ctx, "")
+	require.NoError(t, err)
+	require.Nil(t, j2)
+
+	j1, err := c1.LockJob(ctx, "")
+	require.NoError(t, err)
+	require.NotNil(t, j1)
+	assert.Equal(t, newJob.ID.String(), j1.ID.String())
+
+	err = j1.Error(ctx, errors.New("oops"))
+	require.NoError(t, err)
+
+	j1, err = c1.LockJobByID(ctx, newJob.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), j1.ErrorCount)
+
+	err = j1.Delete(ctx)
+	require.NoError(t, err)
+	err = j1.Done(ctx)
+	require.NoError(t, err)
+
+	var count int
+	err = connPool.QueryRow(ctx, "SELECT COUNT(1) FROM "+c1.jobsTable).Scan(&count)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
+
 func TestJobIDMigration(t *testing.T) {
 	connPool := adapterTesting.OpenTestPoolLibPQCustomSchemas(t, "job_id_migration_01", "job_id_migration_02")
 	ctx := context.Background()
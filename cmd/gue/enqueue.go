@@ -0,0 +1,82 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/vgarvardt/gue/v5"
+)
+
+// enqueueRequest is the JSON representation of the job to enqueue read from stdin.
+type enqueueRequest struct {
+	Type     string          `json:"type"`
+	Queue    string          `json:"queue"`
+	Priority int16           `json:"priority"`
+	RunAt    time.Time       `json:"run_at"`
+	Args     json.RawMessage `json:"args"`
+	// RawArgs are used as job args as is, when set - Args are ignored.
+	// This is useful for non-JSON args, as JSON string is stored without quotes.
+	RawArgs *string `json:"raw_args"`
+	// BackoffName is the name of the backoff registered by the workers, see gue.WithClientNamedBackoff.
+	BackoffName string `json:"backoff_name"`
+	// ExpiresAt is the optional job expiry time, see gue.Job.ExpiresAt.
+	ExpiresAt *time.Time `json:"expires_at"`
+	// FairnessKey is the optional job fairness key, e.g. tenant ID, see gue.Job.FairnessKey.
+	FairnessKey string `json:"fairness_key"`
+}
+
+func newEnqueueCommand(flags *globalFlags) *cobra.Command {
+	return &cobra.Command{
+		Use:   "enqueue",
+		Short: "Enqueues jobs read from stdin as JSON",
+		Long: `Enqueues jobs read from stdin as a stream of JSON objects, e.g.
+
+  echo '{"type": "PrintName", "queue": "name_printer", "args": {"name": "gue"}}' | gue enqueue
+
+Supported fields are "type" (required), "queue", "priority", "run_at" (RFC3339),
+"expires_at" (RFC3339), "args" (any JSON value stored as is), "raw_args" (string stored as is
+instead of "args"), "backoff_name" (name of the backoff registered by the workers)
+and "fairness_key" (e.g. tenant ID the jobs are locked in turns by).`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var jobs []*gue.Job
+			dec := json.NewDecoder(cmd.InOrStdin())
+			for dec.More() {
+				var req enqueueRequest
+				if err := dec.Decode(&req); err != nil {
+					return fmt.Errorf("could not decode job JSON: %w", err)
+				}
+
+				j := &gue.Job{
+					Type:        req.Type,
+					Queue:       req.Queue,
+					Priority:    gue.JobPriority(req.Priority),
+					RunAt:       req.RunAt,
+					Args:        req.Args,
+					BackoffName: req.BackoffName,
+					FairnessKey: req.FairnessKey,
+				}
+				if req.ExpiresAt != nil {
+					j.ExpiresAt = sql.NullTime{Time: *req.ExpiresAt, Valid: true}
+				}
+				if req.RawArgs != nil {
+					j.Args = []byte(*req.RawArgs)
+				}
+
+				jobs = append(jobs, j)
+			}
+
+			return withClient(cmd.Context(), flags, func(gc *gue.Client) error {
+				if err := gc.EnqueueBatch(cmd.Context(), jobs); err != nil {
+					return err
+				}
+
+				return writeJobs(cmd.OutOrStdout(), flags.output, jobs)
+			})
+		},
+	}
+}
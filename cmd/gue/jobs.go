@@ -0,0 +1,237 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	"github.com/spf13/cobra"
+
+	"github.com/vgarvardt/gue/v5"
+)
+
+// filterFlags are the flags for selecting jobs, job IDs are passed as positional arguments.
+type filterFlags struct {
+	queues  []string
+	types   []string
+	failing bool
+	ready   bool
+}
+
+func (f *filterFlags) bind(cmd *cobra.Command) {
+	cmd.Flags().StringSliceVarP(&f.queues, "queue", "q", nil, "filter jobs by queue, may be set several times")
+	cmd.Flags().StringSliceVarP(&f.types, "type", "t", nil, "filter jobs by type, may be set several times")
+	cmd.Flags().BoolVar(&f.failing, "failing", false, "filter jobs that failed at least once")
+	cmd.Flags().BoolVar(&f.ready, "ready", false, "filter jobs that are eligible for execution")
+}
+
+func (f *filterFlags) filter(args []string) (gue.JobFilter, error) {
+	filter := gue.JobFilter{
+		Queues:      f.queues,
+		Types:       f.types,
+		OnlyFailing: f.failing,
+		OnlyReady:   f.ready,
+	}
+
+	for _, arg := range args {
+		id, err := ulid.ParseStrict(arg)
+		if err != nil {
+			return filter, fmt.Errorf("invalid job ID %q: %w", arg, err)
+		}
+		filter.IDs = append(filter.IDs, id)
+	}
+
+	return filter, nil
+}
+
+func (f *filterFlags) empty(args []string) bool {
+	return len(args) == 0 && len(f.queues) == 0 && len(f.types) == 0 && !f.failing && !f.ready
+}
+
+func newJobsCommand(flags *globalFlags) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "jobs",
+		Short: "Inspects and manages jobs",
+	}
+
+	cmd.AddCommand(
+		newJobsListCommand(flags),
+		newJobsGetCommand(flags),
+		newJobsRetryCommand(flags),
+		newJobsRescheduleCommand(flags),
+		newJobsDeleteCommand(flags),
+	)
+
+	return cmd
+}
+
+func newJobsListCommand(flags *globalFlags) *cobra.Command {
+	var (
+		ff    filterFlags
+		limit int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "list [job-id...]",
+		Short: "Lists jobs ordered by the enqueue time",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			filter, err := ff.filter(args)
+			if err != nil {
+				return err
+			}
+			filter.Limit = limit
+
+			return withClient(cmd.Context(), flags, func(gc *gue.Client) error {
+				jobs, err := gc.ListJobs(cmd.Context(), filter)
+				if err != nil {
+					return err
+				}
+
+				return writeJobs(cmd.OutOrStdout(), flags.output, jobs)
+			})
+		},
+	}
+
+	ff.bind(cmd)
+	cmd.Flags().IntVar(&limit, "limit", 100, "max number of jobs to list, 0 for no limit")
+
+	return cmd
+}
+
+func newJobsGetCommand(flags *globalFlags) *cobra.Command {
+	return &cobra.Command{
+		Use:   "get <job-id>",
+		Short: "Shows job details including the last error",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := ulid.ParseStrict(args[0])
+			if err != nil {
+				return fmt.Errorf("invalid job ID %q: %w", args[0], err)
+			}
+
+			return withClient(cmd.Context(), flags, func(gc *gue.Client) error {
+				j, err := gc.GetJob(cmd.Context(), id)
+				if err != nil {
+					return fmt.Errorf("could not get job: %w", err)
+				}
+
+				return writeJob(cmd.OutOrStdout(), flags.output, j)
+			})
+		},
+	}
+}
+
+func newJobsRetryCommand(flags *globalFlags) *cobra.Command {
+	var ff filterFlags
+
+	cmd := &cobra.Command{
+		Use:   "retry [job-id...]",
+		Short: "Makes jobs eligible for the immediate execution",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if ff.empty(args) {
+				return errors.New("at least one job ID or filter must be set")
+			}
+
+			filter, err := ff.filter(args)
+			if err != nil {
+				return err
+			}
+
+			return withClient(cmd.Context(), flags, func(gc *gue.Client) error {
+				n, err := gc.RetryJobs(cmd.Context(), filter)
+				if err != nil {
+					return err
+				}
+
+				return writeAffected(cmd.OutOrStdout(), flags.output, "retried", n)
+			})
+		},
+	}
+
+	ff.bind(cmd)
+
+	return cmd
+}
+
+func newJobsRescheduleCommand(flags *globalFlags) *cobra.Command {
+	var (
+		ff filterFlags
+		at string
+		in time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "reschedule [job-id...]",
+		Short: "Changes jobs scheduled time",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if ff.empty(args) {
+				return errors.New("at least one job ID or filter must be set")
+			}
+
+			if (at == "") == (in == 0) {
+				return errors.New("exactly one of --at or --in must be set")
+			}
+
+			runAt := time.Now().Add(in)
+			if at != "" {
+				var err error
+				if runAt, err = time.Parse(time.RFC3339, at); err != nil {
+					return fmt.Errorf("invalid --at value: %w", err)
+				}
+			}
+
+			filter, err := ff.filter(args)
+			if err != nil {
+				return err
+			}
+
+			return withClient(cmd.Context(), flags, func(gc *gue.Client) error {
+				n, err := gc.RescheduleJobs(cmd.Context(), filter, runAt)
+				if err != nil {
+					return err
+				}
+
+				return writeAffected(cmd.OutOrStdout(), flags.output, "rescheduled", n)
+			})
+		},
+	}
+
+	ff.bind(cmd)
+	cmd.Flags().StringVar(&at, "at", "", "time to reschedule jobs to in RFC3339 format, e.g. 2023-01-02T15:04:05Z")
+	cmd.Flags().DurationVar(&in, "in", 0, "duration from now to reschedule jobs to, e.g. 15m")
+
+	return cmd
+}
+
+func newJobsDeleteCommand(flags *globalFlags) *cobra.Command {
+	var ff filterFlags
+
+	cmd := &cobra.Command{
+		Use:   "delete [job-id...]",
+		Short: "Deletes jobs, except for the ones being worked at the moment",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if ff.empty(args) {
+				return errors.New("at least one job ID or filter must be set, use purge command to delete all queue jobs")
+			}
+
+			filter, err := ff.filter(args)
+			if err != nil {
+				return err
+			}
+
+			return withClient(cmd.Context(), flags, func(gc *gue.Client) error {
+				n, err := gc.DeleteJobs(cmd.Context(), filter)
+				if err != nil {
+					return err
+				}
+
+				return writeAffected(cmd.OutOrStdout(), flags.output, "deleted", n)
+			})
+		},
+	}
+
+	ff.bind(cmd)
+
+	return cmd
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/vgarvardt/gue/v5"
+)
+
+func newMigrateCommand(flags *globalFlags) *cobra.Command {
+	var (
+		dryRun      bool
+		partitionBy string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Applies gue DB schema migrations",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			pool, err := openPool(ctx, flags)
+			if err != nil {
+				return err
+			}
+			defer pool.Close()
+
+			options := append(flags.migrateOptions(), gue.WithMigratePartitioning(gue.Partitioning(partitionBy)))
+			if dryRun {
+				options = append(options, gue.WithMigrateDryRun(cmd.OutOrStdout()))
+			}
+
+			if err := gue.Migrate(ctx, pool, options...); err != nil {
+				return err
+			}
+
+			if !dryRun {
+				cmd.Printf("gue DB schema is up to date, version %d\n", gue.LatestSchemaVersion)
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "print SQL of the migrations to be applied instead of applying them")
+	cmd.Flags().StringVar(
+		&partitionBy, "partition-by", "",
+		"partition jobs table by queue or created_at, only the empty jobs table can be partitioned",
+	)
+
+	return cmd
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/vgarvardt/gue/v5"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+// jobView is the output representation of the job.
+type jobView struct {
+	ID         string          `json:"id"`
+	Queue      string          `json:"queue"`
+	Type       string          `json:"type"`
+	Priority   int16           `json:"priority"`
+	RunAt      time.Time       `json:"run_at"`
+	ErrorCount int32           `json:"error_count"`
+	LastError  *string         `json:"last_error,omitempty"`
+	Args       json.RawMessage `json:"args,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+}
+
+func newJobView(j *gue.Job) jobView {
+	v := jobView{
+		ID:         j.ID.String(),
+		Queue:      j.Queue,
+		Type:       j.Type,
+		Priority:   int16(j.Priority),
+		RunAt:      j.RunAt,
+		ErrorCount: j.ErrorCount,
+		CreatedAt:  j.CreatedAt,
+		UpdatedAt:  j.UpdatedAt,
+	}
+
+	if j.LastError.Valid {
+		v.LastError = &j.LastError.String
+	}
+
+	if len(j.Args) > 0 {
+		if json.Valid(j.Args) {
+			v.Args = j.Args
+		} else {
+			// args are not necessarily JSON, output them as a string then
+			v.Args, _ = json.Marshal(string(j.Args))
+		}
+	}
+
+	return v
+}
+
+// queueView is the output representation of the queue stats.
+type queueView struct {
+	Queue          string     `json:"queue"`
+	Ready          int64      `json:"ready"`
+	Scheduled      int64      `json:"scheduled"`
+	Failing        int64      `json:"failing"`
+	OldestReadyAge *float64   `json:"oldest_ready_age_seconds,omitempty"`
+	OldestReadyAt  *time.Time `json:"oldest_ready_run_at,omitempty"`
+}
+
+func newQueueView(s gue.QueueStats, now time.Time) queueView {
+	v := queueView{Queue: s.Queue, Ready: s.Ready, Scheduled: s.Scheduled, Failing: s.Failing}
+	if s.OldestReadyRunAt.Valid {
+		age := now.Sub(s.OldestReadyRunAt.Time).Seconds()
+		v.OldestReadyAge = &age
+		v.OldestReadyAt = &s.OldestReadyRunAt.Time
+	}
+
+	return v
+}
+
+// pauseView is the output representation of the paused queue or job type.
+type pauseView struct {
+	Kind     string    `json:"kind"`
+	Name     string    `json:"name"`
+	PausedAt time.Time `json:"paused_at"`
+}
+
+// workerView is the output representation of the registered worker.
+type workerView struct {
+	ID          string     `json:"id"`
+	Host        string     `json:"host"`
+	PID         int        `json:"pid"`
+	Queue       string     `json:"queue"`
+	StartedAt   time.Time  `json:"started_at"`
+	HeartbeatAt time.Time  `json:"heartbeat_at"`
+	JobID       string     `json:"job_id,omitempty"`
+	JobType     string     `json:"job_type,omitempty"`
+	JobLockedAt *time.Time `json:"job_locked_at,omitempty"`
+}
+
+func newWorkerView(wi gue.WorkerInfo) workerView {
+	v := workerView{
+		ID:          wi.ID,
+		Host:        wi.Host,
+		PID:         wi.PID,
+		Queue:       wi.Queue,
+		StartedAt:   wi.StartedAt,
+		HeartbeatAt: wi.HeartbeatAt,
+	}
+
+	if wi.CurrentJob != nil {
+		v.JobID = wi.CurrentJob.ID.String()
+		v.JobType = wi.CurrentJob.Type
+		v.JobLockedAt = &wi.CurrentJob.LockedAt
+	}
+
+	return v
+}
+
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func writeJobs(w io.Writer, format string, jobs []*gue.Job) error {
+	views := make([]jobView, len(jobs))
+	for i := range jobs {
+		views[i] = newJobView(jobs[i])
+	}
+
+	if format == outputJSON {
+		return writeJSON(w, views)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tQUEUE\tTYPE\tPRIORITY\tRUN AT\tERRORS\tLAST ERROR")
+	for _, v := range views {
+		lastError := ""
+		if v.LastError != nil {
+			lastError = firstLine(*v.LastError, 60)
+		}
+		fmt.Fprintf(
+			tw, "%s\t%s\t%s\t%d\t%s\t%d\t%s\n",
+			v.ID, displayQueue(v.Queue), v.Type, v.Priority, v.RunAt.Format(time.RFC3339), v.ErrorCount, lastError,
+		)
+	}
+
+	return tw.Flush()
+}
+
+func writeJob(w io.Writer, format string, j *gue.Job) error {
+	v := newJobView(j)
+	if format == outputJSON {
+		return writeJSON(w, v)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "ID:\t%s\n", v.ID)
+	fmt.Fprintf(tw, "Queue:\t%s\n", displayQueue(v.Queue))
+	fmt.Fprintf(tw, "Type:\t%s\n", v.Type)
+	fmt.Fprintf(tw, "Priority:\t%d\n", v.Priority)
+	fmt.Fprintf(tw, "Run At:\t%s\n", v.RunAt.Format(time.RFC3339Nano))
+	fmt.Fprintf(tw, "Errors:\t%d\n", v.ErrorCount)
+	fmt.Fprintf(tw, "Created At:\t%s\n", v.CreatedAt.Format(time.RFC3339Nano))
+	fmt.Fprintf(tw, "Updated At:\t%s\n", v.UpdatedAt.Format(time.RFC3339Nano))
+	fmt.Fprintf(tw, "Args:\t%s\n", string(v.Args))
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if v.LastError != nil {
+		fmt.Fprintf(w, "Last Error:\n%s\n", *v.LastError)
+	}
+
+	return nil
+}
+
+func writeQueues(w io.Writer, format string, stats []gue.QueueStats) error {
+	now := time.Now()
+	views := make([]queueView, len(stats))
+	for i := range stats {
+		views[i] = newQueueView(stats[i], now)
+	}
+
+	if format == outputJSON {
+		return writeJSON(w, views)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "QUEUE\tREADY\tSCHEDULED\tFAILING\tOLDEST READY AGE")
+	for _, v := range views {
+		age := "-"
+		if v.OldestReadyAge != nil {
+			age = (time.Duration(*v.OldestReadyAge) * time.Second).String()
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%s\n", displayQueue(v.Queue), v.Ready, v.Scheduled, v.Failing, age)
+	}
+
+	return tw.Flush()
+}
+
+func writeAffected(w io.Writer, format, action string, n int64) error {
+	if format == outputJSON {
+		return writeJSON(w, map[string]int64{action: n})
+	}
+
+	_, err := fmt.Fprintf(w, "%s: %s\n", action, strconv.FormatInt(n, 10))
+	return err
+}
+
+func writePaused(w io.Writer, format string, paused []gue.Pause) error {
+	views := make([]pauseView, len(paused))
+	for i, p := range paused {
+		views[i] = pauseView{Kind: string(p.Kind), Name: p.Name, PausedAt: p.PausedAt}
+	}
+
+	if format == outputJSON {
+		return writeJSON(w, views)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "KIND\tNAME\tPAUSED AT")
+	for _, v := range views {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", v.Kind, displayQueue(v.Name), v.PausedAt.Format(time.RFC3339))
+	}
+
+	return tw.Flush()
+}
+
+// displayQueue makes default nameless queue visible in the table output.
+func displayQueue(queue string) string {
+	if queue == "" {
+		return `""`
+	}
+	return queue
+}
+
+// firstLine returns the first line of the multiline string, e.g. panic stacktrace, truncated to maxLen runes.
+func firstLine(s string, maxLen int) string {
+	line, _, _ := strings.Cut(s, "\n")
+	if r := []rune(line); len(r) > maxLen {
+		return string(r[:maxLen]) + "…"
+	}
+	return line
+}
+
+func writeWorkers(w io.Writer, format string, workers []gue.WorkerInfo) error {
+	views := make([]workerView, len(workers))
+	for i := range workers {
+		views[i] = newWorkerView(workers[i])
+	}
+
+	if format == outputJSON {
+		return writeJSON(w, views)
+	}
+
+	now := time.Now()
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tHOST\tPID\tQUEUE\tSTARTED AT\tLAST HEARTBEAT\tJOB\tJOB TYPE\tWORKING FOR")
+	for _, v := range views {
+		workingFor := ""
+		if v.JobLockedAt != nil {
+			workingFor = now.Sub(*v.JobLockedAt).Round(time.Second).String()
+		}
+		fmt.Fprintf(
+			tw, "%s\t%s\t%d\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			v.ID, v.Host, v.PID, displayQueue(v.Queue), v.StartedAt.Format(time.RFC3339),
+			now.Sub(v.HeartbeatAt).Round(time.Second).String()+" ago", v.JobID, v.JobType, workingFor,
+		)
+	}
+
+	return tw.Flush()
+}
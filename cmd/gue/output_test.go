@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vgarvardt/gue/v5"
+)
+
+func TestWriteJobs(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	jobs := []*gue.Job{
+		{ID: ulid.Make(), Type: "json", RunAt: now, Args: []byte(`{"foo":"bar"}`)},
+		{
+			ID:         ulid.Make(),
+			Queue:      "q",
+			Type:       "binary",
+			RunAt:      now,
+			Args:       []byte("not a json"),
+			ErrorCount: 3,
+			LastError:  sql.NullString{String: "panic: oops\ngoroutine 1 [running]:", Valid: true},
+		},
+	}
+
+	buf := new(bytes.Buffer)
+	err := writeJobs(buf, outputJSON, jobs)
+	require.NoError(t, err)
+
+	var views []jobView
+	err = json.Unmarshal(buf.Bytes(), &views)
+	require.NoError(t, err)
+	require.Len(t, views, 2)
+	assert.JSONEq(t, `{"foo":"bar"}`, string(views[0].Args))
+	assert.Nil(t, views[0].LastError)
+	assert.JSONEq(t, `"not a json"`, string(views[1].Args))
+	require.NotNil(t, views[1].LastError)
+	assert.Equal(t, jobs[1].LastError.String, *views[1].LastError)
+
+	buf.Reset()
+	err = writeJobs(buf, outputTable, jobs)
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), jobs[0].ID.String())
+	assert.Contains(t, buf.String(), "panic: oops")
+	assert.NotContains(t, buf.String(), "goroutine 1")
+}
+
+func TestFilterFlags(t *testing.T) {
+	ff := filterFlags{queues: []string{"q"}}
+	assert.False(t, ff.empty(nil))
+	assert.True(t, (&filterFlags{}).empty(nil))
+
+	id := ulid.Make()
+	filter, err := ff.filter([]string{id.String()})
+	require.NoError(t, err)
+	assert.Equal(t, []ulid.ULID{id}, filter.IDs)
+	assert.Equal(t, []string{"q"}, filter.Queues)
+
+	_, err = ff.filter([]string{"not-an-id"})
+	assert.Error(t, err)
+}
+
+func TestWriteWorkers(t *testing.T) {
+	now := time.Now().UTC()
+	workers := []gue.WorkerInfo{
+		{ID: "idle", Host: "host-1", PID: 1, StartedAt: now, HeartbeatAt: now},
+		{
+			ID:          "busy",
+			Host:        "host-2",
+			PID:         2,
+			Queue:       "q",
+			StartedAt:   now,
+			HeartbeatAt: now,
+			CurrentJob:  &gue.WorkerJob{ID: ulid.Make(), Type: "MyJob", LockedAt: now.Add(-14 * time.Minute)},
+		},
+	}
+
+	buf := new(bytes.Buffer)
+	err := writeWorkers(buf, outputJSON, workers)
+	require.NoError(t, err)
+
+	var views []workerView
+	err = json.Unmarshal(buf.Bytes(), &views)
+	require.NoError(t, err)
+	require.Len(t, views, 2)
+	assert.Empty(t, views[0].JobID)
+	assert.Nil(t, views[0].JobLockedAt)
+	assert.Equal(t, workers[1].CurrentJob.ID.String(), views[1].JobID)
+
+	buf.Reset()
+	err = writeWorkers(buf, outputTable, workers)
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), workers[1].CurrentJob.ID.String())
+	assert.Contains(t, buf.String(), "14m0s")
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"github.com/vgarvardt/gue/v5"
+)
+
+func newPauseCommand(flags *globalFlags) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "pause",
+		Short: "Pauses queue or job type, workers stop locking their jobs until resumed",
+	}
+
+	cmd.AddCommand(
+		newPauseActionCommand(flags, "queue <queue>", "Pauses queue", (*gue.Client).PauseQueue),
+		newPauseActionCommand(flags, "type <job-type>", "Pauses job type in all the queues", (*gue.Client).PauseJobType),
+	)
+
+	return cmd
+}
+
+func newResumeCommand(flags *globalFlags) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "resume",
+		Short: "Resumes paused queue or job type",
+	}
+
+	cmd.AddCommand(
+		newPauseActionCommand(flags, "queue <queue>", "Resumes queue", (*gue.Client).ResumeQueue),
+		newPauseActionCommand(flags, "type <job-type>", "Resumes job type", (*gue.Client).ResumeJobType),
+	)
+
+	return cmd
+}
+
+func newPauseActionCommand(
+	flags *globalFlags,
+	use, short string,
+	action func(c *gue.Client, ctx context.Context, name string) error,
+) *cobra.Command {
+	return &cobra.Command{
+		Use:   use,
+		Short: short,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withClient(cmd.Context(), flags, func(gc *gue.Client) error {
+				return action(gc, cmd.Context(), args[0])
+			})
+		},
+	}
+}
+
+func newPausedCommand(flags *globalFlags) *cobra.Command {
+	return &cobra.Command{
+		Use:   "paused",
+		Short: "Lists paused queues and job types",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withClient(cmd.Context(), flags, func(gc *gue.Client) error {
+				paused, err := gc.ListPaused(cmd.Context())
+				if err != nil {
+					return err
+				}
+
+				return writePaused(cmd.OutOrStdout(), flags.output, paused)
+			})
+		},
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/vgarvardt/gue/v5"
+)
+
+func newQueuesCommand(flags *globalFlags) *cobra.Command {
+	return &cobra.Command{
+		Use:   "queues",
+		Short: "Shows per-queue depth and the oldest ready job age",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withClient(cmd.Context(), flags, func(gc *gue.Client) error {
+				stats, err := gc.QueueStats(cmd.Context())
+				if err != nil {
+					return err
+				}
+
+				return writeQueues(cmd.OutOrStdout(), flags.output, stats)
+			})
+		},
+	}
+}
+
+func newPurgeCommand(flags *globalFlags) *cobra.Command {
+	var confirm bool
+
+	cmd := &cobra.Command{
+		Use:   "purge <queue>",
+		Short: "Deletes all the jobs from the queue, except for the ones being worked at the moment",
+		Long: `Deletes all the jobs from the queue, except for the ones being worked at the moment.
+Use "" to purge the default nameless queue.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !confirm {
+				return errors.New("purge is destructive, confirm it with --yes flag")
+			}
+
+			return withClient(cmd.Context(), flags, func(gc *gue.Client) error {
+				n, err := gc.PurgeQueue(cmd.Context(), args[0])
+				if err != nil {
+					return fmt.Errorf("could not purge queue: %w", err)
+				}
+
+				return writeAffected(cmd.OutOrStdout(), flags.output, "deleted", n)
+			})
+		},
+	}
+
+	cmd.Flags().BoolVar(&confirm, "yes", false, "confirm queue purge")
+
+	return cmd
+}
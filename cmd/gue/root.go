@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+
+	pgxV4 "github.com/jackc/pgx/v4/pgxpool"
+	pgxV5 "github.com/jackc/pgx/v5/pgxpool"
+	_ "github.com/lib/pq" // register postgres driver
+	"github.com/spf13/cobra"
+
+	"github.com/vgarvardt/gue/v5"
+	"github.com/vgarvardt/gue/v5/adapter"
+	"github.com/vgarvardt/gue/v5/adapter/libpq"
+	"github.com/vgarvardt/gue/v5/adapter/pgxv4"
+	"github.com/vgarvardt/gue/v5/adapter/pgxv5"
+)
+
+const (
+	driverPGXv5 = "pgx/v5"
+	driverPGXv4 = "pgx/v4"
+	driverLibPQ = "lib/pq"
+)
+
+// globalFlags are the flags shared by all the commands.
+type globalFlags struct {
+	dsn    string
+	driver string
+	schema string
+	table  string
+	output string
+}
+
+func (f *globalFlags) migrateOptions() []gue.MigrateOption {
+	options := []gue.MigrateOption{gue.WithMigrateSchema(f.schema)}
+	if f.table != "" {
+		options = append(options, gue.WithMigrateTableName(f.table))
+	}
+
+	return options
+}
+
+func (f *globalFlags) clientOptions() []gue.ClientOption {
+	options := []gue.ClientOption{gue.WithClientID("gue-cli-" + gue.RandomStringID()), gue.WithClientSchema(f.schema)}
+	if f.table != "" {
+		options = append(options, gue.WithClientTableName(f.table))
+	}
+
+	return options
+}
+
+func newRootCommand() *cobra.Command {
+	flags := new(globalFlags)
+
+	cmd := &cobra.Command{
+		Use:          "gue [command]",
+		Short:        "Command-line tool for operating gue queues",
+		SilenceUsage: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if flags.output != outputTable && flags.output != outputJSON {
+				return fmt.Errorf("unknown output format %q, must be one of: %s, %s", flags.output, outputTable, outputJSON)
+			}
+			return nil
+		},
+	}
+
+	cmd.PersistentFlags().StringVar(&flags.dsn, "dsn", os.Getenv("DATABASE_URL"), "DB connection string, defaults to DATABASE_URL env var")
+	cmd.PersistentFlags().StringVar(&flags.driver, "driver", driverPGXv5, fmt.Sprintf("DB driver adapter to use: %s, %s or %s", driverPGXv5, driverPGXv4, driverLibPQ))
+	cmd.PersistentFlags().StringVar(&flags.schema, "schema", "", "DB schema gue jobs table belongs to, connection search_path is used if not set")
+	cmd.PersistentFlags().StringVar(&flags.table, "table", "", "gue jobs table name, default one is used if not set")
+	cmd.PersistentFlags().StringVarP(&flags.output, "output", "o", outputTable, fmt.Sprintf("output format: %s or %s", outputTable, outputJSON))
+
+	cmd.AddCommand(
+		newMigrateCommand(flags),
+		newQueuesCommand(flags),
+		newJobsCommand(flags),
+		newEnqueueCommand(flags),
+		newPurgeCommand(flags),
+		newPauseCommand(flags),
+		newResumeCommand(flags),
+		newPausedCommand(flags),
+		newWorkersCommand(flags),
+		newTailCommand(flags),
+	)
+
+	return cmd
+}
+
+// openPool opens connection pool using the driver adapter set in the flags. Caller is responsible for closing it.
+func openPool(ctx context.Context, flags *globalFlags) (adapter.ConnPool, error) {
+	if flags.dsn == "" {
+		return nil, errors.New("DB connection string is not set, use --dsn flag or DATABASE_URL env var")
+	}
+
+	switch flags.driver {
+	case driverPGXv5:
+		pool, err := pgxV5.New(ctx, flags.dsn)
+		if err != nil {
+			return nil, fmt.Errorf("could not connect to the DB: %w", err)
+		}
+		return pgxv5.NewConnPool(pool), nil
+
+	case driverPGXv4:
+		pool, err := pgxV4.Connect(ctx, flags.dsn)
+		if err != nil {
+			return nil, fmt.Errorf("could not connect to the DB: %w", err)
+		}
+		return pgxv4.NewConnPool(pool), nil
+
+	case driverLibPQ:
+		db, err := sql.Open("postgres", flags.dsn)
+		if err != nil {
+			return nil, fmt.Errorf("could not connect to the DB: %w", err)
+		}
+		return libpq.NewConnPool(db), nil
+	}
+
+	return nil, fmt.Errorf("unknown driver %q", flags.driver)
+}
+
+// withClient opens connection pool, instantiates gue client and calls f with it, closing the pool afterwards.
+func withClient(ctx context.Context, flags *globalFlags, f func(gc *gue.Client) error) error {
+	pool, err := openPool(ctx, flags)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	gc, err := gue.NewClient(pool, flags.clientOptions()...)
+	if err != nil {
+		return fmt.Errorf("could not instantiate gue client: %w", err)
+	}
+
+	return f(gc)
+}
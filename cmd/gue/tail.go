@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/vgarvardt/gue/v5"
+)
+
+func newTailCommand(flags *globalFlags) *cobra.Command {
+	var (
+		ff       filterFlags
+		interval time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "tail",
+		Short: "Follows newly enqueued jobs until interrupted",
+		Long: `Follows newly enqueued jobs until interrupted. Jobs are polled from the DB with the given interval,
+so jobs that were enqueued and processed in between polls are not shown.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			if interval <= 0 {
+				return errors.New("--interval must be positive")
+			}
+
+			filter, err := ff.filter(nil)
+			if err != nil {
+				return err
+			}
+			// job IDs are lexicographically sortable by creation time, so start from the current moment
+			filter.AfterID.SetTime(uint64(time.Now().UnixMilli()))
+
+			return withClient(ctx, flags, func(gc *gue.Client) error {
+				ticker := time.NewTicker(interval)
+				defer ticker.Stop()
+
+				for {
+					jobs, err := gc.ListJobs(ctx, filter)
+					if err != nil {
+						return err
+					}
+
+					if len(jobs) > 0 {
+						filter.AfterID = jobs[len(jobs)-1].ID
+						if err := writeJobs(cmd.OutOrStdout(), flags.output, jobs); err != nil {
+							return err
+						}
+					}
+
+					select {
+					case <-ctx.Done():
+						return nil
+					case <-ticker.C:
+					}
+				}
+			})
+		},
+	}
+
+	ff.bind(cmd)
+	cmd.Flags().DurationVar(&interval, "interval", time.Second, "poll interval")
+
+	return cmd
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTailCommandInterval(t *testing.T) {
+	for _, interval := range []string{"0", "-1s"} {
+		cmd := newTailCommand(new(globalFlags))
+		cmd.SetArgs([]string{"--interval", interval})
+		cmd.SilenceErrors = true
+
+		err := cmd.Execute()
+		require.Error(t, err, interval)
+		assert.Contains(t, err.Error(), "--interval must be positive")
+	}
+}
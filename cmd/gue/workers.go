@@ -0,0 +1,27 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/vgarvardt/gue/v5"
+)
+
+func newWorkersCommand(flags *globalFlags) *cobra.Command {
+	return &cobra.Command{
+		Use:   "workers",
+		Short: "Shows alive workers and jobs they are working on",
+		Long: `Shows alive workers and jobs they are working on.
+Only workers with heartbeats enabled register themselves, see gue.WithWorkerHeartbeat.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withClient(cmd.Context(), flags, func(gc *gue.Client) error {
+				workers, err := gc.ListWorkers(cmd.Context())
+				if err != nil {
+					return err
+				}
+
+				return writeWorkers(cmd.OutOrStdout(), flags.output, workers)
+			})
+		},
+	}
+}
@@ -0,0 +1,437 @@
+// Package dashboard implements HTTP admin API and embedded web dashboard for gue queues.
+//
+// Handler can be mounted into the existing HTTP server, e.g.
+//
+//	mux.Handle("/gue/", http.StripPrefix("/gue", dashboard.New(gc, dashboard.WithAuthorize(authorize))))
+//
+// Handler serves the following endpoints:
+//   - GET / - web dashboard
+//   - GET /api/queues - queues stats
+//   - GET /api/jobs - jobs list, supports "queue", "type", "failing", "ready", "after" and "limit" query params
+//   - GET /api/jobs/{id} - job details including the last error or panic stacktrace
+//   - POST /api/jobs/{id}/retry - make job eligible for the immediate execution
+//   - POST /api/jobs/{id}/reschedule - reschedule job to the "run_at" time from the JSON request body
+//   - DELETE /api/jobs/{id} - delete job
+//   - GET /api/paused - paused queues and job types
+//   - POST /api/paused - pause queue or job type set as "kind" and "name" in the JSON request body
+//   - DELETE /api/paused - resume queue or job type set as "kind" and "name" in the JSON request body
+//   - GET /api/workers - alive workers registered in the worker registry and jobs they are working on
+//
+// Mutating requests (POST and DELETE) must be sent with the "Content-Type: application/json" header, even if they
+// have no body, so that they can not be forged by another site with the plain HTML form submit.
+package dashboard
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+
+	"github.com/vgarvardt/gue/v5"
+	"github.com/vgarvardt/gue/v5/adapter"
+)
+
+// Action is the operation authorization callback is called for.
+type Action string
+
+const (
+	// ActionView is the read-only access to queues and jobs, including the web dashboard itself.
+	ActionView Action = "view"
+	// ActionRetry is the job retry.
+	ActionRetry Action = "retry"
+	// ActionReschedule is the job reschedule.
+	ActionReschedule Action = "reschedule"
+	// ActionDelete is the job deletion.
+	ActionDelete Action = "delete"
+	// ActionPause is the queue or job type pause and resume.
+	ActionPause Action = "pause"
+
+	defaultJobsLimit = 100
+	maxJobsLimit     = 1000
+)
+
+// AuthorizeFunc decides if the request is allowed to perform the action. Return an error to deny the request,
+// error message is returned to the client with the 403 Forbidden status.
+type AuthorizeFunc func(r *http.Request, action Action) error
+
+//go:embed ui
+var uiFS embed.FS
+
+// Handler is the http.Handler serving admin API and web dashboard.
+type Handler struct {
+	c         *gue.Client
+	authorize AuthorizeFunc
+	logger    adapter.Logger
+	mux       *http.ServeMux
+}
+
+// New instantiates new admin API and web dashboard Handler.
+//
+// By default, only the ActionView requests are allowed and all the mutating ones are denied, use WithAuthorize option
+// to allow them and make sure the handler is mounted behind the authentication middleware.
+func New(c *gue.Client, options ...Option) *Handler {
+	h := Handler{
+		c:         c,
+		authorize: authorizeView,
+		logger:    adapter.NoOpLogger{},
+		mux:       http.NewServeMux(),
+	}
+
+	for _, option := range options {
+		option(&h)
+	}
+
+	h.mux.HandleFunc("/api/queues", h.handleQueues)
+	h.mux.HandleFunc("/api/jobs", h.handleJobs)
+	h.mux.HandleFunc("/api/jobs/", h.handleJob)
+	h.mux.HandleFunc("/api/paused", h.handlePaused)
+	h.mux.HandleFunc("/api/workers", h.handleWorkers)
+	h.mux.HandleFunc("/", h.handleUI)
+
+	return &h
+}
+
+// authorizeView is the default AuthorizeFunc that allows only read-only access.
+func authorizeView(_ *http.Request, action Action) error {
+	if action != ActionView {
+		return fmt.Errorf("action %q is not allowed, use dashboard.WithAuthorize option to allow it", action)
+	}
+
+	return nil
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *Handler) handleUI(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" && r.URL.Path != "/index.html" {
+		http.NotFound(w, r)
+		return
+	}
+	if !h.allowed(w, r, ActionView, http.MethodGet) {
+		return
+	}
+
+	page, err := uiFS.ReadFile("ui/index.html")
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(page)
+}
+
+func (h *Handler) handleQueues(w http.ResponseWriter, r *http.Request) {
+	if !h.allowed(w, r, ActionView, http.MethodGet) {
+		return
+	}
+
+	stats, err := h.c.QueueStats(r.Context())
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	now := time.Now()
+	views := make([]queueView, len(stats))
+	for i := range stats {
+		views[i] = newQueueView(stats[i], now)
+	}
+
+	h.writeJSON(w, http.StatusOK, views)
+}
+
+func (h *Handler) handleJobs(w http.ResponseWriter, r *http.Request) {
+	if !h.allowed(w, r, ActionView, http.MethodGet) {
+		return
+	}
+
+	filter, err := parseJobFilter(r)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	jobs, err := h.c.ListJobs(r.Context(), filter)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	views := make([]jobView, len(jobs))
+	for i := range jobs {
+		views[i] = newJobView(jobs[i])
+	}
+
+	h.writeJSON(w, http.StatusOK, views)
+}
+
+func (h *Handler) handleJob(w http.ResponseWriter, r *http.Request) {
+	idStr, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/jobs/"), "/")
+	id, err := ulid.ParseStrict(idStr)
+	if err != nil {
+		h.writeError(w, http.StatusNotFound, errors.New("invalid job ID"))
+		return
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodDelete:
+		h.handleJobDelete(w, r, id)
+	case action == "":
+		h.handleJobGet(w, r, id)
+	case action == "retry":
+		h.handleJobRetry(w, r, id)
+	case action == "reschedule":
+		h.handleJobReschedule(w, r, id)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (h *Handler) handleJobGet(w http.ResponseWriter, r *http.Request, id ulid.ULID) {
+	if !h.allowed(w, r, ActionView, http.MethodGet, http.MethodDelete) {
+		return
+	}
+
+	j, err := h.c.GetJob(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, adapter.ErrNoRows) {
+			h.writeError(w, http.StatusNotFound, errors.New("job not found"))
+			return
+		}
+		h.writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, newJobView(j))
+}
+
+func (h *Handler) handleJobRetry(w http.ResponseWriter, r *http.Request, id ulid.ULID) {
+	if !h.allowed(w, r, ActionRetry, http.MethodPost) {
+		return
+	}
+
+	n, err := h.c.RetryJobs(r.Context(), gue.JobFilter{IDs: []ulid.ULID{id}})
+	h.writeAffected(w, n, err)
+}
+
+func (h *Handler) handleJobReschedule(w http.ResponseWriter, r *http.Request, id ulid.ULID) {
+	if !h.allowed(w, r, ActionReschedule, http.MethodPost) {
+		return
+	}
+
+	var req struct {
+		RunAt time.Time `json:"run_at"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RunAt.IsZero() {
+		h.writeError(w, http.StatusBadRequest, errors.New(`request body must be JSON with "run_at" time set`))
+		return
+	}
+
+	n, err := h.c.RescheduleJobs(r.Context(), gue.JobFilter{IDs: []ulid.ULID{id}}, req.RunAt)
+	h.writeAffected(w, n, err)
+}
+
+func (h *Handler) handleJobDelete(w http.ResponseWriter, r *http.Request, id ulid.ULID) {
+	if !h.allowed(w, r, ActionDelete, http.MethodDelete) {
+		return
+	}
+
+	n, err := h.c.DeleteJobs(r.Context(), gue.JobFilter{IDs: []ulid.ULID{id}})
+	h.writeAffected(w, n, err)
+}
+
+func (h *Handler) handlePaused(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		if !h.allowed(w, r, ActionView, http.MethodGet, http.MethodPost, http.MethodDelete) {
+			return
+		}
+
+		paused, err := h.c.ListPaused(r.Context())
+		if err != nil {
+			h.writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		views := make([]pauseView, len(paused))
+		for i := range paused {
+			views[i] = newPauseView(paused[i])
+		}
+
+		h.writeJSON(w, http.StatusOK, views)
+		return
+	}
+
+	method, otherMethod := http.MethodPost, http.MethodDelete
+	if r.Method == http.MethodDelete {
+		method, otherMethod = http.MethodDelete, http.MethodPost
+	}
+	if !h.allowed(w, r, ActionPause, method, http.MethodGet, otherMethod) {
+		return
+	}
+
+	var req pauseView
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, errors.New(`request body must be JSON with "kind" and "name" set`))
+		return
+	}
+
+	var err error
+	switch {
+	case gue.PauseKind(req.Kind) == gue.PauseKindQueue && method == http.MethodPost:
+		err = h.c.PauseQueue(r.Context(), req.Name)
+	case gue.PauseKind(req.Kind) == gue.PauseKindQueue:
+		err = h.c.ResumeQueue(r.Context(), req.Name)
+	case gue.PauseKind(req.Kind) == gue.PauseKindJobType && method == http.MethodPost:
+		err = h.c.PauseJobType(r.Context(), req.Name)
+	case gue.PauseKind(req.Kind) == gue.PauseKindJobType:
+		err = h.c.ResumeJobType(r.Context(), req.Name)
+	default:
+		h.writeError(w, http.StatusBadRequest, errors.New(`"kind" must be either "queue" or "type"`))
+		return
+	}
+
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, req)
+}
+
+func (h *Handler) handleWorkers(w http.ResponseWriter, r *http.Request) {
+	if !h.allowed(w, r, ActionView, http.MethodGet) {
+		return
+	}
+
+	workers, err := h.c.ListWorkers(r.Context())
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	now := time.Now()
+	views := make([]workerView, len(workers))
+	for i := range workers {
+		views[i] = newWorkerView(workers[i], now)
+	}
+
+	h.writeJSON(w, http.StatusOK, views)
+}
+
+// allowed checks request method and authorization, writes error response if the request is not allowed.
+// Method is the one the action requires, routeMethods are the other methods the route supports, all of them
+// are listed in the Allow header of the 405 Method Not Allowed response.
+func (h *Handler) allowed(
+	w http.ResponseWriter, r *http.Request, action Action, method string, routeMethods ...string,
+) bool {
+	if r.Method != method {
+		w.Header().Set("Allow", strings.Join(append([]string{method}, routeMethods...), ", "))
+		h.writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return false
+	}
+
+	if method != http.MethodGet {
+		// plain HTML form can not set JSON content type, so the mutating request can not be forged by another site
+		mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil || mediaType != "application/json" {
+			h.writeError(
+				w, http.StatusUnsupportedMediaType, errors.New(`request must have "Content-Type: application/json" header`),
+			)
+			return false
+		}
+	}
+
+	if err := h.authorize(r, action); err != nil {
+		h.writeError(w, http.StatusForbidden, err)
+		return false
+	}
+
+	return true
+}
+
+// writeAffected writes the result of the job management action. Jobs that are being worked at the moment
+// are skipped by the management actions, so zero affected jobs results in 409 Conflict.
+func (h *Handler) writeAffected(w http.ResponseWriter, n int64, err error) {
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if n == 0 {
+		h.writeError(w, http.StatusConflict, errors.New("job not found or is being worked at the moment"))
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, map[string]int64{"affected": n})
+}
+
+// writeError writes the error response. Server errors are logged and replaced with the generic message,
+// as they may expose DB details, e.g. SQL and table names.
+func (h *Handler) writeError(w http.ResponseWriter, status int, err error) {
+	if status >= http.StatusInternalServerError {
+		h.logger.Error("Dashboard request failed", adapter.Err(err))
+		err = errors.New(http.StatusText(status))
+	}
+
+	h.writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func (h *Handler) writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		h.logger.Error("Could not write dashboard response", adapter.Err(err))
+	}
+}
+
+func parseJobFilter(r *http.Request) (gue.JobFilter, error) {
+	q := r.URL.Query()
+	filter := gue.JobFilter{
+		Types:       q["type"],
+		OnlyFailing: q.Get("failing") == "true",
+		OnlyReady:   q.Get("ready") == "true",
+		Limit:       defaultJobsLimit,
+	}
+
+	// empty queue name is the valid default queue name, so check for the param presence, not for its value
+	if queues, ok := q["queue"]; ok {
+		filter.Queues = queues
+	}
+
+	if id := q.Get("id"); id != "" {
+		parsed, err := ulid.ParseStrict(id)
+		if err != nil {
+			return filter, errors.New("invalid job ID")
+		}
+		filter.IDs = []ulid.ULID{parsed}
+	}
+
+	if after := q.Get("after"); after != "" {
+		parsed, err := ulid.ParseStrict(after)
+		if err != nil {
+			return filter, errors.New("invalid after job ID")
+		}
+		filter.AfterID = parsed
+	}
+
+	if limit := q.Get("limit"); limit != "" {
+		parsed, err := strconv.Atoi(limit)
+		if err != nil || parsed < 1 || parsed > maxJobsLimit {
+			return filter, errors.New("limit must be a number between 1 and " + strconv.Itoa(maxJobsLimit))
+		}
+		filter.Limit = parsed
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,287 @@
+package dashboard
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vgarvardt/gue/v5"
+	"github.com/vgarvardt/gue/v5/adapter"
+	adapterTesting "github.com/vgarvardt/gue/v5/adapter/testing"
+)
+
+func TestHandler_UI(t *testing.T) {
+	gc, err := gue.NewClient(nil)
+	require.NoError(t, err)
+
+	h := New(gc)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "gue dashboard")
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/unknown", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+// newRequest creates the test request with the JSON content type set for the mutating requests.
+func newRequest(method, path, body string) *http.Request {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	if method != http.MethodGet {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	return r
+}
+
+func allowAll(*http.Request, Action) error {
+	return nil
+}
+
+func TestHandler_DefaultAuthorize(t *testing.T) {
+	gc, err := gue.NewClient(nil)
+	require.NoError(t, err)
+
+	h := New(gc)
+
+	for _, tc := range []struct {
+		method string
+		path   string
+	}{
+		{http.MethodPost, "/api/jobs/01H0000000000000000000000A/retry"},
+		{http.MethodPost, "/api/jobs/01H0000000000000000000000A/reschedule"},
+		{http.MethodDelete, "/api/jobs/01H0000000000000000000000A"},
+		{http.MethodPost, "/api/paused"},
+		{http.MethodDelete, "/api/paused"},
+	} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, newRequest(tc.method, tc.path, ""))
+		assert.Equal(t, http.StatusForbidden, w.Code, tc.path)
+		assert.Contains(t, w.Body.String(), "is not allowed")
+	}
+}
+
+func TestHandler_ContentType(t *testing.T) {
+	gc, err := gue.NewClient(nil)
+	require.NoError(t, err)
+
+	h := New(gc, WithAuthorize(allowAll))
+
+	for _, contentType := range []string{"", "application/x-www-form-urlencoded", "multipart/form-data", "text/plain"} {
+		r := httptest.NewRequest(http.MethodPost, "/api/jobs/01H0000000000000000000000A/retry", nil)
+		if contentType != "" {
+			r.Header.Set("Content-Type", contentType)
+		}
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		assert.Equal(t, http.StatusUnsupportedMediaType, w.Code, contentType)
+	}
+
+	r := newRequest(http.MethodPost, "/api/paused", "")
+	r.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestHandler_Authorize(t *testing.T) {
+	gc, err := gue.NewClient(nil)
+	require.NoError(t, err)
+
+	var actions []Action
+	h := New(gc, WithAuthorize(func(r *http.Request, action Action) error {
+		actions = append(actions, action)
+		return errors.New("not allowed")
+	}))
+
+	for _, tc := range []struct {
+		method string
+		path   string
+	}{
+		{http.MethodGet, "/"},
+		{http.MethodGet, "/api/queues"},
+		{http.MethodGet, "/api/jobs"},
+		{http.MethodGet, "/api/jobs/01H0000000000000000000000A"},
+		{http.MethodPost, "/api/jobs/01H0000000000000000000000A/retry"},
+		{http.MethodPost, "/api/jobs/01H0000000000000000000000A/reschedule"},
+		{http.MethodDelete, "/api/jobs/01H0000000000000000000000A"},
+		{http.MethodGet, "/api/paused"},
+		{http.MethodPost, "/api/paused"},
+		{http.MethodDelete, "/api/paused"},
+		{http.MethodGet, "/api/workers"},
+	} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, newRequest(tc.method, tc.path, ""))
+		assert.Equal(t, http.StatusForbidden, w.Code, tc.path)
+		assert.Contains(t, w.Body.String(), "not allowed")
+	}
+
+	assert.Equal(
+		t,
+		[]Action{
+			ActionView, ActionView, ActionView, ActionView, ActionRetry, ActionReschedule, ActionDelete,
+			ActionView, ActionPause, ActionPause, ActionView,
+		},
+		actions,
+	)
+}
+
+func TestHandler_BadRequests(t *testing.T) {
+	gc, err := gue.NewClient(nil)
+	require.NoError(t, err)
+
+	h := New(gc, WithAuthorize(allowAll))
+
+	for _, tc := range []struct {
+		method string
+		path   string
+		status int
+	}{
+		{http.MethodPost, "/api/queues", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/api/jobs/01H0000000000000000000000A/retry", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/api/jobs/not-an-id", http.StatusNotFound},
+		{http.MethodGet, "/api/jobs/01H0000000000000000000000A/unknown", http.StatusNotFound},
+		{http.MethodGet, "/api/jobs?limit=0", http.StatusBadRequest},
+		{http.MethodGet, "/api/jobs?after=foo", http.StatusBadRequest},
+		{http.MethodPost, "/api/jobs/01H0000000000000000000000A/reschedule", http.StatusBadRequest},
+		{http.MethodPut, "/api/paused", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/api/paused", http.StatusBadRequest},
+	} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, newRequest(tc.method, tc.path, ""))
+		assert.Equal(t, tc.status, w.Code, tc.path)
+	}
+}
+
+func TestHandler_MethodNotAllowed(t *testing.T) {
+	gc, err := gue.NewClient(nil)
+	require.NoError(t, err)
+
+	h := New(gc, WithAuthorize(allowAll))
+
+	for _, tc := range []struct {
+		method string
+		path   string
+		allow  string
+	}{
+		{http.MethodPost, "/api/queues", "GET"},
+		{http.MethodPost, "/api/jobs/01H0000000000000000000000A", "GET, DELETE"},
+		{http.MethodGet, "/api/jobs/01H0000000000000000000000A/retry", "POST"},
+		{http.MethodPut, "/api/paused", "POST, GET, DELETE"},
+	} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, newRequest(tc.method, tc.path, ""))
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code, tc.path)
+		assert.Equal(t, tc.allow, w.Header().Get("Allow"), tc.path)
+	}
+}
+
+func TestHandler_ServerError(t *testing.T) {
+	pool := new(adapterTesting.ConnPool)
+	pool.Queryable.On("Query", mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, errors.New(`relation "gue_jobs" does not exist`))
+
+	gc, err := gue.NewClient(pool)
+	require.NoError(t, err)
+
+	h := New(gc)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, newRequest(http.MethodGet, "/api/jobs", ""))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.JSONEq(t, `{"error": "Internal Server Error"}`, w.Body.String())
+}
+
+func TestHandler_API(t *testing.T) {
+	for name, openFunc := range adapterTesting.AllAdaptersOpenTestPool {
+		t.Run(name, func(t *testing.T) {
+			testHandlerAPI(t, openFunc(t))
+		})
+	}
+}
+
+func testHandlerAPI(t *testing.T, connPool adapter.ConnPool) {
+	ctx := context.Background()
+
+	gc, err := gue.NewClient(connPool)
+	require.NoError(t, err)
+
+	queue := "dashboard-" + gue.RandomStringID()
+	j := &gue.Job{Queue: queue, Type: "MyJob", Args: []byte(`{"foo":"bar"}`)}
+	err = gc.Enqueue(ctx, j)
+	require.NoError(t, err)
+
+	h := New(gc, WithAuthorize(allowAll))
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, newRequest(method, path, body))
+		return w
+	}
+
+	w := do(http.MethodGet, "/api/queues", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var queues []queueView
+	err = json.Unmarshal(w.Body.Bytes(), &queues)
+	require.NoError(t, err)
+	assert.NotEmpty(t, queues)
+
+	w = do(http.MethodGet, "/api/jobs?queue="+queue, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var jobs []jobView
+	err = json.Unmarshal(w.Body.Bytes(), &jobs)
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, j.ID.String(), jobs[0].ID)
+	assert.JSONEq(t, `{"foo":"bar"}`, string(jobs[0].Args))
+
+	w = do(http.MethodGet, "/api/jobs/"+j.ID.String(), "")
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = do(http.MethodPost, "/api/jobs/"+j.ID.String()+"/reschedule", `{"run_at": "2050-01-01T00:00:00Z"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = do(http.MethodPost, "/api/jobs/"+j.ID.String()+"/retry", "")
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = do(http.MethodDelete, "/api/jobs/"+j.ID.String(), "")
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = do(http.MethodGet, "/api/jobs/"+j.ID.String(), "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = do(http.MethodDelete, "/api/jobs/"+j.ID.String(), "")
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = do(http.MethodPost, "/api/paused", `{"kind": "queue", "name": "`+queue+`"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = do(http.MethodGet, "/api/paused", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var paused []pauseView
+	err = json.Unmarshal(w.Body.Bytes(), &paused)
+	require.NoError(t, err)
+	require.Len(t, paused, 1)
+	assert.Equal(t, string(gue.PauseKindQueue), paused[0].Kind)
+	assert.Equal(t, queue, paused[0].Name)
+
+	w = do(http.MethodDelete, "/api/paused", `{"kind": "queue", "name": "`+queue+`"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = do(http.MethodPost, "/api/paused", `{"kind": "unknown", "name": "`+queue+`"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = do(http.MethodGet, "/api/workers", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -0,0 +1,23 @@
+package dashboard
+
+import (
+	"github.com/vgarvardt/gue/v5/adapter"
+)
+
+// Option defines a type that allows to set dashboard handler properties during the build-time.
+type Option func(*Handler)
+
+// WithAuthorize sets authorization callback that is called for every request with the action it performs.
+// It replaces the default one that denies all the mutating actions.
+func WithAuthorize(authorize AuthorizeFunc) Option {
+	return func(h *Handler) {
+		h.authorize = authorize
+	}
+}
+
+// WithLogger sets Logger implementation to dashboard handler.
+func WithLogger(logger adapter.Logger) Option {
+	return func(h *Handler) {
+		h.logger = logger
+	}
+}
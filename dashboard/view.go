@@ -0,0 +1,117 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/vgarvardt/gue/v5"
+)
+
+// jobView is the JSON representation of the job.
+type jobView struct {
+	ID         string          `json:"id"`
+	Queue      string          `json:"queue"`
+	Type       string          `json:"type"`
+	Priority   int16           `json:"priority"`
+	RunAt      time.Time       `json:"run_at"`
+	ErrorCount int32           `json:"error_count"`
+	LastError  *string         `json:"last_error,omitempty"`
+	Args       json.RawMessage `json:"args,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+}
+
+func newJobView(j *gue.Job) jobView {
+	v := jobView{
+		ID:         j.ID.String(),
+		Queue:      j.Queue,
+		Type:       j.Type,
+		Priority:   int16(j.Priority),
+		RunAt:      j.RunAt,
+		ErrorCount: j.ErrorCount,
+		CreatedAt:  j.CreatedAt,
+		UpdatedAt:  j.UpdatedAt,
+	}
+
+	if j.LastError.Valid {
+		v.LastError = &j.LastError.String
+	}
+
+	if len(j.Args) > 0 {
+		if json.Valid(j.Args) {
+			v.Args = j.Args
+		} else {
+			// args are not necessarily JSON, output them as a string then
+			v.Args, _ = json.Marshal(string(j.Args))
+		}
+	}
+
+	return v
+}
+
+// queueView is the JSON representation of the queue stats.
+type queueView struct {
+	Queue          string     `json:"queue"`
+	Ready          int64      `json:"ready"`
+	Scheduled      int64      `json:"scheduled"`
+	Failing        int64      `json:"failing"`
+	OldestReadyAge *float64   `json:"oldest_ready_age_seconds,omitempty"`
+	OldestReadyAt  *time.Time `json:"oldest_ready_run_at,omitempty"`
+}
+
+func newQueueView(s gue.QueueStats, now time.Time) queueView {
+	v := queueView{Queue: s.Queue, Ready: s.Ready, Scheduled: s.Scheduled, Failing: s.Failing}
+	if s.OldestReadyRunAt.Valid {
+		age := now.Sub(s.OldestReadyRunAt.Time).Seconds()
+		v.OldestReadyAge = &age
+		v.OldestReadyAt = &s.OldestReadyRunAt.Time
+	}
+
+	return v
+}
+
+// pauseView is the JSON representation of the paused queue or job type.
+type pauseView struct {
+	Kind     string    `json:"kind"`
+	Name     string    `json:"name"`
+	PausedAt time.Time `json:"paused_at,omitempty"`
+}
+
+func newPauseView(p gue.Pause) pauseView {
+	return pauseView{Kind: string(p.Kind), Name: p.Name, PausedAt: p.PausedAt}
+}
+
+// workerView is the JSON representation of the registered worker.
+type workerView struct {
+	ID          string     `json:"id"`
+	Host        string     `json:"host"`
+	PID         int        `json:"pid"`
+	Queue       string     `json:"queue"`
+	StartedAt   time.Time  `json:"started_at"`
+	HeartbeatAt time.Time  `json:"heartbeat_at"`
+	JobID       string     `json:"job_id,omitempty"`
+	JobType     string     `json:"job_type,omitempty"`
+	JobLockedAt *time.Time `json:"job_locked_at,omitempty"`
+	JobDuration *float64   `json:"job_duration_seconds,omitempty"`
+}
+
+func newWorkerView(wi gue.WorkerInfo, now time.Time) workerView {
+	v := workerView{
+		ID:          wi.ID,
+		Host:        wi.Host,
+		PID:         wi.PID,
+		Queue:       wi.Queue,
+		StartedAt:   wi.StartedAt,
+		HeartbeatAt: wi.HeartbeatAt,
+	}
+
+	if wi.CurrentJob != nil {
+		duration := now.Sub(wi.CurrentJob.LockedAt).Seconds()
+		v.JobID = wi.CurrentJob.ID.String()
+		v.JobType = wi.CurrentJob.Type
+		v.JobLockedAt = &wi.CurrentJob.LockedAt
+		v.JobDuration = &duration
+	}
+
+	return v
+}
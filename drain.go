@@ -0,0 +1,144 @@
+package gue
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/vgarvardt/gue/v5/adapter"
+)
+
+// InterruptedJobsError is returned by Worker.Run and WorkerPool.Run when the drain timeout expired before
+// the jobs being worked were finished, see WithWorkerDrainTimeout. Interrupted jobs are released unchanged,
+// so they are worked again by the other workers.
+type InterruptedJobsError struct {
+	// Jobs are the interrupted jobs.
+	Jobs []WorkerJob
+}
+
+// Error implements error.Error()
+func (e *InterruptedJobsError) Error() string {
+	jobs := make([]string, len(e.Jobs))
+	for i := range e.Jobs {
+		jobs[i] = fmt.Sprintf("%s[type=%s]", e.Jobs[i].ID.String(), e.Jobs[i].Type)
+	}
+
+	return fmt.Sprintf("drain timeout expired, %d jobs interrupted: %s", len(e.Jobs), strings.Join(jobs, ", "))
+}
+
+// detachedContext keeps the parent context values, but is never cancelled. It is used to release the jobs
+// interrupted by the drain timeout, as their handler context is cancelled already at this point.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (c detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (c detachedContext) Done() <-chan struct{}       { return nil }
+func (c detachedContext) Err() error                  { return nil }
+func (c detachedContext) Value(key any) any           { return c.parent.Value(key) }
+
+// startDrain starts the drain timer once the worker context is done, the returned channel is closed
+// when the drain timeout expires. Timer is stopped when the stop channel is closed.
+func (w *Worker) startDrain(ctx context.Context, stop <-chan struct{}) <-chan struct{} {
+	w.drainExpired.Store(false)
+	w.interruptedMu.Lock()
+	w.interrupted = nil
+	w.interruptedMu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-stop:
+			return
+		}
+
+		w.logger.Info("Worker is draining", adapter.F("drain-timeout", w.drainTimeout.String()))
+
+		timer := time.NewTimer(w.drainTimeout)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+			w.drainExpired.Store(true)
+			close(drained)
+		case <-stop:
+		}
+	}()
+
+	return drained
+}
+
+// handlerContext returns the context for the job handlers. In graceful shutdown mode it is not cancelled together
+// with the worker context, and with the drain timeout it is cancelled when the drained channel is closed.
+func (w *Worker) handlerContext(ctx context.Context, drained <-chan struct{}) (context.Context, context.CancelFunc) {
+	if !w.graceful {
+		return ctx, func() {}
+	}
+
+	handlerCtx := context.Background()
+	if w.gracefulCtx != nil {
+		handlerCtx = w.gracefulCtx()
+	}
+	if drained == nil {
+		return handlerCtx, func() {}
+	}
+
+	handlerCtx, cancel := context.WithCancel(handlerCtx)
+	go func() {
+		select {
+		case <-drained:
+			cancel()
+		case <-handlerCtx.Done():
+		}
+	}()
+
+	return handlerCtx, cancel
+}
+
+// interrupt releases the job interrupted by the drain timeout and records it to be reported by Worker.Run.
+// It returns false if the drain timeout did not expire and the job should be finished as usual.
+func (w *Worker) interrupt(ctx context.Context, ll adapter.Logger, j *Job, lockedAt time.Time) bool {
+	if w.drainTimeout <= 0 || !w.drainExpired.Load() {
+		return false
+	}
+
+	ll.Error("Job interrupted by the drain timeout, releasing it")
+	if err := j.release(detachedContext{parent: ctx}); err != nil {
+		ll.Error("Failed to release interrupted job", adapter.Err(err))
+	}
+
+	w.interruptedMu.Lock()
+	w.interrupted = append(w.interrupted, WorkerJob{ID: j.ID, Type: j.Type, LockedAt: lockedAt})
+	w.interruptedMu.Unlock()
+
+	for _, hook := range w.hooksJobTimedOut {
+		hook(ctx, j, ErrJobInterrupted)
+	}
+
+	return true
+}
+
+// interruptFinished is interrupt for the job which handler already returned. Job is interrupted only when
+// the handler failed after its context was cancelled by the drain timeout, successful result is applied as usual,
+// as well as the error of the handler that was not cancelled.
+func (w *Worker) interruptFinished(ctx context.Context, ll adapter.Logger, j *Job, lockedAt time.Time, err error) bool {
+	if err == nil || ctx.Err() == nil {
+		return false
+	}
+
+	return w.interrupt(ctx, ll, j, lockedAt)
+}
+
+// interruptedErr returns InterruptedJobsError if there were jobs interrupted by the drain timeout.
+func (w *Worker) interruptedErr() error {
+	w.interruptedMu.Lock()
+	defer w.interruptedMu.Unlock()
+
+	if len(w.interrupted) == 0 {
+		return nil
+	}
+
+	return &InterruptedJobsError{Jobs: append([]WorkerJob(nil), w.interrupted...)}
+}
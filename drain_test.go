@@ -0,0 +1,198 @@
+package gue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	adapterTesting "github.com/vgarvardt/gue/v5/adapter/testing"
+)
+
+func TestWorker_DrainTimeout(t *testing.T) {
+	tx := new(adapterTesting.Tx)
+	tx.On("Rollback", mock.Anything).Return(nil).Once()
+
+	j := &Job{ID: ulid.MustParse("01H5Q7BZ7CDGA3KQWQ7PGXF8DK"), Type: "MyJob", tx: tx}
+
+	started := make(chan struct{})
+	wm := WorkMap{
+		"MyJob": func(ctx context.Context, j *Job) error {
+			close(started)
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}
+
+	w, err := NewWorker(nil, wm, WithWorkerDrainTimeout(50*time.Millisecond), WithWorkerPollInterval(time.Hour))
+	require.NoError(t, err)
+
+	polled := false
+	w.pollFunc = func(context.Context, string) (*Job, error) {
+		if polled {
+			return nil, errors.New("worker must stop polling")
+		}
+		polled = true
+		return j, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- w.Run(ctx)
+	}()
+
+	<-started
+	cancel()
+
+	err = <-done
+	var interruptedErr *InterruptedJobsError
+	require.ErrorAs(t, err, &interruptedErr)
+	require.Len(t, interruptedErr.Jobs, 1)
+	assert.Equal(t, j.ID, interruptedErr.Jobs[0].ID)
+	assert.Equal(t, "MyJob", interruptedErr.Jobs[0].Type)
+	assert.Contains(t, err.Error(), j.ID.String())
+
+	// job was released without being deleted or errored
+	assert.Nil(t, j.Tx())
+	tx.AssertExpectations(t)
+	tx.Queryable.AssertNotCalled(t, "Exec", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestWorker_DrainTimeoutFinished(t *testing.T) {
+	tx := new(adapterTesting.Tx)
+	tx.Queryable.On("Exec", mock.Anything, mock.Anything, mock.Anything).Return(nil, nil).Once()
+	tx.On("Commit", mock.Anything).Return(nil).Once()
+
+	j := &Job{ID: ulid.MustParse("01H5Q7BZ7CDGA3KQWQ7PGXF8DK"), Type: "MyJob", tx: tx}
+
+	started := make(chan struct{})
+	wm := WorkMap{
+		"MyJob": func(ctx context.Context, j *Job) error {
+			close(started)
+			time.Sleep(50 * time.Millisecond)
+			return ctx.Err()
+		},
+	}
+
+	w, err := NewWorker(nil, wm, WithWorkerDrainTimeout(time.Second), WithWorkerPollInterval(time.Hour))
+	require.NoError(t, err)
+
+	w.pollFunc = func(context.Context, string) (*Job, error) {
+		return j, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- w.Run(ctx)
+	}()
+
+	<-started
+	cancel()
+
+	// handler finished before the drain timeout expired - job is deleted as usual
+	require.NoError(t, <-done)
+	tx.AssertExpectations(t)
+	tx.Queryable.AssertExpectations(t)
+}
+
+func TestWorker_DrainTimeoutSucceeded(t *testing.T) {
+	tx := new(adapterTesting.Tx)
+	tx.Queryable.On("Exec", mock.Anything, mock.Anything, mock.Anything).Return(nil, nil).Once()
+	tx.On("Commit", mock.Anything).Return(nil).Once()
+
+	j := &Job{ID: ulid.MustParse("01H5Q7BZ7CDGA3KQWQ7PGXF8DK"), Type: "MyJob", tx: tx}
+
+	started := make(chan struct{})
+	wm := WorkMap{
+		"MyJob": func(ctx context.Context, j *Job) error {
+			close(started)
+			// handler ignores the context cancellation and completes the job
+			<-ctx.Done()
+			return nil
+		},
+	}
+
+	w, err := NewWorker(nil, wm, WithWorkerDrainTimeout(50*time.Millisecond), WithWorkerPollInterval(time.Hour))
+	require.NoError(t, err)
+
+	polled := false
+	w.pollFunc = func(context.Context, string) (*Job, error) {
+		if polled {
+			return nil, errors.New("worker must stop polling")
+		}
+		polled = true
+		return j, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- w.Run(ctx)
+	}()
+
+	<-started
+	cancel()
+
+	// successful result is committed even after the drain timeout expired
+	require.NoError(t, <-done)
+	tx.AssertExpectations(t)
+	tx.Queryable.AssertExpectations(t)
+	tx.AssertNotCalled(t, "Rollback", mock.Anything)
+}
+
+func TestWorkerPool_DrainTimeout(t *testing.T) {
+	started := make(chan struct{}, 2)
+	wm := WorkMap{
+		"MyJob": func(ctx context.Context, j *Job) error {
+			started <- struct{}{}
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}
+
+	wp, err := NewWorkerPool(nil, wm, 2, WithPoolDrainTimeout(50*time.Millisecond), WithPoolPollInterval(time.Hour))
+	require.NoError(t, err)
+
+	ids := []ulid.ULID{ulid.MustParse("01H5Q7BZ7CDGA3KQWQ7PGXF8DK"), ulid.MustParse("01H5Q7BZ7CDGA3KQWQ7PGXF8DM")}
+	for i, w := range wp.workers {
+		tx := new(adapterTesting.Tx)
+		tx.On("Rollback", mock.Anything).Return(nil).Once()
+
+		jobs := []*Job{{ID: ids[i], Type: "MyJob", tx: tx}}
+		w.pollFunc = func(context.Context, string) (*Job, error) {
+			if len(jobs) == 0 {
+				return nil, nil
+			}
+			j := jobs[0]
+			jobs = jobs[1:]
+			return j, nil
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- wp.Run(ctx)
+	}()
+
+	<-started
+	<-started
+	cancel()
+
+	err = <-done
+	var interruptedErr *InterruptedJobsError
+	require.ErrorAs(t, err, &interruptedErr)
+
+	interrupted := make([]ulid.ULID, 0, len(interruptedErr.Jobs))
+	for _, j := range interruptedErr.Jobs {
+		interrupted = append(interrupted, j.ID)
+	}
+	assert.ElementsMatch(t, ids, interrupted)
+}
@@ -1,11 +1,13 @@
 package gue
 
 import (
+	"errors"
 	"fmt"
 	"time"
 )
 
 // ErrJobReschedule interface implementation allows errors to reschedule jobs in the individual basis.
+// It is recognised with errors.As, so the errors may be wrapped, e.g. with fmt.Errorf and %w verb.
 type ErrJobReschedule interface {
 	rescheduleJobAt() time.Time
 }
@@ -65,3 +67,58 @@ func (e errJobDiscard) Error() string {
 func (e errJobDiscard) rescheduleJobAt() time.Time {
 	return time.Time{}
 }
+
+// ErrorAction is the action applied to the failed Job, see ErrorDecision.
+type ErrorAction string
+
+const (
+	// ErrorActionRetry reschedules the Job using the Backoff, the Job is discarded if the Backoff gives up.
+	ErrorActionRetry ErrorAction = "retry"
+	// ErrorActionReschedule reschedules the Job to the ErrorDecision.RunAt time regardless of the Backoff.
+	ErrorActionReschedule ErrorAction = "reschedule"
+	// ErrorActionDiscard discards the Job unconditionally.
+	ErrorActionDiscard ErrorAction = "discard"
+)
+
+// ErrorDecision is the decision on what to do with the failed Job.
+type ErrorDecision struct {
+	// Action is the action applied to the Job, empty action means that there is no decision.
+	Action ErrorAction
+	// RunAt is the time the Job is rescheduled to with ErrorActionReschedule, zero time reschedules the Job
+	// to run immediately. It is set to the next run time calculated with the Backoff for ErrorActionRetry
+	// when the decision is reported to the hooks.
+	RunAt time.Time
+}
+
+// ErrorClassifier maps the error the Job failed with to the ErrorDecision, e.g. to discard the Job failed with
+// the permanent error like HTTP 4xx response or the constraint violation instead of retrying it. Classifier
+// should return zero ErrorDecision for the errors it does not recognise, they are retried using the Backoff.
+//
+// Errors returned with ErrRescheduleJobIn, ErrRescheduleJobAt and ErrDiscardJob, wrapped or not, take precedence
+// and are not passed to the classifier.
+type ErrorClassifier func(j *Job, err error) ErrorDecision
+
+// ClassifyErrors combines several classifiers into one, the decision of the first classifier that made one is used.
+func ClassifyErrors(classifiers ...ErrorClassifier) ErrorClassifier {
+	return func(j *Job, err error) ErrorDecision {
+		for _, classifier := range classifiers {
+			if d := classifier(j, err); d.Action != "" {
+				return d
+			}
+		}
+
+		return ErrorDecision{}
+	}
+}
+
+// ClassifyErrorIs returns the classifier that makes the decision for the errors matching the target
+// with errors.Is, e.g. to discard the jobs failed with context.DeadlineExceeded.
+func ClassifyErrorIs(target error, decision ErrorDecision) ErrorClassifier {
+	return func(_ *Job, err error) ErrorDecision {
+		if errors.Is(err, target) {
+			return decision
+		}
+
+		return ErrorDecision{}
+	}
+}
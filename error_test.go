@@ -2,6 +2,7 @@ package gue
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"testing"
 	"time"
@@ -138,3 +139,92 @@ func testErrDiscardJob(t *testing.T, connPool adapter.ConnPool) {
 	require.Error(t, err)
 	assert.Nil(t, jLocked2)
 }
+
+func TestJob_errorDecision(t *testing.T) {
+	now := time.Now().UTC()
+	rescheduleAt := now.Add(time.Hour)
+	errPermanent := errors.New("permanent error")
+
+	classifier := ClassifyErrors(
+		ClassifyErrorIs(errPermanent, ErrorDecision{Action: ErrorActionDiscard}),
+		ClassifyErrorIs(context.DeadlineExceeded, ErrorDecision{Action: ErrorActionReschedule, RunAt: rescheduleAt}),
+		ClassifyErrorIs(context.Canceled, ErrorDecision{Action: ErrorActionReschedule}),
+	)
+
+	for name, tc := range map[string]struct {
+		err           error
+		backoff       Backoff
+		decision      ErrorDecision
+		discardReason DiscardReason
+	}{
+		"retried": {
+			err:      errors.New("the error msg"),
+			backoff:  NewConstantBackoff(time.Minute),
+			decision: ErrorDecision{Action: ErrorActionRetry, RunAt: now.Add(time.Minute)},
+		},
+		"discarded by backoff": {
+			err:           errors.New("the error msg"),
+			backoff:       BackoffNever,
+			decision:      ErrorDecision{Action: ErrorActionDiscard},
+			discardReason: DiscardReasonBackoff,
+		},
+		"wrapped discard error": {
+			err:           fmt.Errorf("could not call the service: %w", ErrDiscardJob("the reason")),
+			backoff:       NewConstantBackoff(time.Minute),
+			decision:      ErrorDecision{Action: ErrorActionDiscard},
+			discardReason: DiscardReasonHandler,
+		},
+		"wrapped reschedule error": {
+			err:      fmt.Errorf("could not call the service: %w", ErrRescheduleJobAt(rescheduleAt, "the reason")),
+			backoff:  BackoffNever,
+			decision: ErrorDecision{Action: ErrorActionReschedule, RunAt: rescheduleAt},
+		},
+		"discarded by classifier": {
+			err:           fmt.Errorf("could not call the service: %w", errPermanent),
+			backoff:       NewConstantBackoff(time.Minute),
+			decision:      ErrorDecision{Action: ErrorActionDiscard},
+			discardReason: DiscardReasonClassifier,
+		},
+		"rescheduled by classifier": {
+			err:      fmt.Errorf("could not call the service: %w", context.DeadlineExceeded),
+			backoff:  BackoffNever,
+			decision: ErrorDecision{Action: ErrorActionReschedule, RunAt: rescheduleAt},
+		},
+		"rescheduled by classifier immediately": {
+			err:      context.Canceled,
+			backoff:  BackoffNever,
+			decision: ErrorDecision{Action: ErrorActionReschedule, RunAt: now},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			j := &Job{backoff: AsJobBackoff(tc.backoff), errorClassifier: classifier}
+
+			decision, discardReason := j.errorDecision(tc.err, now, 1)
+			assert.Equal(t, tc.decision, decision)
+			assert.Equal(t, tc.discardReason, discardReason)
+		})
+	}
+}
+
+func TestClassifyErrors(t *testing.T) {
+	classifier := ClassifyErrors()
+	assert.Equal(t, ErrorDecision{}, classifier(nil, errors.New("the error msg")))
+
+	calls := 0
+	classifier = ClassifyErrors(
+		func(*Job, error) ErrorDecision {
+			calls++
+			return ErrorDecision{}
+		},
+		func(*Job, error) ErrorDecision {
+			calls++
+			return ErrorDecision{Action: ErrorActionDiscard}
+		},
+		func(*Job, error) ErrorDecision {
+			calls++
+			return ErrorDecision{Action: ErrorActionRetry}
+		},
+	)
+	assert.Equal(t, ErrorDecision{Action: ErrorActionDiscard}, classifier(nil, errors.New("the error msg")))
+	assert.Equal(t, 2, calls)
+}
@@ -0,0 +1,55 @@
+package gue
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/vgarvardt/gue/v5/adapter"
+)
+
+// expired returns true if the job expired at the given time, see Job.ExpiresAt.
+func (j *Job) expired(now time.Time) bool {
+	return j.ExpiresAt.Valid && !now.Before(j.ExpiresAt.Time)
+}
+
+// expire discards the job that expired before it was worked without calling its handler.
+// It returns false if the job did not expire and should be worked as usual.
+func (w *Worker) expire(ctx context.Context, ll adapter.Logger, j *Job) bool {
+	if !j.expired(time.Now()) {
+		return false
+	}
+
+	ll.Info("Job expired, discarding it", adapter.F("expires-at", j.ExpiresAt.Time.String()))
+	w.mExpired.Add(ctx, 1, metric.WithAttributes(attrQueue.String(j.Queue), attrJobType.String(j.Type)))
+
+	if err := j.delete(ctx, false); err != nil {
+		ll.Error("Got an error on discarding expired job", adapter.Err(err))
+		return true
+	}
+
+	for _, hook := range w.hooksJobExpired {
+		hook(ctx, j, nil)
+	}
+
+	return true
+}
+
+// expireJobs discards the expired batch jobs and returns the rest of them to be worked.
+func (w *Worker) expireJobs(ctx context.Context, ll adapter.Logger, jobs []*Job) []*Job {
+	active := make([]*Job, 0, len(jobs))
+	for _, j := range jobs {
+		jl := ll.With(adapter.F("job-id", j.ID.String()))
+		if !w.expire(ctx, jl, j) {
+			active = append(active, j)
+			continue
+		}
+
+		if err := j.Done(ctx); err != nil {
+			jl.Error("Failed to mark job as done", adapter.Err(err))
+		}
+	}
+
+	return active
+}
@@ -0,0 +1,134 @@
+package gue
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vgarvardt/gue/v5/adapter"
+	adapterTesting "github.com/vgarvardt/gue/v5/adapter/testing"
+)
+
+func TestWorker_WorkOneExpired(t *testing.T) {
+	ctx := context.Background()
+
+	handlerCalled := false
+	wm := WorkMap{
+		"MyJob": func(ctx context.Context, j *Job) error {
+			handlerCalled = true
+			return nil
+		},
+	}
+
+	expiredHook := new(mockHook)
+	w, err := NewWorker(nil, wm, WithWorkerHooksJobExpired(expiredHook.handler))
+	require.NoError(t, err)
+
+	j, tx := newHooksTestJob(BackoffNever)
+	j.Type = "MyJob"
+	j.ExpiresAt = sql.NullTime{Time: time.Now().Add(-time.Second), Valid: true}
+	w.pollFunc = func(context.Context, string) (*Job, error) {
+		return j, nil
+	}
+
+	assert.True(t, w.WorkOne(ctx))
+	assert.False(t, handlerCalled)
+	require.Equal(t, 1, expiredHook.called)
+	assert.Same(t, j, expiredHook.j)
+	assert.NoError(t, expiredHook.err)
+
+	tx.Queryable.AssertCalled(t, "Exec", mock.Anything, `DELETE FROM gue_jobs WHERE job_id = $1`, []any{j.ID.String()})
+	tx.AssertCalled(t, "Commit", mock.Anything)
+	assert.Nil(t, j.Tx())
+
+	// not expired job is worked as usual
+	j, _ = newHooksTestJob(BackoffNever)
+	j.Type = "MyJob"
+	j.ExpiresAt = sql.NullTime{Time: time.Now().Add(time.Hour), Valid: true}
+
+	assert.True(t, w.WorkOne(ctx))
+	assert.True(t, handlerCalled)
+	assert.Equal(t, 1, expiredHook.called)
+}
+
+func TestJob_ErrorExpired(t *testing.T) {
+	ctx := context.Background()
+
+	discardedHook, retriedHook := new(mockErrorHook), new(mockErrorHook)
+
+	j, _ := newHooksTestJob(NewConstantBackoff(time.Hour))
+	j.ExpiresAt = sql.NullTime{Time: time.Now().Add(time.Minute), Valid: true}
+	j.hooksRetried = []ErrorHookFunc{retriedHook.handler}
+	j.hooksDiscarded = []ErrorHookFunc{discardedHook.handler}
+
+	require.NoError(t, j.Error(ctx, errors.New("the error msg")))
+	assert.Equal(t, 0, retriedHook.called)
+	require.Equal(t, 1, discardedHook.called)
+	assert.Equal(t, ErrorActionDiscard, discardedHook.e.Action)
+	assert.Equal(t, DiscardReasonExpired, discardedHook.e.DiscardReason)
+
+	// retried before the expiry
+	j, _ = newHooksTestJob(NewConstantBackoff(time.Second))
+	j.ExpiresAt = sql.NullTime{Time: time.Now().Add(time.Minute), Valid: true}
+	j.hooksRetried = []ErrorHookFunc{retriedHook.handler}
+
+	require.NoError(t, j.Error(ctx, errors.New("the error msg")))
+	assert.Equal(t, 1, retriedHook.called)
+}
+
+func TestJobExpiresAt(t *testing.T) {
+	for name, openFunc := range adapterTesting.AllAdaptersOpenTestPool {
+		t.Run(name, func(t *testing.T) {
+			testJobExpiresAt(t, openFunc(t))
+		})
+	}
+}
+
+func testJobExpiresAt(t *testing.T, connPool adapter.ConnPool) {
+	ctx := context.Background()
+
+	c, err := NewClient(connPool)
+	require.NoError(t, err)
+
+	var worked []string
+	wm := WorkMap{
+		"MyJob": func(ctx context.Context, j *Job) error {
+			worked = append(worked, string(j.Args))
+			return nil
+		},
+	}
+	expiredHook := new(mockHook)
+	w, err := NewWorker(c, wm, WithWorkerHooksJobExpired(expiredHook.handler))
+	require.NoError(t, err)
+
+	expiresAt := time.Now().Add(time.Hour).UTC().Truncate(time.Microsecond)
+	jobs := []*Job{
+		{Type: "MyJob", Args: []byte("expired"), ExpiresAt: sql.NullTime{Time: time.Now().Add(-time.Second), Valid: true}},
+		{Type: "MyJob", Args: []byte("not expired"), ExpiresAt: sql.NullTime{Time: expiresAt, Valid: true}},
+		{Type: "MyJob", Args: []byte("never expires")},
+	}
+	require.NoError(t, c.EnqueueBatch(ctx, jobs))
+
+	j, err := c.GetJob(ctx, jobs[1].ID)
+	require.NoError(t, err)
+	require.True(t, j.ExpiresAt.Valid)
+	assert.True(t, expiresAt.Equal(j.ExpiresAt.Time))
+
+	for i := 0; i < 3; i++ {
+		require.True(t, w.WorkOne(ctx))
+	}
+	assert.False(t, w.WorkOne(ctx))
+
+	assert.ElementsMatch(t, []string{"not expired", "never expires"}, worked)
+	require.Equal(t, 1, expiredHook.called)
+	assert.Equal(t, jobs[0].ID, expiredHook.j.ID)
+
+	_, err = c.GetJob(ctx, jobs[0].ID)
+	assert.ErrorIs(t, err, adapter.ErrNoRows)
+}
@@ -0,0 +1,246 @@
+package gue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/vgarvardt/gue/v5/adapter"
+)
+
+// fairPollMaxKeys is the max number of fairness keys FairPoller tries within a single poll when the jobs
+// of the keys are locked by the other workers or the keys reached their concurrency caps.
+const fairPollMaxKeys = 16
+
+// FairPollerOption is the FairPoller configuration option.
+type FairPollerOption func(p *FairPoller)
+
+// WithFairPollerWeights sets the fairness key weights - the number of jobs of the key locked in a row before
+// the poller moves to the next key, keys without the weight set have the weight of 1.
+func WithFairPollerWeights(weights map[string]int) FairPollerOption {
+	return func(p *FairPoller) {
+		p.weights = weights
+	}
+}
+
+// WithFairPollerCaps sets the fairness key concurrency caps - the max number of jobs of the key being worked
+// at the same time by the workers sharing the poller. Keys without the cap set are capped with the default cap.
+func WithFairPollerCaps(caps map[string]int) FairPollerOption {
+	return func(p *FairPoller) {
+		p.caps = caps
+	}
+}
+
+// WithFairPollerDefaultCap sets the concurrency cap of the fairness keys without the cap set
+// with WithFairPollerCaps. Zero value (default) means no cap.
+func WithFairPollerDefaultCap(limit int) FairPollerOption {
+	return func(p *FairPoller) {
+		p.defaultCap = limit
+	}
+}
+
+// FairPoller is the Poller that locks jobs of the fairness keys with ready jobs in turns, see Job.FairnessKey,
+// so that the flood of the jobs of one tenant does not block the jobs of the other tenants in the same queue.
+// Jobs of the fairness key are locked with the wrapped Poller, e.g. the built-in PollStrategy, so they are locked
+// with FOR UPDATE SKIP LOCKED and the keys which jobs are all locked by the other workers are skipped.
+//
+// Weights and concurrency caps are applied by the poller instance, so they are shared by the workers in the pool,
+// but not across the processes - with several processes every one of them works up to cap jobs of the key.
+type FairPoller struct {
+	poller     Poller
+	weights    map[string]int
+	caps       map[string]int
+	defaultCap int
+
+	mu sync.Mutex
+	// cursor is the last fairness key the jobs were locked for and served is the number of its jobs locked in a row
+	cursor string
+	served int
+	// working is the number of jobs of the capped fairness keys being worked, including the reserved slots
+	working map[string]int
+}
+
+// NewFairPoller creates the FairPoller that locks jobs of the fairness keys in turns with the given Poller.
+func NewFairPoller(p Poller, options ...FairPollerOption) *FairPoller {
+	fp := FairPoller{
+		poller:  p,
+		working: make(map[string]int),
+	}
+
+	for _, option := range options {
+		option(&fp)
+	}
+
+	return &fp
+}
+
+// Poll implements Poller. It starts from the current fairness key until it has the weight number of jobs locked
+// in a row, then moves to the next key with ready jobs in the key order, wrapping around after the last one.
+func (p *FairPoller) Poll(ctx context.Context, c *Client, q PollQuery) ([]*Job, error) {
+	p.mu.Lock()
+	after, inclusive := p.cursor, p.served < p.weight(p.cursor)
+	p.mu.Unlock()
+
+	var skipped []string
+	wrapped := after == "" && inclusive
+	for i := 0; i < fairPollMaxKeys; i++ {
+		key, found, err := c.nextFairnessKey(ctx, lockQuery{
+			queue:               q.Queue,
+			types:               q.Types,
+			excludeTypes:        q.ExcludeTypes,
+			fairnessKeys:        q.FairnessKeys,
+			excludeFairnessKeys: append(p.cappedKeys(), skipped...),
+		}, after, inclusive)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			if wrapped {
+				return nil, nil
+			}
+			wrapped, after, inclusive = true, "", true
+			continue
+		}
+
+		skipped = append(skipped, key)
+		after, inclusive = key, false
+
+		limit, ok := p.reserve(key, q.Limit)
+		if !ok {
+			// key reached its cap after the capped keys were collected
+			continue
+		}
+
+		keyQuery := q
+		keyQuery.Limit, keyQuery.FairnessKeys = limit, []string{key}
+		jobs, err := p.poller.Poll(ctx, c, keyQuery)
+		p.locked(key, limit, jobs)
+		if err != nil || len(jobs) > 0 {
+			return jobs, err
+		}
+	}
+
+	return nil, nil
+}
+
+func (p *FairPoller) weight(key string) int {
+	if w, ok := p.weights[key]; ok && w > 0 {
+		return w
+	}
+	return 1
+}
+
+func (p *FairPoller) limit(key string) int {
+	if limit, ok := p.caps[key]; ok {
+		return limit
+	}
+	return p.defaultCap
+}
+
+// cappedKeys returns the fairness keys that reached their concurrency caps.
+func (p *FairPoller) cappedKeys() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var keys []string
+	for key, n := range p.working {
+		if limit := p.limit(key); limit > 0 && n >= limit {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
+
+// reserve reserves the concurrency cap slots for up to n jobs of the fairness key, it returns the number of jobs
+// to lock and false when the key reached its cap.
+func (p *FairPoller) reserve(key string, n int) (int, bool) {
+	if n < 1 {
+		n = 1
+	}
+
+	limit := p.limit(key)
+	if limit <= 0 {
+		return n, true
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	free := limit - p.working[key]
+	if free <= 0 {
+		return 0, false
+	}
+	if n > free {
+		n = free
+	}
+	p.working[key] += n
+
+	return n, true
+}
+
+// locked moves the cursor to the fairness key the jobs were locked for and frees the reserved cap slots
+// that were not used, the rest are freed when the locked jobs are done.
+func (p *FairPoller) locked(key string, reserved int, jobs []*Job) {
+	p.mu.Lock()
+	if len(jobs) > 0 {
+		if key != p.cursor {
+			p.cursor, p.served = key, 0
+		}
+		p.served += len(jobs)
+	}
+	capped := p.limit(key) > 0
+	if capped {
+		p.release(key, reserved-len(jobs))
+	}
+	p.mu.Unlock()
+
+	if !capped {
+		return
+	}
+
+	// jobs are not returned to the worker yet, so they are not shared and can be modified without the lock
+	for _, j := range jobs {
+		j.onFinish = func() {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+
+			p.release(key, 1)
+		}
+	}
+}
+
+// release frees n cap slots of the fairness key, must be called with FairPoller.mu held.
+func (p *FairPoller) release(key string, n int) {
+	p.working[key] -= n
+	if p.working[key] <= 0 {
+		delete(p.working, key)
+	}
+}
+
+// nextFairnessKey returns the first fairness key after the given one in the key order that has the ready jobs
+// matching the query, the given key is included when inclusive is true.
+func (c *Client) nextFairnessKey(ctx context.Context, q lockQuery, after string, inclusive bool) (string, bool, error) {
+	where, args := q.where(c, 1)
+	args = append(args, after)
+	op := ` > `
+	if inclusive {
+		op = ` >= `
+	}
+
+	var key string
+	err := c.pool.QueryRow(ctx, `SELECT fairness_key FROM `+c.jobsTable+`
+WHERE `+where+` AND fairness_key`+op+`$`+strconv.Itoa(len(args))+`
+ORDER BY fairness_key
+LIMIT $3`, args...).Scan(&key)
+	if errors.Is(err, adapter.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("could not get next fairness key: %w", err)
+	}
+
+	return key, true, nil
+}
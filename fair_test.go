@@ -0,0 +1,185 @@
+package gue
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vgarvardt/gue/v5/adapter"
+	adapterTesting "github.com/vgarvardt/gue/v5/adapter/testing"
+)
+
+func TestFairPoller_caps(t *testing.T) {
+	p := NewFairPoller(PriorityPollStrategy, WithFairPollerCaps(map[string]int{"a": 2, "b": 0}), WithFairPollerDefaultCap(1))
+
+	n, ok := p.reserve("a", 5)
+	require.True(t, ok)
+	assert.Equal(t, 2, n)
+	_, ok = p.reserve("a", 1)
+	assert.False(t, ok)
+	assert.Equal(t, []string{"a"}, p.cappedKeys())
+
+	// unused reserved slot is freed right away, the used one - when the job is done
+	j := &Job{}
+	p.locked("a", 2, []*Job{j})
+	assert.Empty(t, p.cappedKeys())
+	assert.Equal(t, 1, p.working["a"])
+	assert.Equal(t, "a", p.cursor)
+	assert.Equal(t, 1, p.served)
+
+	j.finish()
+	assert.Empty(t, p.working)
+	j.finish()
+	assert.Empty(t, p.working)
+
+	// explicit zero cap disables the default one
+	n, ok = p.reserve("b", 5)
+	require.True(t, ok)
+	assert.Equal(t, 5, n)
+	p.locked("b", 5, []*Job{{}, {}})
+	assert.Empty(t, p.working)
+	assert.Equal(t, "b", p.cursor)
+	assert.Equal(t, 2, p.served)
+
+	n, ok = p.reserve("c", 5)
+	require.True(t, ok)
+	assert.Equal(t, 1, n)
+	p.locked("c", 1, nil)
+	assert.Empty(t, p.working)
+	assert.Equal(t, "b", p.cursor)
+}
+
+func TestFairPoller_weight(t *testing.T) {
+	p := NewFairPoller(PriorityPollStrategy, WithFairPollerWeights(map[string]int{"a": 3, "b": 0}))
+	assert.Equal(t, 3, p.weight("a"))
+	assert.Equal(t, 1, p.weight("b"))
+	assert.Equal(t, 1, p.weight("c"))
+}
+
+func TestClient_nextFairnessKey(t *testing.T) {
+	ctx := context.Background()
+
+	row := new(adapterTesting.Row)
+	row.On("Scan", mock.Anything).Return(adapter.ErrNoRows)
+
+	pool := new(adapterTesting.ConnPool)
+	pool.Queryable.On("QueryRow", ctx, mock.MatchedBy(func(sql string) bool {
+		return assert.Contains(t, sql, "AND fairness_key NOT IN ($4, $5) AND fairness_key > $6\nORDER BY fairness_key\nLIMIT $3")
+	}), mock.MatchedBy(func(args []any) bool {
+		return assert.Len(t, args, 6) && assert.Equal(t, []any{1, "a", "b", "c"}, args[2:])
+	})).Return(row)
+
+	c, err := NewClient(pool)
+	require.NoError(t, err)
+
+	_, found, err := c.nextFairnessKey(ctx, lockQuery{queue: "q", excludeFairnessKeys: []string{"a", "b"}}, "c", false)
+	require.NoError(t, err)
+	assert.False(t, found)
+	pool.Queryable.AssertExpectations(t)
+}
+
+func TestFairPoller(t *testing.T) {
+	for name, openFunc := range adapterTesting.AllAdaptersOpenTestPool {
+		t.Run(name, func(t *testing.T) {
+			testFairPoller(t, openFunc(t))
+		})
+	}
+}
+
+func testFairPoller(t *testing.T, connPool adapter.ConnPool) {
+	ctx := context.Background()
+
+	c, err := NewClient(connPool)
+	require.NoError(t, err)
+
+	var worked []string
+	wm := WorkMap{
+		"MyJob": func(ctx context.Context, j *Job) error {
+			worked = append(worked, j.FairnessKey)
+			return nil
+		},
+	}
+
+	enqueue := func() {
+		var jobs []*Job
+		for i := 0; i < 5; i++ {
+			jobs = append(jobs, &Job{Type: "MyJob", FairnessKey: "tenant-a"})
+		}
+		jobs = append(jobs, &Job{Type: "MyJob", FairnessKey: "tenant-b"}, &Job{Type: "MyJob", FairnessKey: "tenant-b"})
+		require.NoError(t, c.EnqueueBatch(ctx, jobs))
+	}
+
+	t.Run("round robin", func(t *testing.T) {
+		enqueue()
+		worked = nil
+
+		w, err := NewWorker(c, wm, WithWorkerPoller(NewFairPoller(PriorityPollStrategy)))
+		require.NoError(t, err)
+
+		for i := 0; i < 7; i++ {
+			require.True(t, w.WorkOne(ctx))
+		}
+		assert.False(t, w.WorkOne(ctx))
+		assert.Equal(t, []string{
+			"tenant-a", "tenant-b", "tenant-a", "tenant-b", "tenant-a", "tenant-a", "tenant-a",
+		}, worked)
+	})
+
+	t.Run("weights", func(t *testing.T) {
+		enqueue()
+		worked = nil
+
+		p := NewFairPoller(PriorityPollStrategy, WithFairPollerWeights(map[string]int{"tenant-a": 2}))
+		w, err := NewWorker(c, wm, WithWorkerPoller(p))
+		require.NoError(t, err)
+
+		for i := 0; i < 7; i++ {
+			require.True(t, w.WorkOne(ctx))
+		}
+		assert.Equal(t, []string{
+			"tenant-a", "tenant-a", "tenant-b", "tenant-a", "tenant-a", "tenant-b", "tenant-a",
+		}, worked)
+	})
+
+	t.Run("caps", func(t *testing.T) {
+		enqueue()
+
+		p := NewFairPoller(PriorityPollStrategy, WithFairPollerCaps(map[string]int{"tenant-a": 1}))
+		q := PollQuery{Limit: 1}
+
+		poll := func() *Job {
+			jobs, err := p.Poll(ctx, c, q)
+			require.NoError(t, err)
+			require.Len(t, jobs, 1)
+			return jobs[0]
+		}
+
+		j1 := poll()
+		assert.Equal(t, "tenant-a", j1.FairnessKey)
+
+		// capped tenant is skipped until its job is done
+		j2 := poll()
+		assert.Equal(t, "tenant-b", j2.FairnessKey)
+		j3 := poll()
+		assert.Equal(t, "tenant-b", j3.FairnessKey)
+
+		jobs, err := p.Poll(ctx, c, q)
+		require.NoError(t, err)
+		assert.Empty(t, jobs)
+
+		for _, j := range []*Job{j1, j2, j3} {
+			require.NoError(t, j.Delete(ctx))
+			require.NoError(t, j.Done(ctx))
+		}
+
+		for i := 0; i < 4; i++ {
+			j := poll()
+			assert.Equal(t, "tenant-a", j.FairnessKey)
+			require.NoError(t, j.Delete(ctx))
+			require.NoError(t, j.Done(ctx))
+		}
+	})
+}
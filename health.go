@@ -0,0 +1,177 @@
+package gue
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+const (
+	// healthStallGrace is added to the doubled poll interval to get the time after which idle worker
+	// that did not poll for jobs is considered stalled, it covers the lock query duration.
+	healthStallGrace = 30 * time.Second
+	// healthMaxLockErrors is the number of consecutive lock errors after which worker is considered not ready.
+	healthMaxLockErrors = 3
+)
+
+// HealthProbe is the kind of the health check performed by the health handler.
+type HealthProbe string
+
+const (
+	// HealthProbeLiveness checks that workers are running and their run loops are progressing. DB is not pinged
+	// for it, as restarting the process would not help when DB is not available.
+	HealthProbeLiveness HealthProbe = "liveness"
+	// HealthProbeReadiness checks liveness, DB availability and that workers are able to lock jobs.
+	HealthProbeReadiness HealthProbe = "readiness"
+)
+
+// HealthChecker is implemented by Worker and WorkerPool. DB availability is checked for the HealthProbeReadiness
+// probe only.
+type HealthChecker interface {
+	Health(ctx context.Context, probe HealthProbe) Health
+}
+
+// Health is the health state of the Worker or all the WorkerPool workers.
+type Health struct {
+	// DBError is the DB ping error, empty if DB is available or DB was not pinged for the liveness probe.
+	DBError string `json:"db_error,omitempty"`
+	// Workers is the health state of the workers.
+	Workers []WorkerHealth `json:"workers"`
+}
+
+// WorkerHealth is the health state of the single worker.
+type WorkerHealth struct {
+	// ID is the worker ID.
+	ID string `json:"id"`
+	// Running is true when the worker run loop is started and not yet finished.
+	Running bool `json:"running"`
+	// Progressing is true when the worker is running and it is either working on a job for no longer than
+	// the maximum job duration set with WithWorkerMaxJobDuration or polled for jobs recently.
+	Progressing bool `json:"progressing"`
+	// LastPollAt is the time of the last successful poll for jobs, regardless of whether job was found or not.
+	LastPollAt time.Time `json:"last_poll_at"`
+	// WorkingSince is the time worker locked the job it is working on, nil if worker is idle.
+	WorkingSince *time.Time `json:"working_since,omitempty"`
+	// ConsecutiveLockErrors is the number of consecutive failed polls for jobs.
+	ConsecutiveLockErrors int64 `json:"consecutive_lock_errors"`
+}
+
+// Live returns true when all the workers are running and their run loops are progressing.
+func (h Health) Live() bool {
+	for _, wh := range h.Workers {
+		if !wh.Running || !wh.Progressing {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Ready returns true when workers are live, DB is available and workers are able to lock jobs.
+func (h Health) Ready() bool {
+	if h.DBError != "" || !h.Live() {
+		return false
+	}
+
+	for _, wh := range h.Workers {
+		if wh.ConsecutiveLockErrors >= healthMaxLockErrors {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Health returns worker health state, see HealthChecker.
+func (w *Worker) Health(ctx context.Context, probe HealthProbe) Health {
+	return Health{
+		DBError: pingDB(ctx, w.c, probe),
+		Workers: []WorkerHealth{w.workerHealth()},
+	}
+}
+
+// Health returns health state of all the pool workers, see HealthChecker.
+func (w *WorkerPool) Health(ctx context.Context, probe HealthProbe) Health {
+	workers := w.runningWorkers()
+	h := Health{
+		DBError: pingDB(ctx, w.c, probe),
+		Workers: make([]WorkerHealth, len(workers)),
+	}
+
+	for i := range workers {
+		h.Workers[i] = workers[i].workerHealth()
+	}
+
+	return h
+}
+
+func (w *Worker) workerHealth() WorkerHealth {
+	w.mu.Lock()
+	running := w.running
+	w.mu.Unlock()
+
+	wh := WorkerHealth{
+		ID:                    w.id,
+		Running:               running,
+		ConsecutiveLockErrors: w.lockErrors.Load(),
+	}
+
+	if lastPollAt := w.lastPollAt.Load(); lastPollAt > 0 {
+		wh.LastPollAt = time.Unix(0, lastPollAt)
+	}
+	if workingSince := w.workingSince.Load(); workingSince > 0 {
+		since := time.Unix(0, workingSince)
+		wh.WorkingSince = &since
+	}
+
+	// polls that failed to lock a job are still the progress of the run loop, lock errors are reported separately
+	lastPollAttemptAt := time.Unix(0, w.lastPollAttemptAt.Load())
+	if wh.WorkingSince != nil {
+		wh.Progressing = running && (w.maxJobDuration <= 0 || time.Since(*wh.WorkingSince) <= w.maxJobDuration)
+	} else {
+		wh.Progressing = running && time.Since(lastPollAttemptAt) <= 2*w.pollInterval()+healthStallGrace
+	}
+
+	return wh
+}
+
+// pingDB pings the DB for the readiness probe only, so that the DB outage does not fail the liveness probe
+// and healthy workers are not restarted.
+func pingDB(ctx context.Context, c *Client, probe HealthProbe) string {
+	if probe == HealthProbeLiveness {
+		return ""
+	}
+
+	if err := c.pool.Ping(ctx); err != nil {
+		return err.Error()
+	}
+
+	return ""
+}
+
+// NewHealthHandler returns http.Handler that performs health probe against the checker, usually Worker or
+// WorkerPool, and responds with 200 OK when the probe passes and with 503 Service Unavailable otherwise.
+// Response body contains Health JSON, e.g.
+//
+//	mux.Handle("/livez", gue.NewHealthHandler(pool, gue.HealthProbeLiveness))
+//	mux.Handle("/readyz", gue.NewHealthHandler(pool, gue.HealthProbeReadiness))
+func NewHealthHandler(checker HealthChecker, probe HealthProbe) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := checker.Health(r.Context(), probe)
+
+		passed := h.Ready()
+		if probe == HealthProbeLiveness {
+			passed = h.Live()
+		}
+
+		status := http.StatusOK
+		if !passed {
+			status = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(h)
+	})
+}
@@ -0,0 +1,126 @@
+package gue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	adapterTesting "github.com/vgarvardt/gue/v5/adapter/testing"
+)
+
+func TestWorker_Health(t *testing.T) {
+	ctx := context.Background()
+
+	pool := new(adapterTesting.ConnPool)
+	pool.On("Ping", mock.Anything).Return(nil).Once()
+	pool.On("Ping", mock.Anything).Return(errors.New("db is down"))
+
+	c, err := NewClient(pool)
+	require.NoError(t, err)
+
+	w, err := NewWorker(c, dummyWM)
+	require.NoError(t, err)
+
+	lockErr := errors.New("could not lock")
+	w.pollFunc = func(context.Context, string) (*Job, error) {
+		return nil, lockErr
+	}
+
+	// worker is not running yet
+	h := w.Health(ctx, HealthProbeReadiness)
+	assert.Empty(t, h.DBError)
+	require.Len(t, h.Workers, 1)
+	assert.Equal(t, w.id, h.Workers[0].ID)
+	assert.False(t, h.Live())
+	assert.False(t, h.Ready())
+
+	w.running = true
+	assert.False(t, w.WorkOne(ctx))
+	h = w.Health(ctx, HealthProbeReadiness)
+	assert.Equal(t, "db is down", h.DBError)
+	assert.True(t, h.Workers[0].Progressing)
+	assert.True(t, h.Workers[0].LastPollAt.IsZero())
+	assert.Equal(t, int64(1), h.Workers[0].ConsecutiveLockErrors)
+	assert.True(t, h.Live())
+	assert.False(t, h.Ready())
+
+	// DB is not pinged for the liveness probe
+	h = w.Health(ctx, HealthProbeLiveness)
+	assert.Empty(t, h.DBError)
+	assert.True(t, h.Live())
+	pool.AssertNumberOfCalls(t, "Ping", 2)
+
+	h.DBError = ""
+	assert.True(t, h.Ready())
+	h.Workers[0].ConsecutiveLockErrors = healthMaxLockErrors
+	assert.False(t, h.Ready())
+
+	w.pollFunc = func(context.Context, string) (*Job, error) {
+		return nil, nil
+	}
+	assert.False(t, w.WorkOne(ctx))
+	wh := w.workerHealth()
+	assert.Equal(t, int64(0), wh.ConsecutiveLockErrors)
+	assert.WithinDuration(t, time.Now(), wh.LastPollAt, time.Second)
+
+	// idle worker that did not poll for a long time is stalled
+	w.lastPollAttemptAt.Store(time.Now().Add(-time.Hour).UnixNano())
+	assert.False(t, w.workerHealth().Progressing)
+
+	// unless it is working on a long-running job
+	w.workingSince.Store(time.Now().Add(-time.Hour).UnixNano())
+	wh = w.workerHealth()
+	assert.True(t, wh.Progressing)
+	require.NotNil(t, wh.WorkingSince)
+	assert.WithinDuration(t, time.Now().Add(-time.Hour), *wh.WorkingSince, time.Second)
+
+	// that is stuck for longer than the max job duration
+	w.maxJobDuration = time.Minute
+	assert.False(t, w.workerHealth().Progressing)
+	w.maxJobDuration = 2 * time.Hour
+	assert.True(t, w.workerHealth().Progressing)
+}
+
+func TestNewHealthHandler(t *testing.T) {
+	pool := new(adapterTesting.ConnPool)
+	pool.On("Ping", mock.Anything).Return(errors.New("db is down"))
+
+	c, err := NewClient(pool)
+	require.NoError(t, err)
+
+	wp, err := NewWorkerPool(c, dummyWM, 2)
+	require.NoError(t, err)
+
+	for _, w := range wp.workers {
+		w.running = true
+		w.lastPollAttemptAt.Store(time.Now().UnixNano())
+	}
+
+	rec := httptest.NewRecorder()
+	NewHealthHandler(wp, HealthProbeLiveness).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/livez", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var h Health
+	err = json.Unmarshal(rec.Body.Bytes(), &h)
+	require.NoError(t, err)
+	assert.Len(t, h.Workers, 2)
+	assert.Empty(t, h.DBError)
+	pool.AssertNotCalled(t, "Ping", mock.Anything)
+
+	rec = httptest.NewRecorder()
+	NewHealthHandler(wp, HealthProbeReadiness).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	h = Health{}
+	err = json.Unmarshal(rec.Body.Bytes(), &h)
+	require.NoError(t, err)
+	assert.Equal(t, "db is down", h.DBError)
+}
@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"sync"
 	"time"
+
+	"github.com/vgarvardt/gue/v5/adapter"
 )
 
 // RandomStringID returns random alphanumeric string that can be used as ID.
@@ -15,6 +17,14 @@ func RandomStringID() string {
 	return hex.EncodeToString(hash[:])[:6]
 }
 
+// closeRows closes the rows when the adapter supports it, see adapter.RowsCloser. It is meant to be deferred right
+// after the successful query, so that the rows are released on the scan errors as well.
+func closeRows(rows adapter.Rows) {
+	if closer, ok := rows.(adapter.RowsCloser); ok {
+		closer.Close()
+	}
+}
+
 // RunLock ensures that there is only one instance of the running callback function "f" (worker).
 func RunLock(ctx context.Context, f func(ctx context.Context) error, mu *sync.Mutex, running *bool, id string) error {
 	mu.Lock()
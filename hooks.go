@@ -0,0 +1,50 @@
+package gue
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrJobInterrupted is passed to the timed out hooks for the jobs interrupted by the drain timeout,
+// see WithWorkerDrainTimeout.
+var ErrJobInterrupted = errors.New("job interrupted by the worker drain timeout")
+
+// DiscardReason is the reason the failed Job was discarded for.
+type DiscardReason string
+
+const (
+	// DiscardReasonBackoff means that the Backoff returned negative duration, e.g. max number of attempts reached.
+	DiscardReasonBackoff DiscardReason = "backoff"
+	// DiscardReasonHandler means that the handler returned ErrDiscardJob error.
+	DiscardReasonHandler DiscardReason = "handler"
+	// DiscardReasonClassifier means that the ErrorClassifier decided to discard the Job.
+	DiscardReasonClassifier DiscardReason = "classifier"
+	// DiscardReasonExpired means that the Job would expire before it is worked again, see Job.ExpiresAt.
+	DiscardReasonExpired DiscardReason = "expired"
+)
+
+// JobErrorEvent is the outcome of the failed Job passed to the ErrorHookFunc.
+type JobErrorEvent struct {
+	// Err is the error the Job failed with.
+	Err error
+	// Attempt is the number of the failed attempt, starting from 1.
+	Attempt int32
+	// Action is the action applied to the Job, see ErrorClassifier.
+	Action ErrorAction
+	// NextRunAt is the time the retried Job is rescheduled to, it is zero for the discarded Job.
+	NextRunAt time.Time
+	// DiscardReason is the reason the Job was discarded for, it is empty for the retried Job.
+	DiscardReason DiscardReason
+}
+
+// ErrorHookFunc is a function that may react to the failed Job being retried or discarded.
+// The same restrictions as for the HookFunc apply.
+type ErrorHookFunc func(ctx context.Context, j *Job, e JobErrorEvent)
+
+// fireErrorHooks calls the failed job hooks, hooks are called only when the job changes were committed.
+func (j *Job) fireErrorHooks(ctx context.Context, hooks []ErrorHookFunc, e JobErrorEvent) {
+	for _, hook := range hooks {
+		hook(ctx, j, e)
+	}
+}
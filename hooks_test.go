@@ -0,0 +1,230 @@
+package gue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vgarvardt/gue/v5/adapter"
+	adapterTesting "github.com/vgarvardt/gue/v5/adapter/testing"
+)
+
+type mockErrorHook struct {
+	called int
+	j      *Job
+	e      JobErrorEvent
+}
+
+func (h *mockErrorHook) handler(_ context.Context, j *Job, e JobErrorEvent) {
+	h.called++
+	h.j, h.e = j, e
+}
+
+func newHooksTestJob(backoff Backoff) (*Job, *adapterTesting.Tx) {
+	tx := new(adapterTesting.Tx)
+	tx.Queryable.On("Exec", mock.Anything, mock.Anything, mock.Anything).Return(nil, nil)
+	tx.On("Commit", mock.Anything).Return(nil)
+
+	return &Job{
+		ID:         ulid.MustParse("01H5Q7BZ7CDGA3KQWQ7PGXF8DK"),
+		Type:       "MyJob",
+		ErrorCount: 2,
+		tx:         tx,
+		table:      "gue_jobs",
+		backoff:    AsJobBackoff(backoff),
+		logger:     adapter.NoOpLogger{},
+	}, tx
+}
+
+func TestJob_ErrorHooks(t *testing.T) {
+	ctx := context.Background()
+
+	for name, tc := range map[string]struct {
+		backoff       Backoff
+		err           error
+		retried       bool
+		discardReason DiscardReason
+	}{
+		"retried": {
+			backoff: NewConstantBackoff(time.Minute),
+			err:     errors.New("the error msg"),
+			retried: true,
+		},
+		"discarded by backoff": {
+			backoff:       BackoffNever,
+			err:           errors.New("the error msg"),
+			discardReason: DiscardReasonBackoff,
+		},
+		"discarded by handler": {
+			backoff:       NewConstantBackoff(time.Minute),
+			err:           ErrDiscardJob("the reason"),
+			discardReason: DiscardReasonHandler,
+		},
+		"discarded by classifier": {
+			backoff:       NewConstantBackoff(time.Minute),
+			err:           context.DeadlineExceeded,
+			discardReason: DiscardReasonClassifier,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			retriedHook, discardedHook := new(mockErrorHook), new(mockErrorHook)
+
+			j, _ := newHooksTestJob(tc.backoff)
+			j.hooksRetried = []ErrorHookFunc{retriedHook.handler}
+			j.hooksDiscarded = []ErrorHookFunc{discardedHook.handler}
+			j.errorClassifier = ClassifyErrorIs(context.DeadlineExceeded, ErrorDecision{Action: ErrorActionDiscard})
+
+			require.NoError(t, j.Error(ctx, tc.err))
+
+			hook, otherHook := retriedHook, discardedHook
+			if !tc.retried {
+				hook, otherHook = discardedHook, retriedHook
+			}
+
+			assert.Equal(t, 0, otherHook.called)
+			require.Equal(t, 1, hook.called)
+			assert.Same(t, j, hook.j)
+			assert.Equal(t, tc.err, hook.e.Err)
+			assert.Equal(t, int32(3), hook.e.Attempt)
+			assert.Equal(t, tc.discardReason, hook.e.DiscardReason)
+			if tc.retried {
+				assert.Equal(t, ErrorActionRetry, hook.e.Action)
+				assert.WithinDuration(t, time.Now().Add(time.Minute), hook.e.NextRunAt, time.Second)
+			} else {
+				assert.Equal(t, ErrorActionDiscard, hook.e.Action)
+				assert.True(t, hook.e.NextRunAt.IsZero())
+			}
+		})
+	}
+}
+
+func TestJob_ErrorHooksAfterCommit(t *testing.T) {
+	ctx := context.Background()
+
+	// hooks are not fired when the job changes were not committed
+	tx := new(adapterTesting.Tx)
+	tx.Queryable.On("Exec", mock.Anything, mock.Anything, mock.Anything).Return(nil, nil)
+	tx.On("Commit", mock.Anything).Return(errors.New("commit failed"))
+
+	retriedHook := new(mockErrorHook)
+	j, _ := newHooksTestJob(NewConstantBackoff(time.Minute))
+	j.tx = tx
+	j.hooksRetried = []ErrorHookFunc{retriedHook.handler}
+
+	require.Error(t, j.Error(ctx, errors.New("the error msg")))
+	assert.Equal(t, 0, retriedHook.called)
+
+	// batch jobs hooks are fired when the shared transaction is committed with the last batch job
+	batchTx := new(adapterTesting.Tx)
+	batchTx.Queryable.On("Exec", mock.Anything, mock.Anything, mock.Anything).Return(nil, nil)
+	batchTx.On("Commit", mock.Anything).Return(nil).Once()
+
+	batch := &jobBatch{tx: batchTx, pending: 2}
+	first, _ := newHooksTestJob(NewConstantBackoff(time.Minute))
+	second, _ := newHooksTestJob(NewConstantBackoff(time.Minute))
+	for _, bj := range []*Job{first, second} {
+		bj.tx, bj.batch = batchTx, batch
+		bj.hooksRetried = []ErrorHookFunc{retriedHook.handler}
+	}
+
+	require.NoError(t, first.Error(ctx, errors.New("the error msg")))
+	assert.Equal(t, 0, retriedHook.called)
+	batchTx.AssertNotCalled(t, "Commit", mock.Anything)
+
+	require.NoError(t, second.Done(ctx))
+	batchTx.AssertExpectations(t)
+	require.Equal(t, 1, retriedHook.called)
+	assert.Same(t, first, retriedHook.j)
+}
+
+func TestWorker_LifecycleHooks(t *testing.T) {
+	ctx := context.Background()
+
+	wm := WorkMap{
+		"panic": func(ctx context.Context, j *Job) error {
+			panic("the panic msg")
+		},
+		"timeout": func(ctx context.Context, j *Job) error {
+			return fmt.Errorf("could not call the service: %w", context.DeadlineExceeded)
+		},
+	}
+
+	retriedHook, discardedHook := new(mockErrorHook), new(mockErrorHook)
+	panickedHook, timedOutHook, lockEmptyHook := new(mockHook), new(mockHook), new(mockHook)
+
+	w, err := NewWorker(
+		nil,
+		wm,
+		WithWorkerHooksJobRetried(retriedHook.handler),
+		WithWorkerHooksJobDiscarded(discardedHook.handler),
+		WithWorkerHooksJobPanicked(panickedHook.handler),
+		WithWorkerHooksJobTimedOut(timedOutHook.handler),
+		WithWorkerHooksLockEmpty(lockEmptyHook.handler),
+	)
+	require.NoError(t, err)
+
+	var next *Job
+	w.pollFunc = func(context.Context, string) (*Job, error) {
+		return next, nil
+	}
+
+	assert.False(t, w.WorkOne(ctx))
+	assert.Equal(t, 1, lockEmptyHook.called)
+	assert.Nil(t, lockEmptyHook.j)
+	assert.NoError(t, lockEmptyHook.err)
+
+	next, _ = newHooksTestJob(NewConstantBackoff(time.Minute))
+	next.Type = "panic"
+	assert.True(t, w.WorkOne(ctx))
+	require.Equal(t, 1, panickedHook.called)
+	assert.Same(t, next, panickedHook.j)
+	assert.Contains(t, panickedHook.err.Error(), "the panic msg")
+	require.Equal(t, 1, retriedHook.called)
+	assert.Equal(t, panickedHook.err, retriedHook.e.Err)
+	assert.Equal(t, 0, timedOutHook.called)
+
+	next, _ = newHooksTestJob(BackoffNever)
+	next.Type = "timeout"
+	assert.True(t, w.WorkOne(ctx))
+	require.Equal(t, 1, timedOutHook.called)
+	assert.ErrorIs(t, timedOutHook.err, context.DeadlineExceeded)
+	require.Equal(t, 1, discardedHook.called)
+	assert.Equal(t, DiscardReasonBackoff, discardedHook.e.DiscardReason)
+
+	assert.Equal(t, 1, lockEmptyHook.called)
+	assert.Equal(t, 1, panickedHook.called)
+	assert.Equal(t, 1, retriedHook.called)
+}
+
+func TestClient_HooksJobEnqueued(t *testing.T) {
+	ctx := context.Background()
+
+	pool := new(adapterTesting.ConnPool)
+	pool.Queryable.On("Exec", ctx, mock.Anything, mock.Anything).Return(nil, nil).Once()
+	pool.Queryable.On("Exec", ctx, mock.Anything, mock.Anything).Return(nil, errors.New("db is down")).Once()
+
+	hook := new(mockHook)
+	c, err := NewClient(pool, WithClientHooksJobEnqueued(hook.handler))
+	require.NoError(t, err)
+
+	j := &Job{Type: "MyJob"}
+	require.NoError(t, c.Enqueue(ctx, j))
+	require.Equal(t, 1, hook.called)
+	assert.Same(t, j, hook.j)
+	assert.NoError(t, hook.err)
+
+	require.Error(t, c.Enqueue(ctx, &Job{Type: "MyJob"}))
+	require.Equal(t, 2, hook.called)
+	assert.Error(t, hook.err)
+
+	// invalid job is not enqueued at all
+	require.ErrorIs(t, c.Enqueue(ctx, &Job{}), ErrMissingType)
+	assert.Equal(t, 2, hook.called)
+}
@@ -3,6 +3,7 @@ package gue
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -24,6 +25,10 @@ const (
 	JobPriorityLowest  JobPriority = 32767
 )
 
+// jobColumns is the list of columns Job is being read from, in the order expected by Job.scanDest.
+const jobColumns = `job_id, queue, priority, run_at, job_type, args, error_count, last_error, created_at, updated_at,
+backoff_name, expires_at, COALESCE(unique_key, ''), fairness_key`
+
 // Job is a single unit of work for Gue to perform.
 type Job struct {
 	// ID is the unique database ID of the Job. It is ignored on job creation.
@@ -43,12 +48,36 @@ type Job struct {
 	// to delay a job's execution.
 	RunAt time.Time
 
+	// ExpiresAt is the time after which the Job is not useful anymore. Worker discards the expired Job without
+	// calling its handler, and the failed Job is discarded instead of being rescheduled beyond its expiry time.
+	// It is optional, the Job never expires by default.
+	ExpiresAt sql.NullTime
+
 	// Type maps job to a worker func.
 	Type string
 
+	// UniqueKey makes the Job unique among the pending jobs of the same type, e.g. "rebuild-search-index"
+	// job for the single tenant. Enqueued Job collapses into the pending one with the same key according
+	// to the UniqueMode. Job being worked is not pending, so the new Job is enqueued while it runs, and the failed
+	// Job becomes pending again once it is rescheduled. Enqueues of the same key are serialised with the transaction
+	// level lock, with Client.EnqueueTx it is held until the transaction ends. It is optional.
+	UniqueKey string
+
+	// UniqueMode defines how the Job with the UniqueKey collapses into the pending one on enqueue,
+	// drops the enqueued Job by default. It is not stored in the DB.
+	UniqueMode UniqueMode
+
 	// Args for the job.
 	Args []byte
 
+	// FairnessKey groups the jobs the FairPoller locks in turns, e.g. the tenant ID, so that the flood of the jobs
+	// of one tenant does not block the jobs of the other tenants in the same queue. It is optional.
+	FairnessKey string
+
+	// BackoffName is the name of the Backoff registered with WithClientNamedBackoff that is applied to the Job
+	// when it fails instead of the job type, queue or client default one. It is optional.
+	BackoffName string
+
 	// ErrorCount is the number of times this job has attempted to run, but failed with an error.
 	// It is ignored on job creation.
 	// This field is initialised only when the Job is being retrieved from the DB and is not
@@ -60,17 +89,77 @@ type Job struct {
 	// being updated when the current Job run errored. This field supposed to be used mostly for the debug reasons.
 	LastError sql.NullString
 
+	// CreatedAt is the time the Job was enqueued at. It is ignored on job creation.
+	CreatedAt time.Time
+
+	// UpdatedAt is the time the Job was updated at the last time, e.g. when its last run failed.
+	// It is ignored on job creation.
+	UpdatedAt time.Time
+
 	mu      sync.Mutex
 	deleted bool
 	tx      adapter.Tx
-	backoff Backoff
+	table   string
+	backoff JobBackoff
 	logger  adapter.Logger
+
+	// batch is the transaction shared with the other jobs locked together, nil for the single locked job
+	batch *jobBatch
+	// savepoint is true when the job savepoint is open in the batch transaction
+	savepoint bool
+
+	// errorClassifier is set from the client or the worker working the job, see ErrorClassifier
+	errorClassifier ErrorClassifier
+
+	// hooksRetried and hooksDiscarded are set by the worker working the job
+	hooksRetried   []ErrorHookFunc
+	hooksDiscarded []ErrorHookFunc
+
+	// attemptsTable is set when the attempts log is enabled, see WithClientAttemptsLog
+	attemptsTable string
+	// attemptsKept is true when the attempts are kept for the retention period after the job is deleted
+	attemptsKept bool
+	// workerID and startedAt are the current attempt details recorded to the attempts log
+	workerID  string
+	startedAt time.Time
+
+	// priorityAging is used to calculate the aged run at of the rescheduled job, see WithClientPriorityAging
+	priorityAging time.Duration
+
+	// onFinish is called once when the job is done or released, e.g. to free the FairPoller concurrency cap slot
+	onFinish func()
+	// committed are called once the job transaction is committed, e.g. to fire the error hooks, see Job.onCommit
+	committed []func()
+}
+
+// scanDest returns Job fields to scan jobColumns into.
+func (j *Job) scanDest() []any {
+	return []any{
+		&j.ID, &j.Queue, &j.Priority, &j.RunAt, &j.Type, &j.Args, &j.ErrorCount, &j.LastError, &j.CreatedAt, &j.UpdatedAt,
+		&j.BackoffName, &j.ExpiresAt, &j.UniqueKey, &j.FairnessKey,
+	}
+}
+
+// waitDuration returns the time the job waited since it became eligible for execution until the given lock time.
+// Job becomes eligible at its run_at, but not earlier than it was enqueued, as run_at may be set in the past.
+func (j *Job) waitDuration(lockedAt time.Time) time.Duration {
+	eligibleAt := j.RunAt
+	if j.CreatedAt.After(eligibleAt) {
+		eligibleAt = j.CreatedAt
+	}
+
+	if wait := lockedAt.Sub(eligibleAt); wait > 0 {
+		return wait
+	}
+	return 0
 }
 
 // Tx returns DB transaction that this job is locked to. You may use
 // it as you please until you call Done(). At that point, this transaction
 // will be committed. This function will return nil if the Job's
 // transaction was closed with Done().
+//
+// Jobs locked with Client.LockJobs share the same transaction, it is committed when all of them are done.
 func (j *Job) Tx() adapter.Tx {
 	return j.tx
 }
@@ -81,6 +170,11 @@ func (j *Job) Tx() adapter.Tx {
 // the pool. If you got the job from the worker - it will take care of cleaning up the job and resources,
 // no need to do this manually in a WorkFunc.
 func (j *Job) Delete(ctx context.Context) error {
+	return j.delete(ctx, false)
+}
+
+// delete deletes the job, rollback is applied to the batched jobs only, see Job.execBatch.
+func (j *Job) delete(ctx context.Context, rollback bool) error {
 	j.mu.Lock()
 	defer j.mu.Unlock()
 
@@ -88,11 +182,16 @@ func (j *Job) Delete(ctx context.Context) error {
 		return nil
 	}
 
-	_, err := j.tx.Exec(ctx, `DELETE FROM gue_jobs WHERE job_id = $1`, j.ID.String())
-	if err != nil {
+	if err := j.exec(ctx, rollback, `DELETE FROM `+j.table+` WHERE job_id = $1`, j.ID.String()); err != nil {
 		return err
 	}
 
+	if j.attemptsTable != "" && !j.attemptsKept {
+		if err := j.exec(ctx, false, `DELETE FROM `+j.attemptsTable+` WHERE job_id = $1`, j.ID.String()); err != nil {
+			return fmt.Errorf("could not delete job attempts: %w", err)
+		}
+	}
+
 	j.deleted = true
 	return nil
 }
@@ -100,21 +199,105 @@ func (j *Job) Delete(ctx context.Context) error {
 // Done commits transaction that marks job as done. If you got the job from the worker - it will take care of
 // cleaning up the job and resources, no need to do this manually in a WorkFunc.
 func (j *Job) Done(ctx context.Context) error {
+	committed, err := j.done(ctx)
+	for _, fn := range committed {
+		fn()
+	}
+
+	return err
+}
+
+// done commits the job transaction and returns the callbacks to be called after the commit, see Job.onCommit.
+func (j *Job) done(ctx context.Context) ([]func(), error) {
 	j.mu.Lock()
 	defer j.mu.Unlock()
 
 	if j.tx == nil {
 		// already marked as done
-		return nil
+		return nil, nil
+	}
+	defer j.finish()
+
+	if j.batch != nil {
+		err := j.doneBatch(ctx)
+		j.tx = nil
+		return j.takeCommitted(), err
 	}
 
 	if err := j.tx.Commit(ctx); err != nil {
-		return err
+		j.committed = nil
+		return nil, err
 	}
 
 	j.tx = nil
 
-	return nil
+	return j.takeCommitted(), nil
+}
+
+// onCommit registers the callback to be called once the job changes are committed. For the jobs locked together
+// with Client.LockJobs callbacks are called when the shared batch transaction is committed.
+func (j *Job) onCommit(fn func()) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.batch == nil {
+		j.committed = append(j.committed, fn)
+		return
+	}
+
+	j.batch.mu.Lock()
+	j.batch.committed = append(j.batch.committed, fn)
+	j.batch.mu.Unlock()
+}
+
+// takeCommitted returns and resets the callbacks to be called after the commit, must be called with Job.mu held.
+func (j *Job) takeCommitted() []func() {
+	committed := j.committed
+	j.committed = nil
+
+	return committed
+}
+
+// finish calls the job onFinish callback once, must be called with Job.mu held.
+func (j *Job) finish() {
+	if j.onFinish != nil {
+		j.onFinish()
+		j.onFinish = nil
+	}
+}
+
+// release rolls back the job changes and releases the job lock, so that the job is worked again as is.
+// It is used for the jobs interrupted on the worker shutdown.
+func (j *Job) release(ctx context.Context) error {
+	committed, err := j.releaseTx(ctx)
+	for _, fn := range committed {
+		fn()
+	}
+
+	return err
+}
+
+// releaseTx rolls back the job changes, batch transaction is committed when it is the last batch job,
+// in this case the callbacks to be called after the commit are returned, see Job.onCommit.
+func (j *Job) releaseTx(ctx context.Context) ([]func(), error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.tx == nil {
+		return nil, nil
+	}
+	defer j.finish()
+
+	var err error
+	if j.batch != nil {
+		err = j.releaseBatch(ctx)
+	} else {
+		err = j.tx.Rollback(ctx)
+		j.committed = nil
+	}
+	j.tx = nil
+
+	return j.takeCommitted(), err
 }
 
 // Error marks the job as failed and schedules it to be reworked. An error
@@ -125,8 +308,25 @@ func (j *Job) Done(ctx context.Context) error {
 // so calling Done() is not required, although calling it will not cause any issues.
 // If you got the job from the worker - it will take care of cleaning up the job and resources,
 // no need to do this manually in a WorkFunc.
+//
+// For the jobs locked with Client.LockJobs changes made by the job handler within the Job.Tx
+// after the job savepoint was opened by the worker are rolled back.
+//
+// When the attempts log is enabled with WithClientAttemptsLog the failed attempt is recorded
+// in the same transaction.
 func (j *Job) Error(ctx context.Context, jErr error) (err error) {
+	// hooks are fired only after the job changes are committed
+	var (
+		hooks []ErrorHookFunc
+		event JobErrorEvent
+	)
 	defer func() {
+		if err == nil && len(hooks) > 0 {
+			j.onCommit(func() {
+				j.fireErrorHooks(ctx, hooks, event)
+			})
+		}
+
 		doneErr := j.Done(ctx)
 		if doneErr != nil {
 			err = fmt.Errorf("failed to mark job as done (original error: %v): %w", err, doneErr)
@@ -135,38 +335,96 @@ func (j *Job) Error(ctx context.Context, jErr error) (err error) {
 
 	errorCount := j.ErrorCount + 1
 	now := time.Now().UTC()
-	newRunAt := j.calculateErrorRunAt(jErr, now, errorCount)
-	if newRunAt.IsZero() {
+	decision, discardReason := j.errorDecision(jErr, now, errorCount)
+	if decision.Action != ErrorActionDiscard && j.ExpiresAt.Valid && decision.RunAt.After(j.ExpiresAt.Time) {
+		// rescheduled job would expire before it is worked again
+		decision, discardReason = ErrorDecision{Action: ErrorActionDiscard}, DiscardReasonExpired
+	}
+	event = JobErrorEvent{
+		Err:           jErr,
+		Attempt:       errorCount,
+		Action:        decision.Action,
+		NextRunAt:     decision.RunAt,
+		DiscardReason: discardReason,
+	}
+	if decision.Action == ErrorActionDiscard {
 		j.logger.Info(
-			"Got empty new run at for the errored job, discarding it",
+			"Discarding the errored job",
 			adapter.F("job-type", j.Type),
 			adapter.F("job-queue", j.Queue),
 			adapter.F("job-errors", errorCount),
+			adapter.F("discard-reason", discardReason),
 			adapter.Err(jErr),
 		)
-		err = j.Delete(ctx)
+		if err = j.delete(ctx, true); err != nil {
+			return
+		}
+
+		if j.attemptsKept {
+			j.mu.Lock()
+			err = j.logAttempt(ctx, errorCount, jErr, now)
+			j.mu.Unlock()
+		}
+		hooks = j.hooksDiscarded
 		return
 	}
 
-	_, err = j.tx.Exec(
+	j.mu.Lock()
+	err = j.exec(
 		ctx,
-		`UPDATE gue_jobs SET error_count = $1, run_at = $2, last_error = $3, updated_at = $4 WHERE job_id = $5`,
-		errorCount, newRunAt, jErr.Error(), now, j.ID.String(),
+		true,
+		`UPDATE `+j.table+` SET error_count = $1, run_at = $2, last_error = $3, updated_at = $4, aged_run_at = $5
+WHERE job_id = $6`,
+		errorCount, decision.RunAt, jErr.Error(), now, agedRunAt(decision.RunAt, j.Priority, j.priorityAging), j.ID.String(),
 	)
+	if err == nil {
+		err = j.logAttempt(ctx, errorCount, jErr, now)
+	}
+	j.mu.Unlock()
 
+	hooks = j.hooksRetried
 	return err
 }
 
-func (j *Job) calculateErrorRunAt(err error, now time.Time, errorCount int32) time.Time {
-	errReschedule, ok := err.(ErrJobReschedule)
-	if ok {
-		return errReschedule.rescheduleJobAt()
+// exec executes the job statement in the job transaction, must be called with Job.mu held.
+func (j *Job) exec(ctx context.Context, rollback bool, sql string, args ...any) error {
+	if j.batch != nil {
+		return j.execBatch(ctx, rollback, sql, args...)
+	}
+
+	_, err := j.tx.Exec(ctx, sql, args...)
+	return err
+}
+
+// errorDecision decides what to do with the failed job. Typed errors returned by the handler, e.g. ErrDiscardJob,
+// take precedence, the rest of the errors are passed to the ErrorClassifier and retried with the Backoff
+// if the classifier did not make the decision.
+func (j *Job) errorDecision(err error, now time.Time, errorCount int32) (ErrorDecision, DiscardReason) {
+	var errReschedule ErrJobReschedule
+	if errors.As(err, &errReschedule) {
+		runAt := errReschedule.rescheduleJobAt()
+		if runAt.IsZero() {
+			return ErrorDecision{Action: ErrorActionDiscard}, DiscardReasonHandler
+		}
+		return ErrorDecision{Action: ErrorActionReschedule, RunAt: runAt}, ""
+	}
+
+	if j.errorClassifier != nil {
+		switch d := j.errorClassifier(j, err); d.Action {
+		case ErrorActionDiscard:
+			return ErrorDecision{Action: ErrorActionDiscard}, DiscardReasonClassifier
+		case ErrorActionReschedule:
+			if d.RunAt.IsZero() {
+				d.RunAt = now
+			}
+			return d, ""
+		}
 	}
 
-	backoff := j.backoff(int(errorCount))
+	backoff := j.backoff(j, err, int(errorCount))
 	if backoff < 0 {
-		return time.Time{}
+		return ErrorDecision{Action: ErrorActionDiscard}, DiscardReasonBackoff
 	}
 
-	return now.Add(backoff)
+	return ErrorDecision{Action: ErrorActionRetry, RunAt: now.Add(backoff)}, ""
 }
@@ -3,6 +3,7 @@ package gue
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -14,6 +15,37 @@ import (
 	adapterTesting "github.com/vgarvardt/gue/v5/adapter/testing"
 )
 
+func TestJob_waitDuration(t *testing.T) {
+	now := time.Now()
+
+	for name, tc := range map[string]struct {
+		runAt     time.Time
+		createdAt time.Time
+		expected  time.Duration
+	}{
+		"run at": {
+			runAt:     now.Add(-time.Minute),
+			createdAt: now.Add(-time.Hour),
+			expected:  time.Minute,
+		},
+		"run at in the past": {
+			runAt:     now.Add(-time.Hour),
+			createdAt: now.Add(-time.Second),
+			expected:  time.Second,
+		},
+		"run at in the future": {
+			runAt:     now.Add(time.Minute),
+			createdAt: now.Add(-time.Second),
+			expected:  0,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			j := Job{RunAt: tc.runAt, CreatedAt: tc.createdAt}
+			assert.Equal(t, tc.expected, j.waitDuration(now))
+		})
+	}
+}
+
 func TestJob_Tx(t *testing.T) {
 	for name, openFunc := range adapterTesting.AllAdaptersOpenTestPool {
 		t.Run(name, func(t *testing.T) {
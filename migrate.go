@@ -0,0 +1,428 @@
+package gue
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/vgarvardt/gue/v5/adapter"
+)
+
+// LatestSchemaVersion is the DB schema version current library version expects to work with.
+// It is the version of the latest embedded migration.
+const LatestSchemaVersion = 11
+
+// ErrSchemaVersionMismatch is returned when the DB schema version does not match LatestSchemaVersion.
+var ErrSchemaVersionMismatch = errors.New("gue DB schema version does not match library version")
+
+//go:embed migrations/versions/*.sql
+var migrationsFS embed.FS
+
+// partitionJobsTableSQL is the template of the jobs table partitioning step, it creates the jobs table
+// of the latest schema version, so it must be updated along with the migrations changing the jobs table.
+//
+//go:embed migrations/partition_jobs_table.sql
+var partitionJobsTableSQL string
+
+type migration struct {
+	version int
+	name    string
+	tpl     *template.Template
+}
+
+// migrationTemplateData is the data migration templates are being rendered with.
+type migrationTemplateData struct {
+	// JobsTable is the quoted and optionally schema-qualified jobs table name.
+	JobsTable string
+	// PartitionBy is the jobs table partition key column, empty for the regular table, see Partitioning.
+	PartitionBy Partitioning
+
+	schema string
+	table  string
+}
+
+// Index returns quoted index name for the jobs table, index name contains table name to avoid collisions
+// when several installations share the same schema.
+func (d migrationTemplateData) Index(name string) string {
+	return quoteIdentifier("idx_" + d.table + "_" + name)
+}
+
+// QualifiedIndex returns quoted and optionally schema-qualified index name for the jobs table, see Index.
+// Index is created in the jobs table schema, so the qualified name is needed to refer to the existing index.
+func (d migrationTemplateData) QualifiedIndex(name string) string {
+	return qualifiedIdentifier(d.schema, "idx_"+d.table+"_"+name)
+}
+
+// Partition returns quoted and optionally schema-qualified name of the jobs table partition.
+func (d migrationTemplateData) Partition(suffix string) string {
+	return qualifiedIdentifier(d.schema, d.table+"_"+suffix)
+}
+
+// Table returns quoted and optionally schema-qualified name of the table related to the jobs table,
+// see relatedTableName for the naming rules.
+func (d migrationTemplateData) Table(suffix string) string {
+	return qualifiedIdentifier(d.schema, relatedTableName(d.table, suffix))
+}
+
+// Migrate applies embedded schema migrations that were not yet applied to the DB. Applied migrations are tracked
+// in the schema version table that lives next to the jobs table. All the migrations are applied in a single
+// transaction holding transaction-level advisory lock, so it is safe to call Migrate concurrently, e.g. on startup
+// of the several application instances.
+//
+// Use WithMigrateTableName and WithMigrateSchema options when client is configured with the custom jobs table name
+// and schema, WithMigratePartitioning to partition the jobs table, and WithMigrateDryRun to get the SQL
+// that would be applied without actually running it. Partitioning is not a versioned migration, it is applied
+// after all the migrations as a separate step every time it is requested and the jobs table is not partitioned yet.
+func Migrate(ctx context.Context, pool adapter.ConnPool, options ...MigrateOption) (err error) {
+	m := migrator{
+		pool:   pool,
+		table:  defaultTableName,
+		logger: adapter.NoOpLogger{},
+	}
+
+	for _, option := range options {
+		option(&m)
+	}
+
+	if m.table == "" {
+		return errors.New("table name must not be empty")
+	}
+	if err := m.partitioning.validate(); err != nil {
+		return err
+	}
+
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+
+	if m.dryRun != nil {
+		return m.writeDryRun(ctx, migrations)
+	}
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("could not begin migrations transaction: %w", err)
+	}
+	defer func() {
+		if err == nil {
+			err = tx.Commit(ctx)
+			return
+		}
+
+		if rbErr := tx.Rollback(ctx); rbErr != nil {
+			m.logger.Error("Could not properly rollback migrations transaction", adapter.Err(rbErr))
+		}
+	}()
+
+	if _, err = tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, m.lockKey()); err != nil {
+		return fmt.Errorf("could not acquire migrations lock: %w", err)
+	}
+
+	if m.schema != "" {
+		if _, err = tx.Exec(ctx, `CREATE SCHEMA IF NOT EXISTS `+quoteIdentifier(m.schema)); err != nil {
+			return fmt.Errorf("could not create schema: %w", err)
+		}
+	}
+
+	if _, err = tx.Exec(ctx, m.versionTableSQL()); err != nil {
+		return fmt.Errorf("could not create schema version table: %w", err)
+	}
+
+	current, err := schemaVersion(ctx, tx, m.versionTable())
+	if err != nil {
+		return err
+	}
+
+	for _, mig := range migrations {
+		if mig.version <= current {
+			continue
+		}
+
+		migrationSQL, err := mig.render(m.templateData())
+		if err != nil {
+			return err
+		}
+
+		// migration may render to nothing, e.g. the partitioned jobs table one that is replaced by the partitioning step
+		if strings.TrimSpace(migrationSQL) != "" {
+			if _, err := tx.Exec(ctx, migrationSQL); err != nil {
+				return fmt.Errorf("could not apply migration %d (%s): %w", mig.version, mig.name, err)
+			}
+		}
+
+		if _, err := tx.Exec(
+			ctx,
+			`INSERT INTO `+m.versionTable()+` (version, name, applied_at) VALUES ($1, $2, $3)`,
+			mig.version, mig.name, time.Now().UTC(),
+		); err != nil {
+			return fmt.Errorf("could not store applied migration %d version: %w", mig.version, err)
+		}
+
+		m.logger.Info("Applied gue migration", adapter.F("version", mig.version), adapter.F("name", mig.name))
+	}
+
+	return m.partition(ctx, tx)
+}
+
+// DBSchemaVersion returns the version of the gue schema applied to the DB with Migrate.
+// Zero version is returned when no migrations were applied yet.
+func (c *Client) DBSchemaVersion(ctx context.Context) (int, error) {
+	versionTable := qualifiedIdentifier(c.schema, relatedTableName(c.table, "schema_migrations"))
+
+	var exists bool
+	if err := c.pool.QueryRow(ctx, `SELECT to_regclass($1) IS NOT NULL`, versionTable).Scan(&exists); err != nil {
+		return 0, fmt.Errorf("could not check schema version table existence: %w", err)
+	}
+	if !exists {
+		return 0, nil
+	}
+
+	return schemaVersion(ctx, c.pool, versionTable)
+}
+
+func (c *Client) verifySchemaVersion(ctx context.Context) error {
+	version, err := c.DBSchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	if version != LatestSchemaVersion {
+		return fmt.Errorf("%w: DB version is %d, expected %d", ErrSchemaVersionMismatch, version, LatestSchemaVersion)
+	}
+
+	return nil
+}
+
+type migrator struct {
+	pool         adapter.ConnPool
+	schema       string
+	table        string
+	partitioning Partitioning
+	dryRun       io.Writer
+	logger       adapter.Logger
+}
+
+// templateData returns the data versioned migrations are rendered with. Versioned migrations are always rendered
+// for the regular jobs table, the requested partitioning is applied by the separate step, see partitionSQL.
+func (m *migrator) templateData() migrationTemplateData {
+	return migrationTemplateData{
+		JobsTable: qualifiedIdentifier(m.schema, m.table),
+		schema:    m.schema,
+		table:     m.table,
+	}
+}
+
+func (m *migrator) versionTable() string {
+	return qualifiedIdentifier(m.schema, relatedTableName(m.table, "schema_migrations"))
+}
+
+func (m *migrator) versionTableSQL() string {
+	return `CREATE TABLE IF NOT EXISTS ` + m.versionTable() + `
+(
+  version    INTEGER     NOT NULL PRIMARY KEY,
+  name       TEXT        NOT NULL,
+  applied_at TIMESTAMPTZ NOT NULL
+)`
+}
+
+func (m *migrator) lockKey() string {
+	return "gue-migrate:" + qualifiedIdentifier(m.schema, m.table)
+}
+
+// writeDryRun writes SQL statements of the migrations that are not yet applied to the dry-run writer.
+func (m *migrator) writeDryRun(ctx context.Context, migrations []migration) error {
+	var exists bool
+	if err := m.pool.QueryRow(ctx, `SELECT to_regclass($1) IS NOT NULL`, m.versionTable()).Scan(&exists); err != nil {
+		return fmt.Errorf("could not check schema version table existence: %w", err)
+	}
+
+	current := 0
+	if exists {
+		var err error
+		if current, err = schemaVersion(ctx, m.pool, m.versionTable()); err != nil {
+			return err
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	if m.schema != "" {
+		fmt.Fprintf(buf, "CREATE SCHEMA IF NOT EXISTS %s;\n\n", quoteIdentifier(m.schema))
+	}
+	fmt.Fprintf(buf, "%s;\n", m.versionTableSQL())
+
+	for _, mig := range migrations {
+		if mig.version <= current {
+			continue
+		}
+
+		migrationSQL, err := mig.render(m.templateData())
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(buf, "\n-- migration %d: %s\n", mig.version, mig.name)
+		if migrationSQL = strings.TrimSpace(migrationSQL); migrationSQL != "" {
+			fmt.Fprintf(buf, "%s\n", migrationSQL)
+		}
+		fmt.Fprintf(
+			buf,
+			"INSERT INTO %s (version, name, applied_at) VALUES (%d, '%s', now());\n",
+			m.versionTable(), mig.version, mig.name,
+		)
+	}
+
+	partitionSQL, err := m.partitionSQL(ctx, m.pool)
+	if err != nil {
+		return err
+	}
+	if partitionSQL != "" {
+		fmt.Fprintf(buf, "\n-- partition jobs table by %s\n%s\n", m.partitioning, partitionSQL)
+	}
+
+	_, err = m.dryRun.Write(buf.Bytes())
+	return err
+}
+
+// partition applies the requested partitioning to the jobs table created by the migrations. Jobs table is locked
+// before the check, so that the jobs can not be added to it in between.
+func (m *migrator) partition(ctx context.Context, tx adapter.Tx) error {
+	if m.partitioning == "" {
+		return nil
+	}
+
+	jobsTable := qualifiedIdentifier(m.schema, m.table)
+	if _, err := tx.Exec(ctx, `LOCK TABLE `+jobsTable+` IN ACCESS EXCLUSIVE MODE`); err != nil {
+		return fmt.Errorf("could not lock jobs table %s: %w", jobsTable, err)
+	}
+
+	partitionSQL, err := m.partitionSQL(ctx, tx)
+	if err != nil || partitionSQL == "" {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, partitionSQL); err != nil {
+		return fmt.Errorf("could not partition jobs table %s by %s: %w", jobsTable, m.partitioning, err)
+	}
+
+	m.logger.Info("Partitioned gue jobs table", adapter.F("partitioning", string(m.partitioning)))
+	return nil
+}
+
+// partitionSQL returns the SQL converting the jobs table to the requested partitioning, it returns empty string
+// when no partitioning is requested or the jobs table is partitioned already. Only the empty or not yet existing
+// jobs table can be partitioned, as it is recreated, and the partitioned table can not be repartitioned.
+func (m *migrator) partitionSQL(ctx context.Context, q adapter.Queryable) (string, error) {
+	if m.partitioning == "" {
+		return "", nil
+	}
+
+	jobsTable := qualifiedIdentifier(m.schema, m.table)
+	exists, partitioning, err := tablePartitioning(ctx, q, jobsTable)
+	if err != nil {
+		return "", err
+	}
+	if partitioning == m.partitioning {
+		return "", nil
+	}
+	if partitioning != "" {
+		return "", fmt.Errorf("could not partition jobs table %s by %s: table is partitioned by %s already",
+			jobsTable, m.partitioning, partitioning)
+	}
+
+	if exists {
+		var hasJobs bool
+		if err := q.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM `+jobsTable+`)`).Scan(&hasJobs); err != nil {
+			return "", fmt.Errorf("could not check jobs table %s is empty: %w", jobsTable, err)
+		}
+		if hasJobs {
+			return "", fmt.Errorf(
+				"could not partition jobs table %s by %s: table is not empty, only the empty jobs table can be partitioned",
+				jobsTable, m.partitioning,
+			)
+		}
+	}
+
+	tpl, err := template.New("partition_jobs_table").Parse(partitionJobsTableSQL)
+	if err != nil {
+		return "", fmt.Errorf("could not parse jobs table partitioning: %w", err)
+	}
+
+	data := m.templateData()
+	data.PartitionBy = m.partitioning
+
+	buf := new(bytes.Buffer)
+	if err := tpl.Execute(buf, data); err != nil {
+		return "", fmt.Errorf("could not render jobs table partitioning: %w", err)
+	}
+
+	return strings.TrimSpace(buf.String()), nil
+}
+
+func (mig migration) render(data migrationTemplateData) (string, error) {
+	buf := new(bytes.Buffer)
+	if err := mig.tpl.Execute(buf, data); err != nil {
+		return "", fmt.Errorf("could not render migration %d (%s): %w", mig.version, mig.name, err)
+	}
+
+	return buf.String(), nil
+}
+
+func schemaVersion(ctx context.Context, q adapter.Queryable, versionTable string) (int, error) {
+	var version int
+	if err := q.QueryRow(ctx, `SELECT COALESCE(MAX(version), 0) FROM `+versionTable).Scan(&version); err != nil {
+		return 0, fmt.Errorf("could not get current schema version: %w", err)
+	}
+
+	return version, nil
+}
+
+// loadMigrations reads embedded migrations sorted by version. Migration file name has the following format:
+// <version>_<name_with_underscores>.sql, e.g. 0001_create_jobs_table.sql.
+func loadMigrations() ([]migration, error) {
+	entries, err := fs.ReadDir(migrationsFS, "migrations/versions")
+	if err != nil {
+		return nil, fmt.Errorf("could not read embedded migrations: %w", err)
+	}
+
+	migrations := make([]migration, 0, len(entries))
+	for _, entry := range entries {
+		fileName := entry.Name()
+		versionStr, name, found := strings.Cut(strings.TrimSuffix(fileName, ".sql"), "_")
+		if !found {
+			return nil, fmt.Errorf("invalid migration file name %q", fileName)
+		}
+
+		version, err := strconv.Atoi(versionStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration file name %q: %w", fileName, err)
+		}
+
+		tpl, err := template.ParseFS(migrationsFS, path.Join("migrations/versions", fileName))
+		if err != nil {
+			return nil, fmt.Errorf("could not parse migration %q: %w", fileName, err)
+		}
+
+		migrations = append(migrations, migration{
+			version: version,
+			name:    strings.ReplaceAll(name, "_", " "),
+			tpl:     tpl,
+		})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].version < migrations[j].version
+	})
+
+	return migrations, nil
+}
@@ -0,0 +1,50 @@
+package gue
+
+import (
+	"io"
+
+	"github.com/vgarvardt/gue/v5/adapter"
+)
+
+// MigrateOption defines a type that allows to set migration properties.
+type MigrateOption func(*migrator)
+
+// WithMigrateTableName sets the jobs table name migrations are applied for, see WithClientTableName.
+func WithMigrateTableName(table string) MigrateOption {
+	return func(m *migrator) {
+		m.table = table
+	}
+}
+
+// WithMigrateSchema sets the schema migrations are applied in, see WithClientSchema.
+// Schema is created if it does not exist yet.
+func WithMigrateSchema(schema string) MigrateOption {
+	return func(m *migrator) {
+		m.schema = schema
+	}
+}
+
+// WithMigratePartitioning converts the jobs table to the natively partitioned table with the given partitioning,
+// see Partitioning. Jobs table is recreated, so only the new or empty one can be partitioned, Migrate fails when
+// the jobs table holds jobs or is partitioned already the other way. Partitioning is applied after all
+// the migrations, so it can be requested for the existing installation as well.
+func WithMigratePartitioning(p Partitioning) MigrateOption {
+	return func(m *migrator) {
+		m.partitioning = p
+	}
+}
+
+// WithMigrateDryRun enables dry-run mode - SQL statements of the migrations that are not yet applied
+// are written to w instead of being executed.
+func WithMigrateDryRun(w io.Writer) MigrateOption {
+	return func(m *migrator) {
+		m.dryRun = w
+	}
+}
+
+// WithMigrateLogger sets Logger implementation to migrations routine.
+func WithMigrateLogger(logger adapter.Logger) MigrateOption {
+	return func(m *migrator) {
+		m.logger = logger
+	}
+}
@@ -0,0 +1,249 @@
+package gue
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vgarvardt/gue/v5/adapter"
+	adapterTesting "github.com/vgarvardt/gue/v5/adapter/testing"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := loadMigrations()
+	require.NoError(t, err)
+	require.Len(t, migrations, LatestSchemaVersion)
+
+	for i, mig := range migrations {
+		assert.Equal(t, i+1, mig.version)
+		assert.NotEmpty(t, mig.name)
+
+		for _, p := range []Partitioning{"", PartitionByQueue, PartitionByCreatedAt} {
+			_, err := mig.render(migrationTemplateData{JobsTable: `"gue_jobs"`, PartitionBy: p, table: "gue_jobs"})
+			assert.NoError(t, err)
+		}
+	}
+}
+
+func TestMigrations_immutable(t *testing.T) {
+	// applied migrations are never reapplied, so the released ones must not be changed, add the new version instead
+	checksums := map[string]string{
+		"0001_create_jobs_table.sql":                     "3cc747d4fb9ac900112810fa23b24d7c411ecdd5799f0f60371a13964255b601",
+		"0002_create_paused_table.sql":                   "e1e5a4c63c49331e2a13b968c69292cf28444da4f3debff02d4233569ca2ce05",
+		"0003_create_workers_table.sql":                  "b22dd43ffebcd6d90427dbb4dd58acfddbe8ac8d151335696a6e85219cdbc87c",
+		"0004_create_attempts_table.sql":                 "a0ea0293603a28b0b8f4b430cc244ddef8d27c9a36c8bca6e7bfecf31c2407db",
+		"0005_add_jobs_backoff_name.sql":                 "e996eacc456a56384204169c1ba36019119220d3269e5fc7568d14c8a4d7a02f",
+		"0006_add_jobs_expires_at.sql":                   "a4359b1f135293a4f5749899db0ecf5b3663a928062b4adaca522e2e00865a0d",
+		"0007_add_jobs_unique_key.sql":                   "fef63f53b1318fe5628930c7771fc789ec76ab7471007771223d14ed29c56fa1",
+		"0008_add_jobs_aged_run_at.sql":                  "04b8b76f632c97fd8677ffbab8b7997a15cbf4276a6cb1616544d4ee9ce77164",
+		"0009_add_jobs_fairness_key.sql":                 "32e89ebcf84de12c60fbb3c9e30b63b4123016286b73131b1a513fc6e290b882",
+		"0010_create_partitioned_jobs_table.sql":         "e78ef173d4824c63cf4400b7509853489ee0fd94d6cf76a2c2a80deab27730db",
+		"0011_make_jobs_unique_key_index_non_unique.sql": "fcfc451b874e10734b8584e7fa6d29f8c2af1ee168e9920233010fe1d248d569",
+	}
+
+	entries, err := fs.ReadDir(migrationsFS, "migrations/versions")
+	require.NoError(t, err)
+	require.Len(t, entries, len(checksums), "add the checksum of the new migration")
+
+	for _, entry := range entries {
+		content, err := fs.ReadFile(migrationsFS, "migrations/versions/"+entry.Name())
+		require.NoError(t, err)
+
+		sum := sha256.Sum256(content)
+		assert.Equal(t, checksums[entry.Name()], hex.EncodeToString(sum[:]), entry.Name())
+	}
+}
+
+func TestMigrate_DryRun(t *testing.T) {
+	ctx := context.Background()
+
+	row := new(adapterTesting.Row)
+	row.On("Scan", mock.Anything).Return(nil)
+
+	pool := new(adapterTesting.ConnPool)
+	pool.Queryable.On("QueryRow", ctx, mock.Anything, mock.Anything).Return(row)
+
+	buf := new(bytes.Buffer)
+	err := Migrate(ctx, pool, WithMigrateDryRun(buf), WithMigrateSchema("tenant"), WithMigrateTableName("my_jobs"))
+	require.NoError(t, err)
+
+	out := buf.String()
+	assert.Contains(t, out, `CREATE SCHEMA IF NOT EXISTS "tenant";`)
+	assert.Contains(t, out, `CREATE TABLE IF NOT EXISTS "tenant"."my_schema_migrations"`)
+	assert.Contains(t, out, `CREATE TABLE IF NOT EXISTS "tenant"."my_jobs"`)
+	assert.Contains(t, out, `CREATE INDEX IF NOT EXISTS "idx_my_jobs_selector" ON "tenant"."my_jobs"`)
+	assert.Contains(t, out, `INSERT INTO "tenant"."my_schema_migrations" (version, name, applied_at) VALUES (1,`)
+
+	pool.AssertNotCalled(t, "Begin", mock.Anything)
+	pool.Queryable.AssertNotCalled(t, "Exec", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestMigrate_DryRunPartitioning(t *testing.T) {
+	ctx := context.Background()
+
+	// newPool creates the mocked pool with no migrations applied and the jobs table of the given layout
+	newPool := func(exists bool, partitioning Partitioning, hasJobs bool) *adapterTesting.ConnPool {
+		partitioningRow := new(adapterTesting.Row)
+		if exists {
+			partitioningRow.On("Scan", mock.Anything).Run(func(args mock.Arguments) {
+				*args.Get(0).(*string) = string(partitioning)
+			}).Return(nil)
+		} else {
+			partitioningRow.On("Scan", mock.Anything).Return(adapter.ErrNoRows)
+		}
+
+		hasJobsRow := new(adapterTesting.Row)
+		hasJobsRow.On("Scan", mock.Anything).Run(func(args mock.Arguments) {
+			*args.Get(0).(*bool) = hasJobs
+		}).Return(nil)
+
+		row := new(adapterTesting.Row)
+		row.On("Scan", mock.Anything).Return(nil)
+
+		pool := new(adapterTesting.ConnPool)
+		pool.Queryable.On("QueryRow", ctx, mock.MatchedBy(func(sql string) bool {
+			return strings.Contains(sql, "pg_partitioned_table")
+		}), mock.Anything).Return(partitioningRow)
+		pool.Queryable.On("QueryRow", ctx, `SELECT EXISTS (SELECT 1 FROM "gue_jobs")`, mock.Anything).Return(hasJobsRow)
+		pool.Queryable.On("QueryRow", ctx, mock.Anything, mock.Anything).Return(row)
+
+		return pool
+	}
+
+	for name, tc := range map[string]struct {
+		partitioning Partitioning
+		contains     []string
+	}{
+		"queue": {
+			partitioning: PartitionByQueue,
+			contains: []string{
+				`PRIMARY KEY (job_id, queue)
+) PARTITION BY LIST (queue);`,
+				`CREATE TABLE "gue_jobs_default" PARTITION OF "gue_jobs" DEFAULT;`,
+			},
+		},
+		"created_at": {
+			partitioning: PartitionByCreatedAt,
+			contains: []string{
+				`PRIMARY KEY (job_id, created_at)
+) PARTITION BY RANGE (created_at);`,
+				`CREATE TABLE "gue_jobs_default" PARTITION OF "gue_jobs" DEFAULT;`,
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			err := Migrate(ctx, newPool(false, "", false), WithMigrateDryRun(buf), WithMigratePartitioning(tc.partitioning))
+			require.NoError(t, err)
+
+			out := buf.String()
+			for _, s := range tc.contains {
+				assert.Contains(t, out, s)
+			}
+			// versioned migrations are rendered for the regular table, partitioning is the separate step after them
+			assert.Contains(t, out, `job_id      TEXT        NOT NULL PRIMARY KEY,`)
+			assert.Contains(t, out, "-- migration 10: create partitioned jobs table\nINSERT INTO")
+			assert.Contains(t, out, "-- partition jobs table by "+string(tc.partitioning)+"\nDROP TABLE \"gue_jobs\";")
+			assert.Contains(t, out, `CREATE INDEX "idx_gue_jobs_unique_key" ON "gue_jobs" (job_type, unique_key)`)
+		})
+	}
+
+	t.Run("regular", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		err := Migrate(ctx, newPool(false, "", false), WithMigrateDryRun(buf))
+		require.NoError(t, err)
+		assert.NotContains(t, buf.String(), `PARTITION`)
+		assert.Contains(t, buf.String(), "-- migration 10: create partitioned jobs table\nINSERT INTO")
+	})
+
+	t.Run("existing regular table", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		err := Migrate(ctx, newPool(true, "", false), WithMigrateDryRun(buf), WithMigratePartitioning(PartitionByQueue))
+		require.NoError(t, err)
+		assert.Contains(t, buf.String(), "-- partition jobs table by queue\n")
+
+		err = Migrate(ctx, newPool(true, "", true), WithMigrateDryRun(new(bytes.Buffer)), WithMigratePartitioning(PartitionByQueue))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "only the empty jobs table can be partitioned")
+	})
+
+	t.Run("existing partitioned table", func(t *testing.T) {
+		err := Migrate(
+			ctx, newPool(true, PartitionByQueue, false), WithMigrateDryRun(new(bytes.Buffer)),
+			WithMigratePartitioning(PartitionByCreatedAt),
+		)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "table is partitioned by queue already")
+
+		buf := new(bytes.Buffer)
+		err = Migrate(ctx, newPool(true, PartitionByQueue, true), WithMigrateDryRun(buf), WithMigratePartitioning(PartitionByQueue))
+		require.NoError(t, err)
+		assert.NotContains(t, buf.String(), "-- partition jobs table")
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		err := Migrate(ctx, newPool(false, "", false), WithMigrateDryRun(new(bytes.Buffer)), WithMigratePartitioning("job_type"))
+		require.Error(t, err)
+	})
+}
+
+func TestMigrate(t *testing.T) {
+	for name, openFunc := range adapterTesting.AllAdaptersOpenTestPool {
+		t.Run(name, func(t *testing.T) {
+			testMigrate(t, openFunc(t))
+		})
+	}
+}
+
+func testMigrate(t *testing.T, connPool adapter.ConnPool) {
+	ctx := context.Background()
+
+	schema := "gue_migrate_" + RandomStringID()
+	t.Cleanup(func() {
+		_, err := connPool.Exec(ctx, "DROP SCHEMA IF EXISTS "+schema+" CASCADE")
+		assert.NoError(t, err)
+	})
+
+	_, err := NewClient(connPool, WithClientSchema(schema), WithClientSchemaVersionCheck())
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrSchemaVersionMismatch))
+
+	// migrations are idempotent, so it is safe to run them several times
+	for i := 0; i < 2; i++ {
+		err = Migrate(ctx, connPool, WithMigrateSchema(schema))
+		require.NoError(t, err)
+	}
+
+	c, err := NewClient(connPool, WithClientSchema(schema), WithClientSchemaVersionCheck())
+	require.NoError(t, err)
+
+	version, err := c.DBSchemaVersion(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, LatestSchemaVersion, version)
+
+	buf := new(bytes.Buffer)
+	err = Migrate(ctx, connPool, WithMigrateSchema(schema), WithMigrateDryRun(buf))
+	require.NoError(t, err)
+	assert.NotContains(t, buf.String(), "-- migration")
+
+	err = c.Enqueue(ctx, &Job{Type: "MyJob"})
+	require.NoError(t, err)
+
+	j, err := c.LockJob(ctx, "")
+	require.NoError(t, err)
+	require.NotNil(t, j)
+
+	err = j.Delete(ctx)
+	require.NoError(t, err)
+	err = j.Done(ctx)
+	require.NoError(t, err)
+}
@@ -1,15 +1,59 @@
 CREATE TABLE IF NOT EXISTS gue_jobs
 (
-  job_id      TEXT        NOT NULL PRIMARY KEY,
-  priority    SMALLINT    NOT NULL,
-  run_at      TIMESTAMPTZ NOT NULL,
-  job_type    TEXT        NOT NULL,
-  args        BYTEA       NOT NULL,
-  error_count INTEGER     NOT NULL DEFAULT 0,
-  last_error  TEXT,
-  queue       TEXT        NOT NULL,
-  created_at  TIMESTAMPTZ NOT NULL,
-  updated_at  TIMESTAMPTZ NOT NULL
+  job_id       TEXT        NOT NULL PRIMARY KEY,
+  priority     SMALLINT    NOT NULL,
+  run_at       TIMESTAMPTZ NOT NULL,
+  job_type     TEXT        NOT NULL,
+  args         BYTEA       NOT NULL,
+  error_count  INTEGER     NOT NULL DEFAULT 0,
+  last_error   TEXT,
+  queue        TEXT        NOT NULL,
+  created_at   TIMESTAMPTZ NOT NULL,
+  updated_at   TIMESTAMPTZ NOT NULL,
+  backoff_name TEXT        NOT NULL DEFAULT '',
+  expires_at   TIMESTAMPTZ,
+  unique_key   TEXT,
+  aged_run_at  TIMESTAMPTZ,
+  fairness_key TEXT        NOT NULL DEFAULT ''
 );
 
 CREATE INDEX IF NOT EXISTS idx_gue_jobs_selector ON gue_jobs (queue, run_at, priority);
+CREATE INDEX IF NOT EXISTS idx_gue_jobs_aged_selector ON gue_jobs (queue, (COALESCE(aged_run_at, run_at)), priority);
+CREATE INDEX IF NOT EXISTS idx_gue_jobs_fairness_selector ON gue_jobs (queue, fairness_key, run_at, priority);
+CREATE INDEX IF NOT EXISTS idx_gue_jobs_unique_key ON gue_jobs (job_type, unique_key) WHERE unique_key IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS gue_paused
+(
+  kind      TEXT        NOT NULL,
+  name      TEXT        NOT NULL,
+  paused_at TIMESTAMPTZ NOT NULL,
+  PRIMARY KEY (kind, name)
+);
+
+CREATE TABLE IF NOT EXISTS gue_workers
+(
+  worker_id     TEXT        NOT NULL PRIMARY KEY,
+  host          TEXT        NOT NULL,
+  pid           INTEGER     NOT NULL,
+  queue         TEXT        NOT NULL,
+  started_at    TIMESTAMPTZ NOT NULL,
+  heartbeat_at  TIMESTAMPTZ NOT NULL,
+  expires_at    TIMESTAMPTZ NOT NULL,
+  job_id        TEXT,
+  job_type      TEXT,
+  job_locked_at TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS gue_attempts
+(
+  job_id      TEXT        NOT NULL,
+  attempt     INTEGER     NOT NULL,
+  worker_id   TEXT        NOT NULL,
+  started_at  TIMESTAMPTZ NOT NULL,
+  finished_at TIMESTAMPTZ NOT NULL,
+  error       TEXT        NOT NULL,
+  panic_stack TEXT,
+  PRIMARY KEY (job_id, attempt)
+);
+
+CREATE INDEX IF NOT EXISTS idx_gue_jobs_attempts_finished_at ON gue_attempts (finished_at);
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS {{ .JobsTable }}
+(
+  job_id      TEXT        NOT NULL PRIMARY KEY,
+  priority    SMALLINT    NOT NULL,
+  run_at      TIMESTAMPTZ NOT NULL,
+  job_type    TEXT        NOT NULL,
+  args        BYTEA       NOT NULL,
+  error_count INTEGER     NOT NULL DEFAULT 0,
+  last_error  TEXT,
+  queue       TEXT        NOT NULL,
+  created_at  TIMESTAMPTZ NOT NULL,
+  updated_at  TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS {{ .Index "selector" }} ON {{ .JobsTable }} (queue, run_at, priority);
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS {{ .Table "paused" }}
+(
+  kind      TEXT        NOT NULL,
+  name      TEXT        NOT NULL,
+  paused_at TIMESTAMPTZ NOT NULL,
+  PRIMARY KEY (kind, name)
+);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS {{ .Table "workers" }}
+(
+  worker_id     TEXT        NOT NULL PRIMARY KEY,
+  host          TEXT        NOT NULL,
+  pid           INTEGER     NOT NULL,
+  queue         TEXT        NOT NULL,
+  started_at    TIMESTAMPTZ NOT NULL,
+  heartbeat_at  TIMESTAMPTZ NOT NULL,
+  expires_at    TIMESTAMPTZ NOT NULL,
+  job_id        TEXT,
+  job_type      TEXT,
+  job_locked_at TIMESTAMPTZ
+);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS {{ .Table "attempts" }}
+(
+  job_id      TEXT        NOT NULL,
+  attempt     INTEGER     NOT NULL,
+  worker_id   TEXT        NOT NULL,
+  started_at  TIMESTAMPTZ NOT NULL,
+  finished_at TIMESTAMPTZ NOT NULL,
+  error       TEXT        NOT NULL,
+  panic_stack TEXT,
+  PRIMARY KEY (job_id, attempt)
+);
+
+CREATE INDEX IF NOT EXISTS {{ .Index "attempts_finished_at" }} ON {{ .Table "attempts" }} (finished_at);
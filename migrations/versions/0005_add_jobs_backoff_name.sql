@@ -0,0 +1 @@
+ALTER TABLE {{ .JobsTable }} ADD COLUMN IF NOT EXISTS backoff_name TEXT NOT NULL DEFAULT '';
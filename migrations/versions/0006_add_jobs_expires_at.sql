@@ -0,0 +1 @@
+ALTER TABLE {{ .JobsTable }} ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
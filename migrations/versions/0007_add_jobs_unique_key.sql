@@ -0,0 +1,4 @@
+ALTER TABLE {{ .JobsTable }} ADD COLUMN IF NOT EXISTS unique_key TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS {{ .Index "unique_key" }} ON {{ .JobsTable }} (job_type, unique_key)
+  WHERE unique_key IS NOT NULL;
@@ -0,0 +1,3 @@
+ALTER TABLE {{ .JobsTable }} ADD COLUMN IF NOT EXISTS aged_run_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS {{ .Index "aged_selector" }} ON {{ .JobsTable }} (queue, (COALESCE(aged_run_at, run_at)), priority);
@@ -0,0 +1,3 @@
+ALTER TABLE {{ .JobsTable }} ADD COLUMN IF NOT EXISTS fairness_key TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS {{ .Index "fairness_selector" }} ON {{ .JobsTable }} (queue, fairness_key, run_at, priority);
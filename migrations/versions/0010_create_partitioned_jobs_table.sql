@@ -0,0 +1,36 @@
+{{- if .PartitionBy -}}
+DO $$
+BEGIN
+  IF EXISTS (SELECT 1 FROM {{ .JobsTable }}) THEN
+    RAISE EXCEPTION 'gue jobs table is not empty, only the new jobs table can be partitioned';
+  END IF;
+END $$;
+
+DROP TABLE {{ .JobsTable }};
+
+CREATE TABLE {{ .JobsTable }}
+(
+  job_id       TEXT        NOT NULL,
+  priority     SMALLINT    NOT NULL,
+  run_at       TIMESTAMPTZ NOT NULL,
+  job_type     TEXT        NOT NULL,
+  args         BYTEA       NOT NULL,
+  error_count  INTEGER     NOT NULL DEFAULT 0,
+  last_error   TEXT,
+  queue        TEXT        NOT NULL,
+  created_at   TIMESTAMPTZ NOT NULL,
+  updated_at   TIMESTAMPTZ NOT NULL,
+  backoff_name TEXT        NOT NULL DEFAULT '',
+  expires_at   TIMESTAMPTZ,
+  unique_key   TEXT,
+  aged_run_at  TIMESTAMPTZ,
+  fairness_key TEXT        NOT NULL DEFAULT '',
+  PRIMARY KEY (job_id, {{ .PartitionBy }})
+) PARTITION BY {{ if eq .PartitionBy "queue" }}LIST (queue){{ else }}RANGE (created_at){{ end }};
+
+CREATE TABLE {{ .Partition "default" }} PARTITION OF {{ .JobsTable }} DEFAULT;
+
+CREATE INDEX {{ .Index "selector" }} ON {{ .JobsTable }} (queue, run_at, priority);
+CREATE INDEX {{ .Index "aged_selector" }} ON {{ .JobsTable }} (queue, (COALESCE(aged_run_at, run_at)), priority);
+CREATE INDEX {{ .Index "fairness_selector" }} ON {{ .JobsTable }} (queue, fairness_key, run_at, priority);
+{{- end }}
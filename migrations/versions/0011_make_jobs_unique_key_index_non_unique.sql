@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS {{ .QualifiedIndex "unique_key" }};
+
+CREATE INDEX IF NOT EXISTS {{ .Index "unique_key" }} ON {{ .JobsTable }} (job_type, unique_key)
+  WHERE unique_key IS NOT NULL;
@@ -0,0 +1,224 @@
+package gue
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/vgarvardt/gue/v5/adapter"
+)
+
+// defaultPartitionPeriod is the default time range of the jobs table partition, see WithClientPartitionPeriod.
+const defaultPartitionPeriod = 24 * time.Hour
+
+// partitionTimeFormat is the format of the time range partition start in the partition name.
+const partitionTimeFormat = "20060102T1504"
+
+// Partitioning is the jobs table native Postgres partitioning layout, see WithMigratePartitioning.
+// Zero value is the regular not partitioned table.
+type Partitioning string
+
+const (
+	// PartitionByQueue partitions the jobs table by the list of queues, every queue gets its own partition created
+	// with Client.CreateQueuePartition, jobs of the queues without the partition are stored in the default partition.
+	PartitionByQueue Partitioning = "queue"
+	// PartitionByCreatedAt partitions the jobs table by the job creation time range, partitions are created ahead
+	// with Client.CreateJobPartitions and old ones are removed with Client.DetachJobPartitions or
+	// Client.DropJobPartitions, so the dead rows of the deleted jobs are reclaimed with the whole partition
+	// instead of being vacuumed.
+	PartitionByCreatedAt Partitioning = "created_at"
+)
+
+func (p Partitioning) validate() error {
+	switch p {
+	case "", PartitionByQueue, PartitionByCreatedAt:
+		return nil
+	}
+
+	return fmt.Errorf("unknown jobs table partitioning: %q", string(p))
+}
+
+// tablePartitioning returns the partitioning of the table, it returns false if the table does not exist.
+func tablePartitioning(ctx context.Context, q adapter.Queryable, table string) (bool, Partitioning, error) {
+	var column string
+	err := q.QueryRow(ctx, `SELECT COALESCE(a.attname::TEXT, '')
+FROM pg_class c
+LEFT JOIN pg_partitioned_table p ON p.partrelid = c.oid
+LEFT JOIN pg_attribute a ON a.attrelid = p.partrelid AND a.attnum = p.partattrs[0]
+WHERE c.oid = to_regclass($1)`, table).Scan(&column)
+	if errors.Is(err, adapter.ErrNoRows) {
+		return false, "", nil
+	}
+	if err != nil {
+		return false, "", fmt.Errorf("could not get jobs table partitioning: %w", err)
+	}
+
+	return true, Partitioning(column), nil
+}
+
+// CreateQueuePartition creates the jobs table partition for the queue when the one does not exist yet. Jobs table
+// must be partitioned with PartitionByQueue. Creation fails if the default partition holds jobs of the queue already,
+// so it is better to create the queue partition before the first job of the queue is enqueued.
+func (c *Client) CreateQueuePartition(ctx context.Context, queue string) error {
+	sum := sha256.Sum256([]byte(queue))
+	name := qualifiedIdentifier(c.schema, c.table+"_q_"+hex.EncodeToString(sum[:8]))
+
+	_, err := c.pool.Exec(ctx, `CREATE TABLE IF NOT EXISTS `+name+` PARTITION OF `+c.jobsTable+`
+FOR VALUES IN (`+quoteLiteral(queue)+`)`)
+	if err != nil {
+		return fmt.Errorf("could not create partition for queue %q, check the default partition for its jobs: %w", queue, err)
+	}
+
+	return nil
+}
+
+// CreateJobPartitions creates the jobs table time range partitions for the current period and the given number
+// of periods ahead when they do not exist yet, see WithClientPartitionPeriod. Jobs table must be partitioned
+// with PartitionByCreatedAt. It is safe to call it periodically, e.g. once per period, to always have the partitions
+// ready for the new jobs, otherwise new jobs are stored in the default partition.
+func (c *Client) CreateJobPartitions(ctx context.Context, ahead int) error {
+	start := time.Now().UTC().Truncate(c.partitionPeriod)
+	for i := 0; i <= ahead; i++ {
+		end := start.Add(c.partitionPeriod)
+		name := qualifiedIdentifier(c.schema, c.partitionName(start))
+
+		_, err := c.pool.Exec(ctx, `CREATE TABLE IF NOT EXISTS `+name+` PARTITION OF `+c.jobsTable+`
+FOR VALUES FROM (`+quoteLiteral(start.Format(time.RFC3339))+`) TO (`+quoteLiteral(end.Format(time.RFC3339))+`)`)
+		if err != nil {
+			return fmt.Errorf("could not create jobs partition %s: %w", name, err)
+		}
+
+		start = end
+	}
+
+	return nil
+}
+
+// DetachJobPartitions detaches the jobs table time range partitions created with CreateJobPartitions that end before
+// the given time, even if they still hold jobs, and returns the names of the detached partitions. Detached partitions
+// are kept as the regular tables, so their jobs can be inspected or moved back before the tables are dropped.
+func (c *Client) DetachJobPartitions(ctx context.Context, before time.Time) ([]string, error) {
+	partitions, err := c.listJobPartitions(ctx, before)
+	if err != nil {
+		return nil, err
+	}
+
+	detached := make([]string, 0, len(partitions))
+	for _, name := range partitions {
+		if _, err := c.pool.Exec(
+			ctx,
+			`ALTER TABLE `+c.jobsTable+` DETACH PARTITION `+qualifiedIdentifier(c.schema, name),
+		); err != nil {
+			return detached, fmt.Errorf("could not detach jobs partition %s: %w", name, err)
+		}
+		detached = append(detached, name)
+	}
+
+	return detached, nil
+}
+
+// DropJobPartitions detaches and drops the jobs table time range partitions created with CreateJobPartitions that end
+// before the given time, and returns the names of the dropped partitions. Partitions are dropped with all the jobs
+// they still hold, e.g. the failing ones being retried, use DetachJobPartitions to keep them. Finished jobs are still
+// deleted from the partition one by one, the dead rows they leave are reclaimed at once when the partition is dropped.
+func (c *Client) DropJobPartitions(ctx context.Context, before time.Time) ([]string, error) {
+	partitions, err := c.listJobPartitions(ctx, before)
+	if err != nil {
+		return nil, err
+	}
+
+	dropped := make([]string, 0, len(partitions))
+	for _, name := range partitions {
+		if err := c.dropPartition(ctx, qualifiedIdentifier(c.schema, name)); err != nil {
+			return dropped, fmt.Errorf("could not drop jobs partition %s: %w", name, err)
+		}
+		dropped = append(dropped, name)
+	}
+
+	return dropped, nil
+}
+
+// dropPartition detaches the partition from the jobs table and drops it in the same transaction,
+// so the partition is either dropped or stays attached.
+func (c *Client) dropPartition(ctx context.Context, partition string) (err error) {
+	tx, err := c.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer func() {
+		if err == nil {
+			err = tx.Commit(ctx)
+			return
+		}
+
+		if rbErr := tx.Rollback(ctx); rbErr != nil {
+			c.logger.Error("Could not properly rollback transaction", adapter.Err(rbErr))
+		}
+	}()
+
+	if _, err = tx.Exec(ctx, `ALTER TABLE `+c.jobsTable+` DETACH PARTITION `+partition); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `DROP TABLE `+partition)
+	return err
+}
+
+// listJobPartitions returns the names of the jobs table time range partitions that end before the given time.
+// Only partitions named the way CreateJobPartitions names them are returned.
+func (c *Client) listJobPartitions(ctx context.Context, before time.Time) ([]string, error) {
+	rows, err := c.pool.Query(ctx, `SELECT c.relname FROM pg_inherits i
+JOIN pg_class c ON c.oid = i.inhrelid
+WHERE i.inhparent = to_regclass($1)
+ORDER BY c.relname`, c.jobsTable)
+	if err != nil {
+		return nil, fmt.Errorf("could not list jobs partitions: %w", err)
+	}
+	defer closeRows(rows)
+
+	var partitions []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("could not scan jobs partition name: %w", err)
+		}
+
+		start, ok := c.partitionStart(name)
+		if ok && !start.Add(c.partitionPeriod).After(before) {
+			partitions = append(partitions, name)
+		}
+	}
+
+	return partitions, rows.Err()
+}
+
+// partitionName returns the name of the jobs table time range partition starting at the given time.
+func (c *Client) partitionName(start time.Time) string {
+	return c.table + "_p" + start.UTC().Format(partitionTimeFormat)
+}
+
+// partitionStart parses the start of the jobs table time range partition from its name,
+// it returns false if the name does not match partitionName.
+func (c *Client) partitionStart(name string) (time.Time, bool) {
+	prefix := c.table + "_p"
+	if !strings.HasPrefix(name, prefix) {
+		return time.Time{}, false
+	}
+
+	start, err := time.Parse(partitionTimeFormat, strings.TrimPrefix(name, prefix))
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return start, true
+}
+
+// quoteLiteral quotes an SQL string literal, so it can be safely used in SQL statements that do not accept
+// positional arguments, e.g. partition bounds.
+func quoteLiteral(value string) string {
+	return `'` + strings.ReplaceAll(value, `'`, `''`) + `'`
+}
@@ -0,0 +1,242 @@
+package gue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vgarvardt/gue/v5/adapter"
+	adapterTesting "github.com/vgarvardt/gue/v5/adapter/testing"
+)
+
+func TestClient_partitionName(t *testing.T) {
+	c, err := NewClient(nil, WithClientTableName("my_jobs"))
+	require.NoError(t, err)
+
+	start := time.Date(2024, 3, 5, 6, 0, 0, 0, time.UTC)
+	name := c.partitionName(start)
+	assert.Equal(t, "my_jobs_p20240305T0600", name)
+
+	parsed, ok := c.partitionStart(name)
+	require.True(t, ok)
+	assert.True(t, start.Equal(parsed))
+
+	for _, name := range []string{"my_jobs_default", "my_jobs_q_0123456789abcdef", "other_jobs_p20240305T0600"} {
+		_, ok := c.partitionStart(name)
+		assert.False(t, ok, name)
+	}
+}
+
+func TestQuoteLiteral(t *testing.T) {
+	assert.Equal(t, `'queue'`, quoteLiteral("queue"))
+	assert.Equal(t, `'it''s'`, quoteLiteral("it's"))
+}
+
+func TestJobPartitions(t *testing.T) {
+	for name, openFunc := range adapterTesting.AllAdaptersOpenTestPool {
+		t.Run(name, func(t *testing.T) {
+			testJobPartitions(t, openFunc(t))
+		})
+	}
+}
+
+func testJobPartitions(t *testing.T, connPool adapter.ConnPool) {
+	ctx := context.Background()
+
+	schema := "gue_partition_" + RandomStringID()
+	t.Cleanup(func() {
+		_, err := connPool.Exec(ctx, "DROP SCHEMA IF EXISTS "+schema+" CASCADE")
+		assert.NoError(t, err)
+	})
+
+	err := Migrate(ctx, connPool, WithMigrateSchema(schema), WithMigratePartitioning(PartitionByCreatedAt))
+	require.NoError(t, err)
+
+	// partitioned table is kept as is when no partitioning is requested, the other one can not be applied
+	require.NoError(t, Migrate(ctx, connPool, WithMigrateSchema(schema)))
+	require.NoError(t, Migrate(ctx, connPool, WithMigrateSchema(schema), WithMigratePartitioning(PartitionByCreatedAt)))
+	require.Error(t, Migrate(ctx, connPool, WithMigrateSchema(schema), WithMigratePartitioning(PartitionByQueue)))
+
+	c, err := NewClient(connPool, WithClientSchema(schema), WithClientPartitionPeriod(time.Hour))
+	require.NoError(t, err)
+
+	// partitions are created idempotently
+	for i := 0; i < 2; i++ {
+		require.NoError(t, c.CreateJobPartitions(ctx, 2))
+	}
+
+	current := time.Now().UTC().Truncate(time.Hour)
+	for i := 0; i < 3; i++ {
+		name := qualifiedIdentifier(schema, c.partitionName(current.Add(time.Duration(i)*time.Hour)))
+
+		var exists bool
+		err := connPool.QueryRow(ctx, `SELECT to_regclass($1) IS NOT NULL`, name).Scan(&exists)
+		require.NoError(t, err)
+		assert.True(t, exists)
+	}
+
+	j := &Job{Type: "MyJob"}
+	require.NoError(t, c.Enqueue(ctx, j))
+
+	locked, err := c.LockJobByID(ctx, j.ID)
+	require.NoError(t, err)
+	require.NotNil(t, locked)
+	require.NoError(t, locked.Error(ctx, assert.AnError))
+	require.NoError(t, locked.Done(ctx))
+
+	// detached partition keeps the job as the regular table
+	detached, err := c.DetachJobPartitions(ctx, current.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, []string{c.partitionName(current)}, detached)
+
+	_, err = c.GetJob(ctx, j.ID)
+	require.Error(t, err)
+
+	var detachedJobs int
+	err = connPool.QueryRow(
+		ctx, `SELECT COUNT(*) FROM `+qualifiedIdentifier(schema, c.partitionName(current)),
+	).Scan(&detachedJobs)
+	require.NoError(t, err)
+	assert.Equal(t, 1, detachedJobs)
+
+	_, err = connPool.Exec(ctx, `DROP TABLE `+qualifiedIdentifier(schema, c.partitionName(current)))
+	require.NoError(t, err)
+
+	// expired partitions are dropped along with the jobs they still hold
+	require.NoError(t, c.CreateJobPartitions(ctx, 2))
+	j = &Job{Type: "MyJob"}
+	require.NoError(t, c.Enqueue(ctx, j))
+
+	dropped, err := c.DropJobPartitions(ctx, current.Add(2*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		c.partitionName(current),
+		c.partitionName(current.Add(time.Hour)),
+	}, dropped)
+
+	_, err = c.GetJob(ctx, j.ID)
+	require.Error(t, err)
+
+	for i, exists := range []bool{false, false, true} {
+		name := qualifiedIdentifier(schema, c.partitionName(current.Add(time.Duration(i)*time.Hour)))
+
+		var found bool
+		err := connPool.QueryRow(ctx, `SELECT to_regclass($1) IS NOT NULL`, name).Scan(&found)
+		require.NoError(t, err)
+		assert.Equal(t, exists, found, name)
+	}
+}
+
+func TestMigratePartitioningExistingTable(t *testing.T) {
+	for name, openFunc := range adapterTesting.AllAdaptersOpenTestPool {
+		t.Run(name, func(t *testing.T) {
+			testMigratePartitioningExistingTable(t, openFunc(t))
+		})
+	}
+}
+
+func testMigratePartitioningExistingTable(t *testing.T, connPool adapter.ConnPool) {
+	ctx := context.Background()
+
+	schema := "gue_partition_" + RandomStringID()
+	t.Cleanup(func() {
+		_, err := connPool.Exec(ctx, "DROP SCHEMA IF EXISTS "+schema+" CASCADE")
+		assert.NoError(t, err)
+	})
+
+	require.NoError(t, Migrate(ctx, connPool, WithMigrateSchema(schema)))
+
+	c, err := NewClient(connPool, WithClientSchema(schema))
+	require.NoError(t, err)
+
+	j := &Job{Type: "MyJob"}
+	require.NoError(t, c.Enqueue(ctx, j))
+
+	// jobs table is recreated, so the table holding jobs can not be partitioned
+	err = Migrate(ctx, connPool, WithMigrateSchema(schema), WithMigratePartitioning(PartitionByCreatedAt))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "only the empty jobs table can be partitioned")
+
+	locked, err := c.LockJobByID(ctx, j.ID)
+	require.NoError(t, err)
+	require.NoError(t, locked.Delete(ctx))
+	require.NoError(t, locked.Done(ctx))
+
+	// migrated and drained jobs table is partitioned later with no new migration versions
+	err = Migrate(ctx, connPool, WithMigrateSchema(schema), WithMigratePartitioning(PartitionByCreatedAt))
+	require.NoError(t, err)
+
+	exists, partitioning, err := tablePartitioning(ctx, connPool, qualifiedIdentifier(schema, defaultTableName))
+	require.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, PartitionByCreatedAt, partitioning)
+
+	version, err := c.DBSchemaVersion(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, LatestSchemaVersion, version)
+
+	// unique jobs keep working with the recreated table indexes
+	require.NoError(t, c.Enqueue(ctx, &Job{Type: "MyJob", UniqueKey: "key"}))
+	dropped := &Job{Type: "MyJob", UniqueKey: "key"}
+	require.NoError(t, c.Enqueue(ctx, dropped))
+	assert.Equal(t, ulid.ULID{}, dropped.ID)
+}
+
+func TestQueuePartitions(t *testing.T) {
+	for name, openFunc := range adapterTesting.AllAdaptersOpenTestPool {
+		t.Run(name, func(t *testing.T) {
+			testQueuePartitions(t, openFunc(t))
+		})
+	}
+}
+
+func testQueuePartitions(t *testing.T, connPool adapter.ConnPool) {
+	ctx := context.Background()
+
+	schema := "gue_partition_" + RandomStringID()
+	t.Cleanup(func() {
+		_, err := connPool.Exec(ctx, "DROP SCHEMA IF EXISTS "+schema+" CASCADE")
+		assert.NoError(t, err)
+	})
+
+	err := Migrate(ctx, connPool, WithMigrateSchema(schema), WithMigratePartitioning(PartitionByQueue))
+	require.NoError(t, err)
+
+	c, err := NewClient(connPool, WithClientSchema(schema))
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, c.CreateQueuePartition(ctx, "it's"))
+	}
+
+	// unique jobs are supported with the partitioned jobs table as well
+	unique := &Job{Type: "MyJob", Queue: "it's", UniqueKey: "key"}
+	require.NoError(t, c.Enqueue(ctx, unique))
+	dropped := &Job{Type: "MyJob", Queue: "it's", UniqueKey: "key"}
+	require.NoError(t, c.Enqueue(ctx, dropped))
+	assert.Equal(t, ulid.ULID{}, dropped.ID)
+	locked, err := c.LockJobByID(ctx, unique.ID)
+	require.NoError(t, err)
+	require.NoError(t, locked.Delete(ctx))
+	require.NoError(t, locked.Done(ctx))
+
+	require.NoError(t, c.Enqueue(ctx, &Job{Type: "MyJob", Queue: "it's"}))
+
+	// jobs of the queue without the partition are stored in the default partition
+	require.NoError(t, c.Enqueue(ctx, &Job{Type: "MyJob", Queue: "other"}))
+
+	// default partition holds the queue jobs, so the queue partition can not be created
+	require.Error(t, c.CreateQueuePartition(ctx, "other"))
+
+	for _, queue := range []string{"it's", "other"} {
+		j, err := c.LockJob(ctx, queue)
+		require.NoError(t, err)
+		require.NotNil(t, j)
+		require.NoError(t, j.Delete(ctx))
+		require.NoError(t, j.Done(ctx))
+	}
+}
@@ -0,0 +1,119 @@
+package gue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vgarvardt/gue/v5/adapter"
+)
+
+// PauseKind is the kind of the paused entity.
+type PauseKind string
+
+const (
+	// PauseKindQueue is the paused queue, no jobs are locked from it.
+	PauseKindQueue PauseKind = "queue"
+	// PauseKindJobType is the paused job type, jobs of this type are not locked from any queue.
+	PauseKindJobType PauseKind = "type"
+)
+
+// Pause is the paused queue or job type.
+type Pause struct {
+	// Kind is the kind of the paused entity.
+	Kind PauseKind
+	// Name is the name of the paused queue or job type.
+	Name string
+	// PausedAt is the time the entity was paused at.
+	PausedAt time.Time
+}
+
+// PauseQueue pauses the queue - workers stop locking jobs from it until the queue is resumed with ResumeQueue.
+// Pause state is persisted in the DB, so it is shared by all the workers using the same jobs table.
+// Jobs that are being worked at the moment of the pause are not affected.
+func (c *Client) PauseQueue(ctx context.Context, queue string) error {
+	return c.pause(ctx, PauseKindQueue, queue)
+}
+
+// ResumeQueue resumes previously paused queue.
+func (c *Client) ResumeQueue(ctx context.Context, queue string) error {
+	return c.resume(ctx, PauseKindQueue, queue)
+}
+
+// PauseJobType pauses the job type - workers stop locking jobs of this type from all the queues until the type is
+// resumed with ResumeJobType. See PauseQueue for details.
+func (c *Client) PauseJobType(ctx context.Context, jobType string) error {
+	return c.pause(ctx, PauseKindJobType, jobType)
+}
+
+// ResumeJobType resumes previously paused job type.
+func (c *Client) ResumeJobType(ctx context.Context, jobType string) error {
+	return c.resume(ctx, PauseKindJobType, jobType)
+}
+
+// ListPaused returns all paused queues and job types.
+func (c *Client) ListPaused(ctx context.Context) ([]Pause, error) {
+	rows, err := c.pool.Query(ctx, `SELECT kind, name, paused_at FROM `+c.pausedTable+` ORDER BY kind, name`)
+	if err != nil {
+		return nil, fmt.Errorf("could not query paused queues and job types: %w", err)
+	}
+	defer closeRows(rows)
+
+	var paused []Pause
+	for rows.Next() {
+		var p Pause
+		if err := rows.Scan(&p.Kind, &p.Name, &p.PausedAt); err != nil {
+			return nil, fmt.Errorf("could not scan paused entity: %w", err)
+		}
+		paused = append(paused, p)
+	}
+
+	return paused, rows.Err()
+}
+
+func (c *Client) pause(ctx context.Context, kind PauseKind, name string) error {
+	_, err := c.pool.Exec(
+		ctx,
+		`INSERT INTO `+c.pausedTable+` (kind, name, paused_at) VALUES ($1, $2, $3) ON CONFLICT (kind, name) DO NOTHING`,
+		string(kind), name, time.Now().UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("could not pause %s %q: %w", kind, name, err)
+	}
+
+	c.logger.Info("Paused", adapter.F("kind", string(kind)), adapter.F("name", name))
+	return nil
+}
+
+func (c *Client) resume(ctx context.Context, kind PauseKind, name string) error {
+	_, err := c.pool.Exec(ctx, `DELETE FROM `+c.pausedTable+` WHERE kind = $1 AND name = $2`, string(kind), name)
+	if err != nil {
+		return fmt.Errorf("could not resume %s %q: %w", kind, name, err)
+	}
+
+	c.logger.Info("Resumed", adapter.F("kind", string(kind)), adapter.F("name", name))
+	return nil
+}
+
+// notPausedCondition returns SQL condition for the lock queries that filters out jobs from the paused queue
+// with the name set as the first query argument and jobs of the paused types. Queue check does not depend
+// on the row, so it is evaluated only once per query.
+func (c *Client) notPausedCondition() string {
+	return `NOT EXISTS (SELECT 1 FROM ` + c.pausedTable + ` WHERE kind = 'queue' AND name = $1)
+  AND job_type NOT IN (SELECT name FROM ` + c.pausedTable + ` WHERE kind = 'type')`
+}
+
+// sqlStateUndefinedTable is the Postgres error code of the query referencing the table that does not exist.
+const sqlStateUndefinedTable = "42P01"
+
+// migrateHint adds the hint to the lock query error caused by the missing table, e.g. the paused queues and job types
+// table every lock query joins, that is created by the schema migrations only.
+func migrateHint(err error) error {
+	var pgErr interface{ SQLState() string }
+	if errors.As(err, &pgErr) && pgErr.SQLState() == sqlStateUndefinedTable {
+		return fmt.Errorf("lock query requires all the gue tables, make sure gue.Migrate was applied: %w", err)
+	}
+
+	return err
+}
@@ -0,0 +1,123 @@
+package gue
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vgarvardt/gue/v5/adapter"
+	adapterTesting "github.com/vgarvardt/gue/v5/adapter/testing"
+)
+
+func TestClientPause(t *testing.T) {
+	for name, openFunc := range adapterTesting.AllAdaptersOpenTestPool {
+		t.Run(name, func(t *testing.T) {
+			testClientPause(t, openFunc(t))
+		})
+	}
+}
+
+func testClientPause(t *testing.T, connPool adapter.ConnPool) {
+	ctx := context.Background()
+
+	c, err := NewClient(connPool)
+	require.NoError(t, err)
+
+	queue := "pause-" + RandomStringID()
+	jobType := "pause-type-" + RandomStringID()
+
+	err = c.Enqueue(ctx, &Job{Queue: queue, Type: jobType})
+	require.NoError(t, err)
+
+	// pausing is idempotent
+	require.NoError(t, c.PauseQueue(ctx, queue))
+	require.NoError(t, c.PauseQueue(ctx, queue))
+
+	paused, err := c.ListPaused(ctx)
+	require.NoError(t, err)
+	require.Len(t, paused, 1)
+	assert.Equal(t, PauseKindQueue, paused[0].Kind)
+	assert.Equal(t, queue, paused[0].Name)
+	assert.False(t, paused[0].PausedAt.IsZero())
+
+	j, err := c.LockJob(ctx, queue)
+	require.NoError(t, err)
+	assert.Nil(t, j)
+
+	w, err := NewWorker(c, WorkMap{})
+	require.NoError(t, err)
+	w.queue = queue
+	w.pauseCheckInterval = 0
+	w.checkPaused(ctx)
+	assert.True(t, w.pausedQueue.Load())
+
+	require.NoError(t, c.ResumeQueue(ctx, queue))
+	require.NoError(t, c.PauseJobType(ctx, jobType))
+
+	w.checkPaused(ctx)
+	assert.False(t, w.pausedQueue.Load())
+	assert.Equal(t, jobType, w.pausedTypes)
+
+	j, err = c.LockNextScheduledJob(ctx, queue)
+	require.NoError(t, err)
+	assert.Nil(t, j)
+
+	require.NoError(t, c.ResumeJobType(ctx, jobType))
+
+	paused, err = c.ListPaused(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, paused)
+
+	j, err = c.LockJob(ctx, queue)
+	require.NoError(t, err)
+	require.NotNil(t, j)
+	require.NoError(t, j.Delete(ctx))
+	require.NoError(t, j.Done(ctx))
+}
+
+type sqlStateError string
+
+func (e sqlStateError) Error() string {
+	return "sql state " + string(e)
+}
+
+func (e sqlStateError) SQLState() string {
+	return string(e)
+}
+
+func TestLockJobNotMigrated(t *testing.T) {
+	ctx := context.Background()
+
+	errUndefinedTable := sqlStateError(sqlStateUndefinedTable)
+
+	scanArgs := make([]any, len((&Job{}).scanDest()))
+	for i := range scanArgs {
+		scanArgs[i] = mock.Anything
+	}
+
+	row := new(adapterTesting.Row)
+	row.On("Scan", scanArgs...).Return(errUndefinedTable)
+
+	tx := new(adapterTesting.Tx)
+	tx.Queryable.On("QueryRow", ctx, mock.Anything, mock.Anything).Return(row)
+	tx.On("Rollback", ctx).Return(nil)
+
+	pool := new(adapterTesting.ConnPool)
+	pool.On("Begin", ctx).Return(tx, nil)
+
+	c, err := NewClient(pool)
+	require.NoError(t, err)
+
+	_, err = c.LockJob(ctx, "")
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errUndefinedTable))
+	assert.Contains(t, err.Error(), "make sure gue.Migrate was applied")
+
+	// other errors are returned as is
+	assert.Equal(t, assert.AnError, migrateHint(assert.AnError))
+	assert.Equal(t, sqlStateError("40001"), migrateHint(sqlStateError("40001")))
+}
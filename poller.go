@@ -0,0 +1,119 @@
+package gue
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// PollQuery describes the jobs the Poller is asked to lock.
+type PollQuery struct {
+	// Queue is the name of the queue the worker works jobs from.
+	Queue string
+	// Limit is the max number of jobs to lock. Jobs locked within a single Poll call are worked as a batch,
+	// so when Limit is greater than one they must be locked in a single transaction, e.g. with Client.LockJobs.
+	Limit int
+	// Types limits the job types to lock when not empty, e.g. to lock jobs for the batch handler of the job type.
+	Types []string
+	// ExcludeTypes are the job types not to lock, e.g. the ones with the batch handlers registered.
+	ExcludeTypes []string
+	// FairnessKeys limits the job fairness keys to lock when not empty, see Job.FairnessKey.
+	FairnessKeys []string
+}
+
+// AllowsType returns true if the job of the given type matches the query types filters.
+func (q PollQuery) AllowsType(jobType string) bool {
+	for _, t := range q.ExcludeTypes {
+		if t == jobType {
+			return false
+		}
+	}
+	if len(q.Types) == 0 {
+		return true
+	}
+	for _, t := range q.Types {
+		if t == jobType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Poller selects and locks the next jobs to work on, see WithWorkerPoller. Poll returns no jobs and no error
+// when there is nothing to work on, and must not return more jobs than the query limit. Jobs must be ready
+// to be worked and belong to the query queue, and the query types filters must be respected.
+//
+// Poller is shared by all the workers in the pool, so it must be safe for concurrent use.
+type Poller interface {
+	Poll(ctx context.Context, c *Client, q PollQuery) ([]*Job, error)
+}
+
+// PollerFunc is an adapter to allow the use of ordinary functions as Poller.
+type PollerFunc func(ctx context.Context, c *Client, q PollQuery) ([]*Job, error)
+
+// Poll calls f(ctx, c, q).
+func (f PollerFunc) Poll(ctx context.Context, c *Client, q PollQuery) ([]*Job, error) {
+	return f(ctx, c, q)
+}
+
+// Poll implements Poller for the built-in poll strategies. It locks the single job the same way as Client.LockJob
+// does when the query limit is one, and the batch of jobs in a single transaction as Client.LockJobs otherwise.
+// Jobs from the paused queue or of the paused type are not locked.
+func (s PollStrategy) Poll(ctx context.Context, c *Client, q PollQuery) ([]*Job, error) {
+	lq := lockQuery{
+		queue:        q.Queue,
+		strategy:     s,
+		types:        q.Types,
+		excludeTypes: q.ExcludeTypes,
+		fairnessKeys: q.FairnessKeys,
+	}
+	if q.Limit > 1 {
+		return c.lockJobs(ctx, lq, q.Limit)
+	}
+
+	j, err := c.lockJob(ctx, lq)
+	if j == nil {
+		return nil, err
+	}
+
+	return []*Job{j}, nil
+}
+
+// typesRoundRobinPoller polls the job types one by one, see NewTypesRoundRobinPoller.
+type typesRoundRobinPoller struct {
+	poller Poller
+	types  []string
+	next   atomic.Uint64
+}
+
+// NewTypesRoundRobinPoller creates the Poller that locks jobs of the given types in turns with the given Poller,
+// so that the flood of jobs of one type does not delay the jobs of the other types. Every poll starts from the type
+// next to the one the previous poll started from and falls through to the following types until the jobs are found.
+// Only jobs of the given types are locked.
+func NewTypesRoundRobinPoller(p Poller, types ...string) Poller {
+	return &typesRoundRobinPoller{poller: p, types: types}
+}
+
+// Poll implements Poller.
+func (p *typesRoundRobinPoller) Poll(ctx context.Context, c *Client, q PollQuery) ([]*Job, error) {
+	if len(p.types) == 0 {
+		return nil, nil
+	}
+
+	start := p.next.Add(1) - 1
+	for i := range p.types {
+		jobType := p.types[(start+uint64(i))%uint64(len(p.types))]
+		if !q.AllowsType(jobType) {
+			continue
+		}
+
+		typeQuery := q
+		typeQuery.Types, typeQuery.ExcludeTypes = []string{jobType}, nil
+		jobs, err := p.poller.Poll(ctx, c, typeQuery)
+		if err != nil || len(jobs) > 0 {
+			return jobs, err
+		}
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,102 @@
+package gue
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vgarvardt/gue/v5/adapter"
+	adapterTesting "github.com/vgarvardt/gue/v5/adapter/testing"
+)
+
+func TestPollQuery_AllowsType(t *testing.T) {
+	assert.True(t, PollQuery{}.AllowsType("foo"))
+	assert.False(t, PollQuery{ExcludeTypes: []string{"foo"}}.AllowsType("foo"))
+	assert.True(t, PollQuery{Types: []string{"foo", "bar"}}.AllowsType("bar"))
+	assert.False(t, PollQuery{Types: []string{"foo"}}.AllowsType("bar"))
+	assert.False(t, PollQuery{Types: []string{"foo"}, ExcludeTypes: []string{"foo"}}.AllowsType("foo"))
+}
+
+func TestTypesRoundRobinPoller(t *testing.T) {
+	ctx := context.Background()
+
+	var polled []string
+	found := map[string]bool{"foo": true, "baz": true}
+	p := NewTypesRoundRobinPoller(PollerFunc(func(ctx context.Context, c *Client, q PollQuery) ([]*Job, error) {
+		require.Len(t, q.Types, 1)
+		assert.Empty(t, q.ExcludeTypes)
+		assert.Equal(t, "q", q.Queue)
+		assert.Equal(t, 1, q.Limit)
+
+		polled = append(polled, q.Types[0])
+		if found[q.Types[0]] {
+			return []*Job{{Type: q.Types[0]}}, nil
+		}
+		return nil, nil
+	}), "foo", "bar", "baz")
+
+	for _, expected := range []string{"foo", "baz", "baz", "foo"} {
+		jobs, err := p.Poll(ctx, nil, PollQuery{Queue: "q", Limit: 1})
+		require.NoError(t, err)
+		require.Len(t, jobs, 1)
+		assert.Equal(t, expected, jobs[0].Type)
+	}
+	assert.Equal(t, []string{"foo", "bar", "baz", "baz", "foo"}, polled)
+
+	// query types filters are respected
+	polled = nil
+	jobs, err := p.Poll(ctx, nil, PollQuery{Queue: "q", Limit: 1, ExcludeTypes: []string{"foo", "baz"}})
+	require.NoError(t, err)
+	assert.Empty(t, jobs)
+	assert.Equal(t, []string{"bar"}, polled)
+
+	// errors are returned right away
+	p = NewTypesRoundRobinPoller(PollerFunc(func(ctx context.Context, c *Client, q PollQuery) ([]*Job, error) {
+		return nil, errors.New("db is down")
+	}), "foo", "bar")
+	_, err = p.Poll(ctx, nil, PollQuery{Queue: "q", Limit: 1})
+	assert.Error(t, err)
+
+	jobs, err = NewTypesRoundRobinPoller(PriorityPollStrategy).Poll(ctx, nil, PollQuery{Queue: "q", Limit: 1})
+	require.NoError(t, err)
+	assert.Empty(t, jobs)
+}
+
+func TestWorker_Poller(t *testing.T) {
+	for name, openFunc := range adapterTesting.AllAdaptersOpenTestPool {
+		t.Run(name, func(t *testing.T) {
+			testWorkerPoller(t, openFunc(t))
+		})
+	}
+}
+
+func testWorkerPoller(t *testing.T, connPool adapter.ConnPool) {
+	ctx := context.Background()
+
+	c, err := NewClient(connPool)
+	require.NoError(t, err)
+
+	var worked []string
+	handler := func(ctx context.Context, j *Job) error {
+		worked = append(worked, j.Type)
+		return nil
+	}
+	wm := WorkMap{"foo": handler, "bar": handler, "baz": handler}
+
+	// flood of foo jobs does not delay bar and baz ones
+	for _, jobType := range []string{"foo", "foo", "foo", "bar", "baz"} {
+		require.NoError(t, c.Enqueue(ctx, &Job{Type: jobType}))
+	}
+
+	w, err := NewWorker(c, wm, WithWorkerPoller(NewTypesRoundRobinPoller(PriorityPollStrategy, "foo", "bar", "baz")))
+	require.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		require.True(t, w.WorkOne(ctx))
+	}
+	assert.False(t, w.WorkOne(ctx))
+	assert.Equal(t, []string{"foo", "bar", "baz", "foo", "foo"}, worked)
+}
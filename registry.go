@@ -0,0 +1,193 @@
+package gue
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+
+	"github.com/vgarvardt/gue/v5/adapter"
+)
+
+// heartbeatTTLFactor is the number of missed heartbeats after which worker registration is considered stale.
+const heartbeatTTLFactor = 3
+
+// WorkerInfo is the worker registered in the worker registry, see WithWorkerHeartbeat.
+type WorkerInfo struct {
+	// ID is the worker ID, set with WithWorkerID or derived from the WithPoolID for the pool workers.
+	ID string
+	// Host is the hostname worker is running on.
+	Host string
+	// PID is the ID of the process worker is running in.
+	PID int
+	// Queue is the name of the queue worker is working on.
+	Queue string
+	// StartedAt is the time the worker was started at.
+	StartedAt time.Time
+	// HeartbeatAt is the time of the last worker heartbeat.
+	HeartbeatAt time.Time
+	// CurrentJob is the job worker is working on at the moment of the last heartbeat, nil if worker was idle.
+	CurrentJob *WorkerJob
+}
+
+// WorkerJob is the job registered worker is working on.
+type WorkerJob struct {
+	// ID is the job ID.
+	ID ulid.ULID
+	// Type is the job type.
+	Type string
+	// LockedAt is the time the job was locked by the worker at.
+	LockedAt time.Time
+}
+
+// ListWorkers returns alive workers registered in the worker registry ordered by the worker ID. Workers register
+// themselves only when heartbeats are enabled with WithWorkerHeartbeat or WithPoolHeartbeat.
+func (c *Client) ListWorkers(ctx context.Context) ([]WorkerInfo, error) {
+	rows, err := c.pool.Query(ctx, `SELECT worker_id, host, pid, queue, started_at, heartbeat_at, job_id, job_type, job_locked_at
+FROM `+c.workersTable+`
+WHERE expires_at >= $1
+ORDER BY worker_id`, time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("could not query workers: %w", err)
+	}
+	defer closeRows(rows)
+
+	var workers []WorkerInfo
+	for rows.Next() {
+		var (
+			wi          WorkerInfo
+			jobID       sql.NullString
+			jobType     sql.NullString
+			jobLockedAt sql.NullTime
+		)
+		if err := rows.Scan(
+			&wi.ID, &wi.Host, &wi.PID, &wi.Queue, &wi.StartedAt, &wi.HeartbeatAt, &jobID, &jobType, &jobLockedAt,
+		); err != nil {
+			return nil, fmt.Errorf("could not scan worker: %w", err)
+		}
+
+		if jobID.Valid {
+			id, err := ulid.ParseStrict(jobID.String)
+			if err != nil {
+				return nil, fmt.Errorf("could not parse worker %q job ID: %w", wi.ID, err)
+			}
+			wi.CurrentJob = &WorkerJob{ID: id, Type: jobType.String, LockedAt: jobLockedAt.Time}
+		}
+
+		workers = append(workers, wi)
+	}
+
+	return workers, rows.Err()
+}
+
+// workerRegistration keeps the worker registry entry of the single worker up-to-date.
+type workerRegistration struct {
+	c         *Client
+	id        string
+	queue     string
+	interval  time.Duration
+	logger    adapter.Logger
+	host      string
+	pid       int
+	startedAt time.Time
+
+	mu         sync.Mutex
+	currentJob *WorkerJob
+}
+
+func newWorkerRegistration(w *Worker) *workerRegistration {
+	host, err := os.Hostname()
+	if err != nil {
+		w.logger.Error("Could not get hostname for the worker registration", adapter.Err(err))
+	}
+
+	return &workerRegistration{
+		c:        w.c,
+		id:       w.id,
+		queue:    w.queue,
+		interval: w.heartbeatInterval,
+		logger:   w.logger,
+		host:     host,
+		pid:      os.Getpid(),
+	}
+}
+
+// setCurrentJob stores the job worker is working on, it is written to the registry on the next heartbeat.
+func (r *workerRegistration) setCurrentJob(j *Job, lockedAt time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if j == nil {
+		r.currentJob = nil
+		return
+	}
+	r.currentJob = &WorkerJob{ID: j.ID, Type: j.Type, LockedAt: lockedAt}
+}
+
+// run registers the worker and heartbeats until the ctx is done, worker registration is removed after that.
+func (r *workerRegistration) run(ctx context.Context) {
+	r.startedAt = time.Now().UTC()
+	r.heartbeat(ctx)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			r.unregister()
+			return
+		case <-ticker.C:
+			r.heartbeat(ctx)
+		}
+	}
+}
+
+func (r *workerRegistration) heartbeat(ctx context.Context) {
+	r.mu.Lock()
+	var (
+		jobID       sql.NullString
+		jobType     sql.NullString
+		jobLockedAt sql.NullTime
+	)
+	if r.currentJob != nil {
+		jobID = sql.NullString{String: r.currentJob.ID.String(), Valid: true}
+		jobType = sql.NullString{String: r.currentJob.Type, Valid: true}
+		jobLockedAt = sql.NullTime{Time: r.currentJob.LockedAt, Valid: true}
+	}
+	r.mu.Unlock()
+
+	now := time.Now().UTC()
+	if _, err := r.c.pool.Exec(ctx, `INSERT INTO `+r.c.workersTable+`
+(worker_id, host, pid, queue, started_at, heartbeat_at, expires_at, job_id, job_type, job_locked_at)
+VALUES
+($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+ON CONFLICT (worker_id) DO UPDATE
+SET heartbeat_at = excluded.heartbeat_at, expires_at = excluded.expires_at,
+    job_id = excluded.job_id, job_type = excluded.job_type, job_locked_at = excluded.job_locked_at`,
+		r.id, r.host, r.pid, r.queue, r.startedAt, now, now.Add(heartbeatTTLFactor*r.interval),
+		jobID, jobType, jobLockedAt,
+	); err != nil {
+		r.logger.Error("Worker failed to send heartbeat", adapter.Err(err))
+		return
+	}
+
+	// every worker cleans up stale entries of the workers that did not unregister properly, e.g. crashed
+	if _, err := r.c.pool.Exec(ctx, `DELETE FROM `+r.c.workersTable+` WHERE expires_at < $1`, now); err != nil {
+		r.logger.Error("Worker failed to clean up stale worker registrations", adapter.Err(err))
+	}
+}
+
+func (r *workerRegistration) unregister() {
+	// use separate context as the worker context is done already at this point
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := r.c.pool.Exec(ctx, `DELETE FROM `+r.c.workersTable+` WHERE worker_id = $1`, r.id); err != nil {
+		r.logger.Error("Worker failed to unregister", adapter.Err(err))
+	}
+}
@@ -0,0 +1,102 @@
+package gue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vgarvardt/gue/v5/adapter"
+	adapterTesting "github.com/vgarvardt/gue/v5/adapter/testing"
+)
+
+func TestWorkerRegistry(t *testing.T) {
+	for name, openFunc := range adapterTesting.AllAdaptersOpenTestPool {
+		t.Run(name, func(t *testing.T) {
+			testWorkerRegistry(t, openFunc(t))
+		})
+	}
+}
+
+func testWorkerRegistry(t *testing.T, connPool adapter.ConnPool) {
+	ctx := context.Background()
+
+	c, err := NewClient(connPool)
+	require.NoError(t, err)
+
+	// stale registration of the crashed worker
+	_, err = connPool.Exec(
+		ctx,
+		`INSERT INTO gue_workers (worker_id, host, pid, queue, started_at, heartbeat_at, expires_at)
+VALUES ('crashed', 'host', 1, '', $1, $1, $1)`,
+		time.Now().Add(-time.Hour).UTC(),
+	)
+	require.NoError(t, err)
+
+	queue := "registry-" + RandomStringID()
+	jobStarted := make(chan struct{})
+	finishJob := make(chan struct{})
+	wm := WorkMap{
+		"MyJob": func(ctx context.Context, j *Job) error {
+			close(jobStarted)
+			<-finishJob
+			return nil
+		},
+	}
+
+	w, err := NewWorker(
+		c, wm,
+		WithWorkerID("registry-worker"),
+		WithWorkerQueue(queue),
+		WithWorkerPollInterval(10*time.Millisecond),
+		WithWorkerHeartbeat(50*time.Millisecond),
+	)
+	require.NoError(t, err)
+
+	j := &Job{Queue: queue, Type: "MyJob"}
+	err = c.Enqueue(ctx, j)
+	require.NoError(t, err)
+
+	runCtx, cancel := context.WithCancel(ctx)
+	done := make(chan error)
+	go func() {
+		done <- w.Run(runCtx)
+	}()
+
+	<-jobStarted
+	require.Eventually(t, func() bool {
+		workers, err := c.ListWorkers(ctx)
+		require.NoError(t, err)
+		return len(workers) == 1 && workers[0].CurrentJob != nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	workers, err := c.ListWorkers(ctx)
+	require.NoError(t, err)
+	require.Len(t, workers, 1)
+	assert.Equal(t, "registry-worker", workers[0].ID)
+	assert.Equal(t, queue, workers[0].Queue)
+	assert.NotZero(t, workers[0].PID)
+	assert.Equal(t, j.ID, workers[0].CurrentJob.ID)
+	assert.Equal(t, "MyJob", workers[0].CurrentJob.Type)
+
+	var staleCount int
+	err = connPool.QueryRow(ctx, `SELECT COUNT(*) FROM gue_workers WHERE worker_id = 'crashed'`).Scan(&staleCount)
+	require.NoError(t, err)
+	assert.Equal(t, 0, staleCount)
+
+	close(finishJob)
+	require.Eventually(t, func() bool {
+		workers, err := c.ListWorkers(ctx)
+		require.NoError(t, err)
+		return len(workers) == 1 && workers[0].CurrentJob == nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	require.NoError(t, <-done)
+
+	workers, err = c.ListWorkers(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, workers)
+}
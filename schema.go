@@ -0,0 +1,50 @@
+package gue
+
+import (
+	"strings"
+)
+
+// SchemaSQL returns DDL statements that create gue tables and indexes using table name and schema configured with
+// WithClientTableName and WithClientSchema options. Schema itself is not being created, it must exist already.
+// Returned statements do not track schema version, use Migrate to apply versioned migrations instead.
+func (c *Client) SchemaSQL() string {
+	migrations, err := loadMigrations()
+	if err != nil {
+		// embedded migrations are always valid, this is tested
+		panic(err)
+	}
+
+	data := migrationTemplateData{JobsTable: c.jobsTable, schema: c.schema, table: c.table}
+
+	var sb strings.Builder
+	for _, mig := range migrations {
+		migrationSQL, err := mig.render(data)
+		if err != nil {
+			panic(err)
+		}
+		sb.WriteString(migrationSQL)
+	}
+
+	return sb.String()
+}
+
+// relatedTableName builds the name of the table related to the jobs table, e.g. schema migrations table.
+// Related table name is built from the jobs table name with the "_jobs" suffix trimmed, so default installation
+// gets "gue_<suffix>" tables, e.g. "gue_schema_migrations", while "billing_jobs" gets "billing_schema_migrations".
+func relatedTableName(table, suffix string) string {
+	return strings.TrimSuffix(table, "_jobs") + "_" + suffix
+}
+
+// quoteIdentifier quotes an SQL identifier (e.g. table or schema name), so it can be safely used in SQL statements.
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// qualifiedIdentifier builds quoted identifier qualified with the schema name if the one is set.
+func qualifiedIdentifier(schema, name string) string {
+	if schema == "" {
+		return quoteIdentifier(name)
+	}
+
+	return quoteIdentifier(schema) + "." + quoteIdentifier(name)
+}
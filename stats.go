@@ -0,0 +1,335 @@
+package gue
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/vgarvardt/gue/v5/adapter"
+)
+
+const defaultStatsInterval = 30 * time.Second
+
+// TypeStats is the aggregated state of the jobs of the single type in the single queue.
+type TypeStats struct {
+	// Queue is the name of the queue.
+	Queue string
+	// Type is the job type.
+	Type string
+	// Ready is the number of jobs that are eligible for execution.
+	Ready int64
+	// Scheduled is the number of jobs scheduled to run in the future.
+	Scheduled int64
+	// Failing is the number of jobs that failed at least once and are waiting for retry.
+	Failing int64
+	// OldestReadyRunAt is the scheduled time of the oldest job that is eligible for execution,
+	// not valid if there are no ready jobs.
+	OldestReadyRunAt sql.NullTime
+}
+
+// TypeStats returns stats of all the queue and job type pairs that have jobs, ordered by the queue name and type.
+func (c *Client) TypeStats(ctx context.Context) ([]TypeStats, error) {
+	return queryTypeStats(ctx, c.pool, c.jobsTable)
+}
+
+func queryTypeStats(ctx context.Context, q adapter.Queryable, jobsTable string) ([]TypeStats, error) {
+	rows, err := q.Query(ctx, `SELECT
+  queue,
+  job_type,
+  COUNT(*) FILTER (WHERE run_at <= $1),
+  COUNT(*) FILTER (WHERE run_at > $1),
+  COUNT(*) FILTER (WHERE error_count > 0),
+  MIN(run_at) FILTER (WHERE run_at <= $1)
+FROM `+jobsTable+`
+GROUP BY queue, job_type
+ORDER BY queue, job_type`, time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("could not query job type stats: %w", err)
+	}
+	defer closeRows(rows)
+
+	var stats []TypeStats
+	for rows.Next() {
+		var s TypeStats
+		if err := rows.Scan(&s.Queue, &s.Type, &s.Ready, &s.Scheduled, &s.Failing, &s.OldestReadyRunAt); err != nil {
+			return nil, fmt.Errorf("could not scan job type stats: %w", err)
+		}
+		stats = append(stats, s)
+	}
+
+	return stats, rows.Err()
+}
+
+// StatsCollector periodically collects queues stats and reports them as observable gauges:
+//   - gue_queue_jobs_ready - number of jobs eligible for execution
+//   - gue_queue_jobs_scheduled - number of jobs scheduled to run in the future
+//   - gue_queue_jobs_failing - number of jobs that failed at least once and are waiting for retry
+//   - gue_queue_oldest_ready_job_age - age of the oldest job eligible for execution, counted from its run_at
+//   - gue_queue_lock_latency - max run_at-to-lock latency of the jobs locked by the client since previous collection
+//
+// All the gauges have "queue" and "job-type" attributes, except for the lock latency that has only "queue" one.
+// Jobs counts are collected with aggregate queries against the whole jobs table, so in case of several application
+// replicas use WithStatsCollectorLeaderOnly to run them only on one of them. Lock latency is collected from the
+// jobs locked by the client itself, so it is reported by every replica.
+type StatsCollector struct {
+	c          *Client
+	interval   time.Duration
+	leaderOnly bool
+	logger     adapter.Logger
+	meter      metric.Meter
+
+	mu          sync.RWMutex
+	stats       []TypeStats
+	collectedAt time.Time
+	lockLatency map[string]time.Duration
+
+	// leaderConn is the connection holding leader advisory lock, set only in the leader-only mode
+	leaderConn adapter.Conn
+
+	mReady       metric.Int64ObservableGauge
+	mScheduled   metric.Int64ObservableGauge
+	mFailing     metric.Int64ObservableGauge
+	mOldestAge   metric.Int64ObservableGauge
+	mLockLatency metric.Int64ObservableGauge
+}
+
+// NewStatsCollector creates new StatsCollector for the client jobs table. Collector uses client meter,
+// unless overridden with WithStatsCollectorMeter, and does nothing until Run is called.
+func NewStatsCollector(c *Client, options ...StatsCollectorOption) (*StatsCollector, error) {
+	s := StatsCollector{
+		c:        c,
+		interval: defaultStatsInterval,
+		logger:   c.logger,
+		meter:    c.meter,
+	}
+
+	for _, option := range options {
+		option(&s)
+	}
+
+	if s.interval <= 0 {
+		return nil, errors.New("stats collection interval must be positive")
+	}
+
+	return &s, s.initMetrics()
+}
+
+// Run collects stats every interval until the ctx is done. Collection errors are logged and do not stop
+// the collector.
+func (s *StatsCollector) Run(ctx context.Context) error {
+	defer s.releaseLeadership()
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-timer.C:
+			s.collect(ctx)
+			timer.Reset(s.interval)
+		}
+	}
+}
+
+func (s *StatsCollector) collect(ctx context.Context) {
+	lockLatency := s.c.takeLockLatency()
+
+	var q adapter.Queryable = s.c.pool
+	if s.leaderOnly {
+		isLeader, err := s.ensureLeadership(ctx)
+		if err != nil {
+			s.logger.Error("Stats collector failed to acquire leadership", adapter.Err(err))
+		}
+		if !isLeader {
+			s.store(nil, time.Time{}, lockLatency)
+			return
+		}
+		q = s.leaderConn
+	}
+
+	stats, err := queryTypeStats(ctx, q, s.c.jobsTable)
+	if err != nil {
+		s.logger.Error("Stats collector failed to collect stats", adapter.Err(err))
+		if s.leaderOnly {
+			// connection may be broken, so give up leadership and try to acquire it again on the next collection
+			s.releaseLeadership()
+		}
+		s.store(nil, time.Time{}, lockLatency)
+		return
+	}
+
+	s.store(stats, time.Now(), lockLatency)
+}
+
+func (s *StatsCollector) store(stats []TypeStats, collectedAt time.Time, lockLatency map[string]time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stats = stats
+	s.collectedAt = collectedAt
+	s.lockLatency = lockLatency
+}
+
+// ensureLeadership tries to acquire session-level advisory lock on the dedicated connection, the lock is being held
+// until the collector stops or the connection breaks, so only one collector per jobs table is the leader.
+func (s *StatsCollector) ensureLeadership(ctx context.Context) (bool, error) {
+	if s.leaderConn != nil {
+		return true, nil
+	}
+
+	conn, err := s.c.pool.Acquire(ctx)
+	if err != nil {
+		return false, fmt.Errorf("could not acquire connection: %w", err)
+	}
+
+	var locked bool
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock(hashtext($1))`, s.leaderLockKey()).Scan(&locked); err != nil {
+		if rErr := conn.Release(); rErr != nil {
+			s.logger.Error("Could not release stats collector connection", adapter.Err(rErr))
+		}
+		return false, fmt.Errorf("could not try leader lock: %w", err)
+	}
+
+	if !locked {
+		return false, conn.Release()
+	}
+
+	s.leaderConn = conn
+	s.logger.Info("Stats collector became the leader")
+	return true, nil
+}
+
+func (s *StatsCollector) releaseLeadership() {
+	if s.leaderConn == nil {
+		return
+	}
+
+	// use separate context as the collector context is most probably done already at this point
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := s.leaderConn.Exec(ctx, `SELECT pg_advisory_unlock(hashtext($1))`, s.leaderLockKey()); err != nil {
+		s.logger.Error("Could not release stats collector leader lock", adapter.Err(err))
+	}
+	if err := s.leaderConn.Release(); err != nil {
+		s.logger.Error("Could not release stats collector connection", adapter.Err(err))
+	}
+
+	s.leaderConn = nil
+	s.logger.Info("Stats collector gave up the leadership")
+}
+
+func (s *StatsCollector) leaderLockKey() string {
+	return "gue-stats:" + s.c.jobsTable
+}
+
+func (s *StatsCollector) initMetrics() (err error) {
+	if s.mReady, err = s.meter.Int64ObservableGauge(
+		"gue_queue_jobs_ready",
+		metric.WithDescription("Number of jobs that are eligible for execution"),
+		metric.WithUnit("1"),
+	); err != nil {
+		return fmt.Errorf("could not register mReady metric: %w", err)
+	}
+
+	if s.mScheduled, err = s.meter.Int64ObservableGauge(
+		"gue_queue_jobs_scheduled",
+		metric.WithDescription("Number of jobs scheduled to run in the future"),
+		metric.WithUnit("1"),
+	); err != nil {
+		return fmt.Errorf("could not register mScheduled metric: %w", err)
+	}
+
+	if s.mFailing, err = s.meter.Int64ObservableGauge(
+		"gue_queue_jobs_failing",
+		metric.WithDescription("Number of jobs that failed at least once and are waiting for retry"),
+		metric.WithUnit("1"),
+	); err != nil {
+		return fmt.Errorf("could not register mFailing metric: %w", err)
+	}
+
+	if s.mOldestAge, err = s.meter.Int64ObservableGauge(
+		"gue_queue_oldest_ready_job_age",
+		metric.WithDescription("Age of the oldest job that is eligible for execution, counted from its run_at"),
+		metric.WithUnit("ms"),
+	); err != nil {
+		return fmt.Errorf("could not register mOldestAge metric: %w", err)
+	}
+
+	if s.mLockLatency, err = s.meter.Int64ObservableGauge(
+		"gue_queue_lock_latency",
+		metric.WithDescription("Max run_at-to-lock latency of the jobs locked since previous stats collection"),
+		metric.WithUnit("ms"),
+	); err != nil {
+		return fmt.Errorf("could not register mLockLatency metric: %w", err)
+	}
+
+	if _, err = s.meter.RegisterCallback(
+		s.observe,
+		s.mReady, s.mScheduled, s.mFailing, s.mOldestAge, s.mLockLatency,
+	); err != nil {
+		return fmt.Errorf("could not register stats metrics callback: %w", err)
+	}
+
+	return nil
+}
+
+func (s *StatsCollector) observe(_ context.Context, o metric.Observer) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, st := range s.stats {
+		attrs := metric.WithAttributes(attrQueue.String(st.Queue), attrJobType.String(st.Type))
+
+		o.ObserveInt64(s.mReady, st.Ready, attrs)
+		o.ObserveInt64(s.mScheduled, st.Scheduled, attrs)
+		o.ObserveInt64(s.mFailing, st.Failing, attrs)
+
+		var age time.Duration
+		if st.OldestReadyRunAt.Valid {
+			age = s.collectedAt.Sub(st.OldestReadyRunAt.Time)
+		}
+		o.ObserveInt64(s.mOldestAge, age.Milliseconds(), attrs)
+	}
+
+	for queue, latency := range s.lockLatency {
+		o.ObserveInt64(s.mLockLatency, latency.Milliseconds(), metric.WithAttributes(attrQueue.String(queue)))
+	}
+
+	return nil
+}
+
+// recordLockLatency stores the max run_at-to-lock latency per queue to be reported by the StatsCollector.
+func (c *Client) recordLockLatency(queue string, runAt time.Time) {
+	latency := time.Since(runAt)
+	if latency < 0 {
+		latency = 0
+	}
+
+	c.lockLatencyMu.Lock()
+	defer c.lockLatencyMu.Unlock()
+
+	if c.lockLatency == nil {
+		c.lockLatency = make(map[string]time.Duration)
+	}
+	if current, ok := c.lockLatency[queue]; !ok || latency > current {
+		c.lockLatency[queue] = latency
+	}
+}
+
+// takeLockLatency returns lock latencies recorded since the previous call.
+func (c *Client) takeLockLatency() map[string]time.Duration {
+	c.lockLatencyMu.Lock()
+	defer c.lockLatencyMu.Unlock()
+
+	latency := c.lockLatency
+	c.lockLatency = nil
+	return latency
+}
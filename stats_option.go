@@ -0,0 +1,42 @@
+package gue
+
+import (
+	"time"
+
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/vgarvardt/gue/v5/adapter"
+)
+
+// StatsCollectorOption defines a type that allows to set stats collector properties during the build-time.
+type StatsCollectorOption func(*StatsCollector)
+
+// WithStatsCollectorInterval overrides default stats collection interval (30 seconds) with the given value.
+func WithStatsCollectorInterval(d time.Duration) StatsCollectorOption {
+	return func(s *StatsCollector) {
+		s.interval = d
+	}
+}
+
+// WithStatsCollectorLeaderOnly enables leader-only mode - jobs counts are collected only by the collector holding
+// Postgres advisory lock for the jobs table, so that several application replicas do not run the same aggregate
+// queries. Leader keeps one connection from the pool acquired while running.
+func WithStatsCollectorLeaderOnly() StatsCollectorOption {
+	return func(s *StatsCollector) {
+		s.leaderOnly = true
+	}
+}
+
+// WithStatsCollectorLogger sets Logger implementation to stats collector, client logger is used by default.
+func WithStatsCollectorLogger(logger adapter.Logger) StatsCollectorOption {
+	return func(s *StatsCollector) {
+		s.logger = logger
+	}
+}
+
+// WithStatsCollectorMeter sets metric.Meter instance to stats collector, client meter is used by default.
+func WithStatsCollectorMeter(meter metric.Meter) StatsCollectorOption {
+	return func(s *StatsCollector) {
+		s.meter = meter
+	}
+}
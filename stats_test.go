@@ -0,0 +1,120 @@
+package gue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/metric/noop"
+
+	"github.com/vgarvardt/gue/v5/adapter"
+	adapterTesting "github.com/vgarvardt/gue/v5/adapter/testing"
+)
+
+func TestNewStatsCollector(t *testing.T) {
+	c, err := NewClient(nil)
+	require.NoError(t, err)
+
+	s, err := NewStatsCollector(c)
+	require.NoError(t, err)
+	assert.Equal(t, defaultStatsInterval, s.interval)
+	assert.False(t, s.leaderOnly)
+	assert.Equal(t, c.meter, s.meter)
+
+	customMeter := noop.NewMeterProvider().Meter("custom")
+	s, err = NewStatsCollector(
+		c,
+		WithStatsCollectorInterval(time.Minute),
+		WithStatsCollectorLeaderOnly(),
+		WithStatsCollectorMeter(customMeter),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, s.interval)
+	assert.True(t, s.leaderOnly)
+	assert.Equal(t, customMeter, s.meter)
+
+	_, err = NewStatsCollector(c, WithStatsCollectorInterval(0))
+	assert.Error(t, err)
+}
+
+func TestClient_lockLatency(t *testing.T) {
+	c, err := NewClient(nil)
+	require.NoError(t, err)
+
+	assert.Nil(t, c.takeLockLatency())
+
+	c.recordLockLatency("q1", time.Now().Add(-time.Minute))
+	c.recordLockLatency("q1", time.Now().Add(-time.Second))
+	c.recordLockLatency("q2", time.Now().Add(time.Hour))
+
+	latency := c.takeLockLatency()
+	require.Len(t, latency, 2)
+	assert.GreaterOrEqual(t, latency["q1"], time.Minute)
+	assert.Equal(t, time.Duration(0), latency["q2"])
+
+	assert.Nil(t, c.takeLockLatency())
+}
+
+func TestStatsCollector_collect(t *testing.T) {
+	for name, openFunc := range adapterTesting.AllAdaptersOpenTestPool {
+		t.Run(name, func(t *testing.T) {
+			testStatsCollectorCollect(t, openFunc(t))
+		})
+	}
+}
+
+func testStatsCollectorCollect(t *testing.T, connPool adapter.ConnPool) {
+	ctx := context.Background()
+
+	c, err := NewClient(connPool)
+	require.NoError(t, err)
+
+	queue := "stats-" + RandomStringID()
+	now := time.Now()
+	err = c.EnqueueBatch(ctx, []*Job{
+		{Queue: queue, Type: "t1", RunAt: now.Add(-time.Minute)},
+		{Queue: queue, Type: "t1"},
+		{Queue: queue, Type: "t1", RunAt: now.Add(time.Hour)},
+		{Queue: queue, Type: "t2"},
+	})
+	require.NoError(t, err)
+
+	leader, err := NewStatsCollector(c, WithStatsCollectorLeaderOnly())
+	require.NoError(t, err)
+	follower, err := NewStatsCollector(c, WithStatsCollectorLeaderOnly())
+	require.NoError(t, err)
+
+	leader.collect(ctx)
+	defer leader.releaseLeadership()
+	follower.collect(ctx)
+
+	assert.Empty(t, follower.stats)
+
+	var t1, t2 *TypeStats
+	for i := range leader.stats {
+		if leader.stats[i].Queue != queue {
+			continue
+		}
+		switch leader.stats[i].Type {
+		case "t1":
+			t1 = &leader.stats[i]
+		case "t2":
+			t2 = &leader.stats[i]
+		}
+	}
+	require.NotNil(t, t1)
+	require.NotNil(t, t2)
+	assert.Equal(t, int64(2), t1.Ready)
+	assert.Equal(t, int64(1), t1.Scheduled)
+	assert.Equal(t, int64(0), t1.Failing)
+	assert.True(t, t1.OldestReadyRunAt.Valid)
+	assert.Equal(t, int64(1), t2.Ready)
+
+	// once the leader gives up, follower takes over
+	leader.releaseLeadership()
+	follower.collect(ctx)
+	defer follower.releaseLeadership()
+	assert.NotEmpty(t, follower.stats)
+}
@@ -0,0 +1,97 @@
+package gue
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+
+	"github.com/vgarvardt/gue/v5/adapter"
+)
+
+// UniqueMode defines what happens when the Job with the UniqueKey is enqueued while the pending Job of the same type
+// with the same key exists already, see Job.UniqueKey.
+type UniqueMode int
+
+const (
+	// UniqueModeDrop drops the enqueued Job, the pending one is left unchanged. Enqueued Job ID is left zero.
+	UniqueModeDrop UniqueMode = iota
+	// UniqueModeDebounce moves the pending Job run at to the enqueued Job run at and replaces its args, so that
+	// the series of enqueues collapses into the single Job that runs after the last of them, e.g. with the
+	// run at set to 30 seconds from now.
+	UniqueModeDebounce
+	// UniqueModeThrottle keeps the earliest run at of the pending and the enqueued Job and the pending Job args,
+	// so that the series of enqueues collapses into the single Job that runs after the first of them.
+	UniqueModeThrottle
+)
+
+// execEnqueueUnique inserts the job with the unique key or collapses it into the pending job of the same type
+// with the same key according to the job unique mode. Enqueues of the same key are serialised with the transaction
+// advisory lock, so q must be the transaction. Jobs locked by the workers are skipped instead of waiting for them
+// to finish, so the new pending job is inserted while the job with the same key is being worked, e.g. to handle
+// the changes made during the run. Job ID and run at are set to the ones of the pending job the enqueued one
+// collapsed into. Pending job keeps its priority, so its aged run at is recalculated from the new run at in the DB.
+func (c *Client) execEnqueueUnique(ctx context.Context, j *Job, q adapter.Queryable, args []any) error {
+	if j.UniqueMode != UniqueModeDrop && j.UniqueMode != UniqueModeDebounce && j.UniqueMode != UniqueModeThrottle {
+		return fmt.Errorf("unknown job unique mode: %d", j.UniqueMode)
+	}
+
+	if _, err := q.Exec(
+		ctx, `SELECT pg_advisory_xact_lock(hashtext($1), hashtext($2))`, j.Type, j.UniqueKey,
+	); err != nil {
+		return fmt.Errorf("could not acquire job unique key lock: %w", err)
+	}
+
+	var (
+		pendingID    ulid.ULID
+		pendingRunAt time.Time
+	)
+	err := q.QueryRow(ctx, `SELECT job_id, run_at FROM `+c.jobsTable+`
+WHERE job_type = $1 AND unique_key = $2
+ORDER BY run_at
+LIMIT 1
+FOR UPDATE SKIP LOCKED`, j.Type, j.UniqueKey).Scan(&pendingID, &pendingRunAt)
+	if errors.Is(err, adapter.ErrNoRows) {
+		_, err = q.Exec(ctx, `INSERT INTO `+c.jobsTable+`
+(job_id, queue, priority, run_at, job_type, args, created_at, updated_at, backoff_name, expires_at, aged_run_at,
+fairness_key, unique_key)
+VALUES
+($1, $2, $3, $4, $5, $6, $7, $7, $8, $9, $10, $11, $12)
+`, args...)
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("could not find pending unique job: %w", err)
+	}
+
+	switch j.UniqueMode {
+	case UniqueModeDebounce:
+		_, err = q.Exec(ctx, `UPDATE `+c.jobsTable+`
+SET run_at = $1, args = $2, updated_at = $3, aged_run_at = `+agedRunAtSQL(`$1::TIMESTAMPTZ`, `priority`, 4)+`
+WHERE job_id = $5`, j.RunAt, j.Args, j.UpdatedAt, c.priorityAging.Microseconds(), pendingID.String())
+		pendingRunAt = j.RunAt
+	case UniqueModeThrottle:
+		err = q.QueryRow(ctx, `UPDATE `+c.jobsTable+`
+SET run_at = LEAST(run_at, $1), updated_at = $2, aged_run_at = `+agedRunAtSQL(`LEAST(run_at, $1)`, `priority`, 3)+`
+WHERE job_id = $4
+RETURNING run_at`, j.RunAt, j.UpdatedAt, c.priorityAging.Microseconds(), pendingID.String()).Scan(&pendingRunAt)
+	default:
+		// drop mode leaves the pending job unchanged and the enqueued job ID zero
+		j.ID = ulid.ULID{}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not update pending unique job: %w", err)
+	}
+
+	j.ID, j.RunAt = pendingID, pendingRunAt
+	return nil
+}
+
+// uniqueKey returns the job unique key to be stored, NULL for the job without the key.
+func (j *Job) uniqueKey() sql.NullString {
+	return sql.NullString{String: j.UniqueKey, Valid: j.UniqueKey != ""}
+}
@@ -0,0 +1,257 @@
+package gue
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vgarvardt/gue/v5/adapter"
+	adapterTesting "github.com/vgarvardt/gue/v5/adapter/testing"
+)
+
+func TestClient_execEnqueueUnique(t *testing.T) {
+	ctx := context.Background()
+	pendingID := ulid.MustParse("01H5Q7BZ7CDGA3KQWQ7PGXF8DK")
+	pendingRunAt := time.Now().Add(time.Minute).UTC()
+
+	// newTx returns the transaction mock with the pending job of the same key if pending is true
+	newTx := func(pending bool) *adapterTesting.Tx {
+		row := new(adapterTesting.Row)
+		if pending {
+			row.On("Scan", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				*args.Get(0).(*ulid.ULID) = pendingID
+				*args.Get(1).(*time.Time) = pendingRunAt
+			}).Return(nil)
+		} else {
+			row.On("Scan", mock.Anything, mock.Anything).Return(adapter.ErrNoRows)
+		}
+
+		tx := new(adapterTesting.Tx)
+		tx.Queryable.On("Exec", ctx, `SELECT pg_advisory_xact_lock(hashtext($1), hashtext($2))`, []any{"MyJob", "tenant-1"}).
+			Return(nil, nil).Once()
+		tx.Queryable.On("QueryRow", ctx, mock.MatchedBy(func(sql string) bool {
+			return strings.HasPrefix(sql, "SELECT job_id, run_at") && strings.Contains(sql, "FOR UPDATE SKIP LOCKED")
+		}), []any{"MyJob", "tenant-1"}).Return(row).Once()
+		tx.On("Commit", ctx).Return(nil).Once()
+
+		return tx
+	}
+
+	t.Run("inserted", func(t *testing.T) {
+		tx := newTx(false)
+		tx.Queryable.On("Exec", ctx, mock.MatchedBy(func(sql string) bool {
+			return strings.HasPrefix(sql, `INSERT INTO "gue_jobs"`) && strings.Contains(sql, "unique_key")
+		}), mock.Anything).Return(nil, nil).Once()
+
+		pool := new(adapterTesting.ConnPool)
+		pool.On("Begin", ctx).Return(tx, nil)
+
+		c, err := NewClient(pool)
+		require.NoError(t, err)
+
+		j := &Job{Type: "MyJob", UniqueKey: "tenant-1", UniqueMode: UniqueModeDebounce}
+		require.NoError(t, c.Enqueue(ctx, j))
+		assert.NotEqual(t, ulid.ULID{}, j.ID)
+		assert.NotEqual(t, pendingID, j.ID)
+		tx.AssertExpectations(t)
+		tx.Queryable.AssertExpectations(t)
+	})
+
+	t.Run("dropped", func(t *testing.T) {
+		tx := newTx(true)
+
+		pool := new(adapterTesting.ConnPool)
+		pool.On("Begin", ctx).Return(tx, nil)
+
+		c, err := NewClient(pool)
+		require.NoError(t, err)
+
+		j := &Job{Type: "MyJob", UniqueKey: "tenant-1"}
+		require.NoError(t, c.Enqueue(ctx, j))
+		assert.Equal(t, ulid.ULID{}, j.ID)
+		tx.AssertExpectations(t)
+		tx.Queryable.AssertExpectations(t)
+	})
+
+	t.Run("debounced", func(t *testing.T) {
+		tx := newTx(true)
+		tx.Queryable.On("Exec", ctx, mock.MatchedBy(func(sql string) bool {
+			return strings.HasPrefix(sql, `UPDATE "gue_jobs"`) && strings.Contains(sql, "SET run_at = $1, args = $2")
+		}), mock.MatchedBy(func(args []any) bool {
+			return len(args) == 5 && args[4] == pendingID.String()
+		})).Return(nil, nil).Once()
+
+		pool := new(adapterTesting.ConnPool)
+		pool.On("Begin", ctx).Return(tx, nil)
+
+		c, err := NewClient(pool)
+		require.NoError(t, err)
+
+		runAt := time.Now().Add(time.Hour)
+		j := &Job{Type: "MyJob", UniqueKey: "tenant-1", UniqueMode: UniqueModeDebounce, RunAt: runAt}
+		require.NoError(t, c.Enqueue(ctx, j))
+		assert.Equal(t, pendingID, j.ID)
+		assert.Equal(t, runAt, j.RunAt)
+		tx.AssertExpectations(t)
+		tx.Queryable.AssertExpectations(t)
+	})
+
+	t.Run("throttled", func(t *testing.T) {
+		row := new(adapterTesting.Row)
+		row.On("Scan", mock.Anything).Run(func(args mock.Arguments) {
+			*args.Get(0).(*time.Time) = pendingRunAt
+		}).Return(nil)
+
+		tx := newTx(true)
+		tx.Queryable.On("QueryRow", ctx, mock.MatchedBy(func(sql string) bool {
+			return strings.HasPrefix(sql, `UPDATE "gue_jobs"`) && strings.Contains(sql, "SET run_at = LEAST(run_at, $1)") &&
+				strings.Contains(sql, "RETURNING run_at")
+		}), mock.MatchedBy(func(args []any) bool {
+			return len(args) == 4 && args[3] == pendingID.String()
+		})).Return(row).Once()
+
+		pool := new(adapterTesting.ConnPool)
+		pool.On("Begin", ctx).Return(tx, nil)
+
+		c, err := NewClient(pool)
+		require.NoError(t, err)
+
+		j := &Job{Type: "MyJob", UniqueKey: "tenant-1", UniqueMode: UniqueModeThrottle, RunAt: time.Now().Add(time.Hour)}
+		require.NoError(t, c.Enqueue(ctx, j))
+		assert.Equal(t, pendingID, j.ID)
+		assert.Equal(t, pendingRunAt, j.RunAt)
+		tx.AssertExpectations(t)
+		tx.Queryable.AssertExpectations(t)
+	})
+
+	t.Run("unknown mode", func(t *testing.T) {
+		tx := new(adapterTesting.Tx)
+		tx.On("Rollback", ctx).Return(nil).Once()
+
+		pool := new(adapterTesting.ConnPool)
+		pool.On("Begin", ctx).Return(tx, nil)
+
+		c, err := NewClient(pool)
+		require.NoError(t, err)
+
+		require.Error(t, c.Enqueue(ctx, &Job{Type: "MyJob", UniqueKey: "tenant-1", UniqueMode: 42}))
+		tx.AssertExpectations(t)
+		tx.Queryable.AssertNotCalled(t, "Exec", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestEnqueueUnique(t *testing.T) {
+	for name, openFunc := range adapterTesting.AllAdaptersOpenTestPool {
+		t.Run(name, func(t *testing.T) {
+			testEnqueueUnique(t, openFunc(t))
+		})
+	}
+}
+
+func testEnqueueUnique(t *testing.T, connPool adapter.ConnPool) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	c, err := NewClient(connPool)
+	require.NoError(t, err)
+
+	t.Run("drop", func(t *testing.T) {
+		j1 := &Job{Type: "MyJob", UniqueKey: "drop", RunAt: now, Args: []byte("first")}
+		require.NoError(t, c.Enqueue(ctx, j1))
+		require.NotEqual(t, ulid.ULID{}, j1.ID)
+
+		j2 := &Job{Type: "MyJob", UniqueKey: "drop", RunAt: now.Add(time.Minute), Args: []byte("second")}
+		require.NoError(t, c.Enqueue(ctx, j2))
+		assert.Equal(t, ulid.ULID{}, j2.ID)
+
+		// same key of the other type does not conflict
+		j3 := &Job{Type: "OtherJob", UniqueKey: "drop"}
+		require.NoError(t, c.Enqueue(ctx, j3))
+		assert.NotEqual(t, ulid.ULID{}, j3.ID)
+
+		stored, err := c.GetJob(ctx, j1.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "first", string(stored.Args))
+		assert.Equal(t, "drop", stored.UniqueKey)
+		assert.True(t, now.Equal(stored.RunAt))
+	})
+
+	t.Run("debounce", func(t *testing.T) {
+		j1 := &Job{Type: "MyJob", UniqueKey: "debounce", UniqueMode: UniqueModeDebounce, RunAt: now, Args: []byte("first")}
+		require.NoError(t, c.Enqueue(ctx, j1))
+
+		j2 := &Job{
+			Type: "MyJob", UniqueKey: "debounce", UniqueMode: UniqueModeDebounce, RunAt: now.Add(time.Minute),
+			Args: []byte("second"),
+		}
+		require.NoError(t, c.Enqueue(ctx, j2))
+		assert.Equal(t, j1.ID, j2.ID)
+		assert.True(t, now.Add(time.Minute).Equal(j2.RunAt))
+
+		stored, err := c.GetJob(ctx, j1.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "second", string(stored.Args))
+		assert.True(t, now.Add(time.Minute).Equal(stored.RunAt))
+	})
+
+	t.Run("throttle", func(t *testing.T) {
+		j1 := &Job{Type: "MyJob", UniqueKey: "throttle", UniqueMode: UniqueModeThrottle, RunAt: now, Args: []byte("first")}
+		require.NoError(t, c.Enqueue(ctx, j1))
+
+		j2 := &Job{
+			Type: "MyJob", UniqueKey: "throttle", UniqueMode: UniqueModeThrottle, RunAt: now.Add(time.Minute),
+			Args: []byte("second"),
+		}
+		require.NoError(t, c.Enqueue(ctx, j2))
+		assert.Equal(t, j1.ID, j2.ID)
+		assert.True(t, now.Equal(j2.RunAt))
+
+		stored, err := c.GetJob(ctx, j1.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "first", string(stored.Args))
+		assert.True(t, now.Equal(stored.RunAt))
+	})
+
+	t.Run("running job is not pending", func(t *testing.T) {
+		j1 := &Job{Type: "MyJob", UniqueKey: "running", UniqueMode: UniqueModeDebounce}
+		require.NoError(t, c.Enqueue(ctx, j1))
+
+		locked, err := c.LockJobByID(ctx, j1.ID)
+		require.NoError(t, err)
+
+		// enqueue does not wait for the running job and adds the new pending one
+		j2 := &Job{Type: "MyJob", UniqueKey: "running", UniqueMode: UniqueModeDebounce}
+		require.NoError(t, c.Enqueue(ctx, j2))
+		assert.NotEqual(t, ulid.ULID{}, j2.ID)
+		assert.NotEqual(t, j1.ID, j2.ID)
+
+		// the next enqueue collapses into the pending one
+		j3 := &Job{Type: "MyJob", UniqueKey: "running", UniqueMode: UniqueModeDebounce}
+		require.NoError(t, c.Enqueue(ctx, j3))
+		assert.Equal(t, j2.ID, j3.ID)
+
+		require.NoError(t, locked.Delete(ctx))
+		require.NoError(t, locked.Done(ctx))
+	})
+
+	t.Run("worked job is not pending anymore", func(t *testing.T) {
+		j1 := &Job{Type: "MyJob", UniqueKey: "worked"}
+		require.NoError(t, c.Enqueue(ctx, j1))
+
+		locked, err := c.LockJobByID(ctx, j1.ID)
+		require.NoError(t, err)
+		require.NoError(t, locked.Delete(ctx))
+		require.NoError(t, locked.Done(ctx))
+
+		j2 := &Job{Type: "MyJob", UniqueKey: "worked"}
+		require.NoError(t, c.Enqueue(ctx, j2))
+		assert.NotEqual(t, ulid.ULID{}, j2.ID)
+		assert.NotEqual(t, j1.ID, j2.ID)
+	})
+}
@@ -6,7 +6,10 @@ import (
 	"errors"
 	"fmt"
 	"runtime"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.opentelemetry.io/otel/attribute"
@@ -14,12 +17,11 @@ import (
 	"go.opentelemetry.io/otel/metric/noop"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/multierr"
-	"golang.org/x/sync/errgroup"
 
 	"github.com/vgarvardt/gue/v5/adapter"
 )
 
-// PollStrategy determines how the DB is queried for the next job to work on
+// PollStrategy determines how the DB is queried for the next job to work on, it is the built-in Poller
 type PollStrategy string
 
 const (
@@ -28,12 +30,18 @@ const (
 
 	defaultPanicStackBufSize = 1024
 
+	defaultPauseCheckInterval = 10 * time.Second
+
 	// PriorityPollStrategy cares about the priority first to lock top priority jobs first even if there are available
 	// ones that should be executed earlier but with lower priority.
 	PriorityPollStrategy PollStrategy = "OrderByPriority"
 	// RunAtPollStrategy cares about the scheduled time first to lock earliest to execute jobs first even if there
 	// are ones with a higher priority scheduled to a later time but already eligible for execution
 	RunAtPollStrategy PollStrategy = "OrderByRunAtPriority"
+	// PriorityAgingPollStrategy cares about the effective priority that increases while the job waits after its
+	// scheduled time, so that the steady stream of the high priority jobs does not starve the low priority ones.
+	// Job priority increases by one every priority aging duration, see WithClientPriorityAging.
+	PriorityAgingPollStrategy PollStrategy = "OrderByAgedRunAtPriority"
 )
 
 // WorkFunc is the handler function that performs the Job. If an error is returned, the Job
@@ -44,6 +52,15 @@ const (
 // behaviour. Please never do this.
 type WorkFunc func(ctx context.Context, j *Job) error
 
+// BatchWorkFunc is the handler function that performs the batch of Jobs of the same type at once, e.g. with a single
+// bulk API call. It returns either nil if all the Jobs succeeded, or the slice of the same length as jobs with
+// the individual Job errors, so that every Job is either deleted or re-enqueued with the backoff on its own.
+// Returning the slice of the other length fails all the Jobs.
+//
+// Jobs share the same transaction, see Client.LockJobs. Changes made by the handler within the Job.Tx are committed
+// regardless of the individual Job results.
+type BatchWorkFunc func(ctx context.Context, jobs []*Job) []error
+
 // HookFunc is a function that may react to a Job lifecycle events. All the callbacks are being executed synchronously,
 // so be careful with the long-running locking operations. Hooks do not return an error, therefore they can not and
 // must not be used to affect the Job execution flow, e.g. cancel it - this is the WorkFunc responsibility.
@@ -60,34 +77,92 @@ type WorkMap map[string]WorkFunc
 // pollFunc is a function that queries the DB for the next job to work on
 type pollFunc func(context.Context, string) (*Job, error)
 
+// batchPollFunc is a function that queries the DB for the next batch of jobs to work on
+type batchPollFunc func(context.Context, string, int) ([]*Job, error)
+
 // Worker is a single worker that pulls jobs off the specified queue. If no Job
 // is found, the Worker will sleep for interval seconds.
 type Worker struct {
-	wm           WorkMap
-	interval     time.Duration
-	queue        string
-	c            *Client
-	id           string
-	logger       adapter.Logger
-	mu           sync.Mutex
-	running      bool
-	pollStrategy PollStrategy
-	pollFunc     pollFunc
+	// cfgMu guards the configuration that can be changed at runtime - work map and poll interval
+	cfgMu    sync.RWMutex
+	wm       WorkMap
+	interval time.Duration
+	queue    string
+	c        *Client
+	id       string
+	logger   adapter.Logger
+	mu       sync.Mutex
+	running  bool
+	poller   Poller
+	pollFunc pollFunc
+
+	batchPollFunc        batchPollFunc
+	lockBatchSize        int
+	lockBatchConcurrency int
+
+	batchHandlers map[string]batchHandler
+	// batchTypes are the sorted job types with the registered batch handlers
+	batchTypes []string
+
+	// knownTypesOnly limits the jobs locked for the WorkMap handlers to the job types present in the WorkMap
+	knownTypesOnly bool
+	// allowedTypes and deniedTypes limit the jobs locked by the worker to the job types on the allow list
+	// and not on the deny list, allow list is ignored when empty
+	allowedTypes []string
+	deniedTypes  []string
+	// excludeTypes are the job types not to lock for the WorkMap handlers - batch and denied ones
+	excludeTypes []string
 
 	graceful    bool
 	gracefulCtx func() context.Context
 
+	drainTimeout  time.Duration
+	drainExpired  atomic.Bool
+	interruptedMu sync.Mutex
+	interrupted   []WorkerJob
+
 	tracer trace.Tracer
 	meter  metric.Meter
 
 	hooksJobLocked      []HookFunc
 	hooksUnknownJobType []HookFunc
 	hooksJobDone        []HookFunc
+	hooksJobRetried     []ErrorHookFunc
+	hooksJobDiscarded   []ErrorHookFunc
+	hooksJobPanicked    []HookFunc
+	hooksJobTimedOut    []HookFunc
+	hooksLockEmpty      []HookFunc
+	hooksJobExpired     []HookFunc
+
+	errorClassifier ErrorClassifier
 
 	mWorked   metric.Int64Counter
 	mDuration metric.Int64Histogram
+	mWait     metric.Int64Histogram
+	mExpired  metric.Int64Counter
+	mPaused   metric.Int64ObservableGauge
 
 	panicStackBufSize int
+
+	pauseCheckInterval time.Duration
+	pauseCheckedAt     time.Time
+	pausedQueue        atomic.Bool
+	pausedTypes        string
+
+	heartbeatInterval time.Duration
+	registration      *workerRegistration
+
+	// maxJobDuration is the job duration after which working worker is considered stalled, disabled when not positive
+	maxJobDuration time.Duration
+	// health state, times are stored as unix nanoseconds
+	lastPollAt        atomic.Int64
+	lastPollAttemptAt atomic.Int64
+	workingSince      atomic.Int64
+	lockErrors        atomic.Int64
+
+	// successful polls counters used by the worker pool autoscaler
+	polls     atomic.Int64
+	idlePolls atomic.Int64
 }
 
 // NewWorker returns a Worker that fetches Jobs from the Client and executes
@@ -100,35 +175,147 @@ type Worker struct {
 // WithWorkerQueue option.
 func NewWorker(c *Client, wm WorkMap, options ...WorkerOption) (*Worker, error) {
 	w := Worker{
-		interval:     defaultPollInterval,
-		queue:        defaultQueueName,
-		c:            c,
-		id:           RandomStringID(),
-		wm:           wm,
-		logger:       adapter.NoOpLogger{},
-		pollStrategy: PriorityPollStrategy,
-		tracer:       trace.NewNoopTracerProvider().Tracer("noop"),
-		meter:        noop.NewMeterProvider().Meter("noop"),
-
-		panicStackBufSize: defaultPanicStackBufSize,
+		interval: defaultPollInterval,
+		queue:    defaultQueueName,
+		c:        c,
+		id:       RandomStringID(),
+		wm:       wm,
+		logger:   adapter.NoOpLogger{},
+		poller:   PriorityPollStrategy,
+		tracer:   trace.NewNoopTracerProvider().Tracer("noop"),
+		meter:    noop.NewMeterProvider().Meter("noop"),
+
+		panicStackBufSize:  defaultPanicStackBufSize,
+		pauseCheckInterval: defaultPauseCheckInterval,
 	}
 
 	for _, option := range options {
 		option(&w)
 	}
 
-	switch w.pollStrategy {
-	case RunAtPollStrategy:
-		w.pollFunc = w.c.LockNextScheduledJob
-	default:
-		w.pollFunc = w.c.LockJob
+	allowed := PollQuery{Types: w.allowedTypes, ExcludeTypes: w.deniedTypes}
+	for jobType, h := range w.batchHandlers {
+		if h.maxSize < 1 {
+			return nil, fmt.Errorf("batch size for the job type %q must be positive", jobType)
+		}
+		if allowed.AllowsType(jobType) {
+			w.batchTypes = append(w.batchTypes, jobType)
+		}
+	}
+	sort.Strings(w.batchTypes)
+	w.excludeTypes = append(append([]string(nil), w.batchTypes...), w.deniedTypes...)
+
+	// jobs of the types with the batch handlers are locked by the type separately
+	w.pollFunc = func(ctx context.Context, queue string) (*Job, error) {
+		q, ok := w.pollQuery(queue, 1)
+		if !ok {
+			return nil, nil
+		}
+
+		jobs, err := w.poller.Poll(ctx, w.c, q)
+		if len(jobs) == 0 {
+			return nil, err
+		}
+		return jobs[0], err
+	}
+	w.batchPollFunc = func(ctx context.Context, queue string, n int) ([]*Job, error) {
+		q, ok := w.pollQuery(queue, n)
+		if !ok {
+			return nil, nil
+		}
+
+		return w.poller.Poll(ctx, w.c, q)
 	}
 
 	w.logger = w.logger.With(adapter.F("worker-id", w.id))
 
+	if w.heartbeatInterval > 0 {
+		w.registration = newWorkerRegistration(&w)
+	}
+
 	return &w, w.initMetrics()
 }
 
+// SetPollInterval changes worker poll interval at runtime, new interval takes effect after the current one expires.
+func (w *Worker) SetPollInterval(d time.Duration) {
+	w.cfgMu.Lock()
+	defer w.cfgMu.Unlock()
+
+	w.interval = d
+}
+
+// Register adds the job type handler to the worker WorkMap at runtime or replaces existing one, e.g. to enable
+// feature-flagged job types without restarting the process. WorkMap passed to the worker on creation is not modified.
+func (w *Worker) Register(jobType string, fn WorkFunc) {
+	w.cfgMu.Lock()
+	defer w.cfgMu.Unlock()
+
+	w.wm = w.wm.with(jobType, fn)
+}
+
+func (w *Worker) pollInterval() time.Duration {
+	w.cfgMu.RLock()
+	defer w.cfgMu.RUnlock()
+
+	return w.interval
+}
+
+func (w *Worker) workFunc(jobType string) (WorkFunc, bool) {
+	w.cfgMu.RLock()
+	defer w.cfgMu.RUnlock()
+
+	wf, ok := w.wm[jobType]
+	return wf, ok
+}
+
+// pollQuery returns the query to poll up to limit jobs for the WorkMap handlers with, limited to the known
+// and allowed job types. It returns false when the worker is not allowed to lock any job type.
+func (w *Worker) pollQuery(queue string, limit int) (PollQuery, bool) {
+	q := PollQuery{Queue: queue, Limit: limit, Types: w.allowedTypes, ExcludeTypes: w.excludeTypes}
+	if !w.knownTypesOnly {
+		return q, true
+	}
+
+	w.cfgMu.RLock()
+	known := make([]string, 0, len(w.wm))
+	for jobType := range w.wm {
+		if q.AllowsType(jobType) {
+			known = append(known, jobType)
+		}
+	}
+	w.cfgMu.RUnlock()
+
+	sort.Strings(known)
+	q.Types = known
+
+	return q, len(known) > 0
+}
+
+// readyQuery returns the query matching the jobs the worker can lock, both for the WorkMap and the batch handlers,
+// e.g. to count the ready ones. It returns false when the worker is not allowed to lock any job type.
+func (w *Worker) readyQuery() (lockQuery, bool) {
+	if !w.knownTypesOnly {
+		return lockQuery{queue: w.queue, types: w.allowedTypes, excludeTypes: w.deniedTypes}, true
+	}
+
+	// known and batch types are filtered by the allowed and denied ones already
+	q, _ := w.pollQuery(w.queue, 1)
+	types := append(q.Types, w.batchTypes...)
+
+	return lockQuery{queue: w.queue, types: types}, len(types) > 0
+}
+
+// with returns the copy of the WorkMap with the job type handler added.
+func (wm WorkMap) with(jobType string, fn WorkFunc) WorkMap {
+	cp := make(WorkMap, len(wm)+1)
+	for k, v := range wm {
+		cp[k] = v
+	}
+	cp[jobType] = fn
+
+	return cp
+}
+
 // Run pulls jobs off the Worker's queue at its interval. This function does
 // not run in its own goroutine, so it’s possible to wait for completion. Use
 // context cancellation to shut it down.
@@ -140,68 +327,191 @@ func (w *Worker) Run(ctx context.Context) error {
 func (w *Worker) runLoop(ctx context.Context) error {
 	defer w.logger.Info("Worker finished")
 
-	timer := time.NewTimer(w.interval)
+	if w.registration != nil {
+		// registration lives until the loop exits, so that the job being worked on graceful shutdown is still visible
+		regCtx, regCancel := context.WithCancel(context.Background())
+		regDone := make(chan struct{})
+		go func() {
+			defer close(regDone)
+			w.registration.run(regCtx)
+		}()
+		defer func() {
+			regCancel()
+			<-regDone
+		}()
+	}
+
+	if w.c != nil && w.c.attemptsLog && w.c.attemptsRetention > 0 {
+		// pruner is shared by all the workers using the client
+		defer w.c.acquireAttemptsPruner()()
+	}
+
+	var drained <-chan struct{}
+	if w.drainTimeout > 0 {
+		stopDrain := make(chan struct{})
+		defer close(stopDrain)
+		drained = w.startDrain(ctx, stopDrain)
+	}
+
+	timer := time.NewTimer(w.pollInterval())
 	defer timer.Stop()
 
 	for {
-		handlerCtx := ctx
-		if w.graceful {
-			if w.gracefulCtx == nil {
-				handlerCtx = context.Background()
-			} else {
-				handlerCtx = w.gracefulCtx()
-			}
-		}
+		handlerCtx, cancelHandlerCtx := w.handlerContext(ctx, drained)
 
 		// Try to work a job
-		if w.WorkOne(handlerCtx) {
+		didWork := w.WorkOne(handlerCtx)
+		cancelHandlerCtx()
+		if didWork {
 			// Since we just did work, non-blocking check whether we should exit
 			select {
 			case <-ctx.Done():
-				return nil
+				return w.interruptedErr()
 			default:
 				continue
 			}
 		}
 
+		w.checkPaused(ctx)
+
 		// Reset or create the timer; time.After is leaky
 		// on context cancellation since we can’t stop it.
-		timer.Reset(w.interval)
+		timer.Reset(w.pollInterval())
 
 		// No work found, block until exit or timer expires
 		select {
 		case <-ctx.Done():
-			return nil
+			return w.interruptedErr()
 		case <-timer.C:
 			continue
 		}
 	}
 }
 
-// WorkOne tries to consume single message from the queue.
+// WorkOne tries to consume single message from the queue. When batch locking is enabled
+// with WithWorkerLockBatch - it tries to consume the batch of messages. Batches of the jobs with
+// the types registered with WithWorkerBatchWorkFunc are consumed first.
 func (w *Worker) WorkOne(ctx context.Context) (didWork bool) {
+	if len(w.batchTypes) > 0 && w.workBatchHandlers(ctx) {
+		return true
+	}
+
+	if w.lockBatchSize > 1 {
+		return w.workBatch(ctx)
+	}
+
 	j, err := w.pollFunc(ctx, w.queue)
+	lockedAt, found := w.polled(ctx, err, j != nil)
+	if !found {
+		return
+	}
+
+	w.workingSince.Store(lockedAt.UnixNano())
+	defer w.workingSince.Store(0)
+	w.setCurrentJob(j, lockedAt)
+	defer w.setCurrentJob(nil, time.Time{})
+
+	w.work(ctx, j, lockedAt)
+	return true
+}
+
+// workBatch locks the batch of jobs in a single transaction and works them either sequentially
+// or concurrently, see WithWorkerLockBatch.
+func (w *Worker) workBatch(ctx context.Context) bool {
+	jobs, err := w.batchPollFunc(ctx, w.queue, w.lockBatchSize)
+	lockedAt, found := w.polled(ctx, err, len(jobs) > 0)
+	if !found {
+		return false
+	}
+
+	w.workingSince.Store(lockedAt.UnixNano())
+	defer w.workingSince.Store(0)
+	defer w.setCurrentJob(nil, time.Time{})
+
+	if w.lockBatchConcurrency <= 1 {
+		for _, j := range jobs {
+			w.setCurrentJob(j, time.Now())
+			w.work(ctx, j, lockedAt)
+		}
+		return true
+	}
 
+	// registry entry holds single job, so the first batch job is reported for the whole batch
+	w.setCurrentJob(jobs[0], lockedAt)
+
+	sem := make(chan struct{}, w.lockBatchConcurrency)
+	var wg sync.WaitGroup
+	for _, j := range jobs {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(j *Job) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			w.work(ctx, j, lockedAt)
+		}(j)
+	}
+	wg.Wait()
+
+	return true
+}
+
+// polled updates worker health state and poll counters after the poll for jobs,
+// it returns the poll time and whether there are jobs to work on.
+func (w *Worker) polled(ctx context.Context, err error, found bool) (time.Time, bool) {
+	polledAt := time.Now()
+	w.lastPollAttemptAt.Store(polledAt.UnixNano())
 	if err != nil {
+		w.lockErrors.Add(1)
 		w.mWorked.Add(ctx, 1, metric.WithAttributes(attrJobType.String(""), attrSuccess.Bool(false)))
 		w.logger.Error("Worker failed to lock a job", adapter.Err(err))
 		for _, hook := range w.hooksJobLocked {
 			hook(ctx, nil, err)
 		}
-		return
+		return polledAt, false
 	}
-	if j == nil {
-		return // no job was available
+
+	w.lastPollAt.Store(polledAt.UnixNano())
+	w.lockErrors.Store(0)
+	w.polls.Add(1)
+	if !found {
+		w.idlePolls.Add(1) // no job was available
+		for _, hook := range w.hooksLockEmpty {
+			hook(ctx, nil, nil)
+		}
 	}
 
+	return polledAt, found
+}
+
+func (w *Worker) setCurrentJob(j *Job, lockedAt time.Time) {
+	if w.registration != nil {
+		w.registration.setCurrentJob(j, lockedAt)
+	}
+}
+
+// work performs the locked job and finishes it - deletes it on success or reschedules on failure.
+func (w *Worker) work(ctx context.Context, j *Job, lockedAt time.Time) {
+	ll := w.logger.With(adapter.F("job-id", j.ID.String()), adapter.F("job-type", j.Type))
+	if w.interrupt(ctx, ll, j, lockedAt) {
+		// drain timeout expired before the job was started, e.g. it is the rest of the locked batch
+		return
+	}
+	w.prepareJob(j)
+
 	processingStartedAt := time.Now()
+	j.startAttempt(w.id, processingStartedAt)
+	w.mWait.Record(
+		ctx,
+		j.waitDuration(lockedAt).Milliseconds(),
+		metric.WithAttributes(attrQueue.String(j.Queue), attrJobType.String(j.Type), attrAttempt.Int64(int64(j.ErrorCount)+1)),
+	)
+
 	ctx, span := w.tracer.Start(ctx, "Worker.WorkOne", trace.WithAttributes(
 		attribute.String("job-type", j.Type),
 	))
 	defer span.End()
 
-	ll := w.logger.With(adapter.F("job-id", j.ID.String()), adapter.F("job-type", j.Type))
-
 	defer func() {
 		if err := j.Done(ctx); err != nil {
 			span.RecordError(fmt.Errorf("failed to mark job as done: %w", err))
@@ -216,13 +526,25 @@ func (w *Worker) WorkOne(ctx context.Context) (didWork bool) {
 	}()
 	defer w.recoverPanic(ctx, ll, j)
 
+	if w.expire(ctx, ll, j) {
+		return
+	}
+
 	for _, hook := range w.hooksJobLocked {
 		hook(ctx, j, nil)
 	}
 
-	didWork = true
+	if w.lockBatchConcurrency <= 1 {
+		// job savepoint is opened only for the sequentially worked batch jobs, as the batch transaction is shared
+		if err := j.openSavepoint(ctx); err != nil {
+			w.mWorked.Add(ctx, 1, metric.WithAttributes(attrJobType.String(j.Type), attrSuccess.Bool(false)))
+			span.RecordError(err)
+			ll.Error("Failed to open job savepoint", adapter.Err(err))
+			return
+		}
+	}
 
-	wf, ok := w.wm[j.Type]
+	wf, ok := w.workFunc(j.Type)
 	if !ok {
 		w.mWorked.Add(ctx, 1, metric.WithAttributes(attrJobType.String(j.Type), attrSuccess.Bool(false)))
 
@@ -230,7 +552,7 @@ func (w *Worker) WorkOne(ctx context.Context) (didWork bool) {
 		ll.Error("Got a job with unknown type")
 
 		errUnknownType := fmt.Errorf("worker[id=%s] unknown job type: %q", w.id, j.Type)
-		if err = j.Error(ctx, errUnknownType); err != nil {
+		if err := j.Error(ctx, errUnknownType); err != nil {
 			span.RecordError(fmt.Errorf("failed to mark job as error: %w", err))
 			ll.Error("Got an error on setting an error to unknown job", adapter.Err(err))
 		}
@@ -242,13 +564,29 @@ func (w *Worker) WorkOne(ctx context.Context) (didWork bool) {
 		return
 	}
 
-	if err = wf(ctx, j); err != nil {
+	err := wf(ctx, j)
+	if w.interruptFinished(ctx, ll, j, lockedAt, err) {
+		return
+	}
+
+	w.finishJob(ctx, span, ll, j, err)
+}
+
+// finishJob applies the job handler result - reschedules the errored job or deletes the finished one.
+func (w *Worker) finishJob(ctx context.Context, span trace.Span, ll adapter.Logger, j *Job, err error) {
+	if err != nil {
 		w.mWorked.Add(ctx, 1, metric.WithAttributes(attrJobType.String(j.Type), attrSuccess.Bool(false)))
 
 		for _, hook := range w.hooksJobDone {
 			hook(ctx, j, err)
 		}
 
+		if errors.Is(err, context.DeadlineExceeded) {
+			for _, hook := range w.hooksJobTimedOut {
+				hook(ctx, j, err)
+			}
+		}
+
 		if jErr := j.Error(ctx, err); jErr != nil {
 			span.RecordError(fmt.Errorf("failed to mark job as error: %w", err))
 			ll.Error("Got an error on setting an error to an errored job", adapter.Err(jErr), adapter.F("job-error", err))
@@ -262,6 +600,9 @@ func (w *Worker) WorkOne(ctx context.Context) (didWork bool) {
 	}
 
 	err = j.Delete(ctx)
+	if err == nil {
+		err = j.logSucceeded(ctx)
+	}
 	if err != nil {
 		span.RecordError(fmt.Errorf("failed to delete finished job: %w", err))
 		ll.Error("Got an error on deleting a job", adapter.Err(err))
@@ -269,7 +610,6 @@ func (w *Worker) WorkOne(ctx context.Context) (didWork bool) {
 
 	w.mWorked.Add(ctx, 1, metric.WithAttributes(attrJobType.String(j.Type), attrSuccess.Bool(err == nil)))
 	ll.Debug("Job finished")
-	return
 }
 
 func (w *Worker) initMetrics() (err error) {
@@ -289,9 +629,86 @@ func (w *Worker) initMetrics() (err error) {
 		return fmt.Errorf("could not register mDuration metric: %w", err)
 	}
 
+	if w.mWait, err = w.meter.Int64Histogram(
+		"gue_worker_jobs_wait",
+		metric.WithDescription("Time the job waited since it became eligible for execution until it was locked"),
+		metric.WithUnit("ms"),
+	); err != nil {
+		return fmt.Errorf("could not register mWait metric: %w", err)
+	}
+
+	if w.mExpired, err = w.meter.Int64Counter(
+		"gue_worker_jobs_expired",
+		metric.WithDescription("Number of jobs discarded as expired before they were worked"),
+		metric.WithUnit("1"),
+	); err != nil {
+		return fmt.Errorf("could not register mExpired metric: %w", err)
+	}
+
+	if w.mPaused, err = w.meter.Int64ObservableGauge(
+		"gue_worker_queue_paused",
+		metric.WithDescription("Whether the queue worker is working on is paused (1) or not (0)"),
+		metric.WithUnit("1"),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			var paused int64
+			if w.pausedQueue.Load() {
+				paused = 1
+			}
+			o.Observe(paused, metric.WithAttributes(attrQueue.String(w.queue)))
+			return nil
+		}),
+	); err != nil {
+		return fmt.Errorf("could not register mPaused metric: %w", err)
+	}
+
 	return nil
 }
 
+// checkPaused refreshes pause state of the worker queue and job types it can work on when there were no jobs
+// to work on, so that the state is reported in logs and metrics. Check is throttled with the pause check interval.
+func (w *Worker) checkPaused(ctx context.Context) {
+	if time.Since(w.pauseCheckedAt) < w.pauseCheckInterval {
+		return
+	}
+	w.pauseCheckedAt = time.Now()
+
+	paused, err := w.c.ListPaused(ctx)
+	if err != nil {
+		w.logger.Error("Worker failed to check paused queues and job types", adapter.Err(err))
+		return
+	}
+
+	var (
+		queuePaused bool
+		typesPaused []string
+	)
+	for _, p := range paused {
+		switch {
+		case p.Kind == PauseKindQueue && p.Name == w.queue:
+			queuePaused = true
+		case p.Kind == PauseKindJobType:
+			_, batch := w.batchHandlers[p.Name]
+			if _, ok := w.workFunc(p.Name); ok || batch {
+				typesPaused = append(typesPaused, p.Name)
+			}
+		}
+	}
+
+	if w.pausedQueue.Swap(queuePaused) != queuePaused {
+		if queuePaused {
+			w.logger.Info("Worker queue is paused, jobs are not being worked", adapter.F("queue", w.queue))
+		} else {
+			w.logger.Info("Worker queue is resumed", adapter.F("queue", w.queue))
+		}
+	}
+
+	sort.Strings(typesPaused)
+	if types := strings.Join(typesPaused, ","); types != w.pausedTypes {
+		w.pausedTypes = types
+		w.logger.Info("Worker job types pause state changed", adapter.F("paused-job-types", types))
+	}
+}
+
 // recoverPanic tries to handle panics in job execution.
 // A stacktrace is stored into Job last_error.
 func (w *Worker) recoverPanic(ctx context.Context, logger adapter.Logger, j *Job) {
@@ -299,56 +716,112 @@ func (w *Worker) recoverPanic(ctx context.Context, logger adapter.Logger, j *Job
 		ctx, span := w.tracer.Start(ctx, "Worker.recoverPanic")
 		defer span.End()
 
-		// record an error on the job with panic message and stacktrace
-		stackBuf := make([]byte, w.panicStackBufSize)
-		n := runtime.Stack(stackBuf, false)
-
-		buf := new(bytes.Buffer)
-		_, printRErr := fmt.Fprintf(buf, "%v\n", r)
-		_, printStackErr := fmt.Fprintln(buf, string(stackBuf[:n]))
-		_, printEllipsisErr := fmt.Fprintln(buf, "[...]")
-		stacktrace := buf.String()
-
-		if err := multierr.Combine(printRErr, printStackErr, printEllipsisErr); err != nil {
-			logger.Error("Could not build panicked job stacktrace", adapter.Err(err), adapter.F("runtime-stack", string(stackBuf[:n])))
-		}
+		stacktrace := w.panicStacktrace(logger, r)
 
 		w.mWorked.Add(ctx, 1, metric.WithAttributes(attrJobType.String(j.Type), attrSuccess.Bool(false)))
 		span.RecordError(errors.New("job panicked"), trace.WithAttributes(attribute.String("stacktrace", stacktrace)))
 		logger.Error("Job panicked", adapter.F("stacktrace", stacktrace))
 
-		if err := j.Error(ctx, errors.New(stacktrace)); err != nil {
+		panicErr := newPanicError(r, stacktrace)
+		for _, hook := range w.hooksJobPanicked {
+			hook(ctx, j, panicErr)
+		}
+
+		if err := j.Error(ctx, panicErr); err != nil {
 			span.RecordError(fmt.Errorf("failed to mark panicked job as error: %w", err))
 			logger.Error("Got an error on setting an error to a panicked job", adapter.Err(err))
 		}
 	}
 }
 
+// prepareJob sets the worker hooks and error classifier that are used by the job itself, e.g. in Job.Error.
+func (w *Worker) prepareJob(j *Job) {
+	if w.errorClassifier != nil {
+		j.errorClassifier = w.errorClassifier
+	}
+	j.hooksRetried = w.hooksJobRetried
+	j.hooksDiscarded = w.hooksJobDiscarded
+}
+
+// panicStacktrace builds the panic message with the stacktrace to be stored into Job last_error.
+func (w *Worker) panicStacktrace(logger adapter.Logger, r any) string {
+	// record an error on the job with panic message and stacktrace
+	stackBuf := make([]byte, w.panicStackBufSize)
+	n := runtime.Stack(stackBuf, false)
+
+	buf := new(bytes.Buffer)
+	_, printRErr := fmt.Fprintf(buf, "%v\n", r)
+	_, printStackErr := fmt.Fprintln(buf, string(stackBuf[:n]))
+	_, printEllipsisErr := fmt.Fprintln(buf, "[...]")
+
+	if err := multierr.Combine(printRErr, printStackErr, printEllipsisErr); err != nil {
+		logger.Error("Could not build panicked job stacktrace", adapter.Err(err), adapter.F("runtime-stack", string(stackBuf[:n])))
+	}
+
+	return buf.String()
+}
+
 // WorkerPool is a pool of Workers, each working jobs from the queue
 // at the specified interval using the WorkMap.
 type WorkerPool struct {
-	wm           WorkMap
-	interval     time.Duration
-	queue        string
-	c            *Client
-	workers      []*Worker
-	id           string
-	logger       adapter.Logger
-	mu           sync.Mutex
-	running      bool
-	pollStrategy PollStrategy
+	wm       WorkMap
+	interval time.Duration
+	queue    string
+	c        *Client
+	id       string
+	logger   adapter.Logger
+	mu       sync.Mutex
+	running  bool
+	poller   Poller
 
 	graceful    bool
 	gracefulCtx func() context.Context
 
+	drainTimeout  time.Duration
+	interruptedMu sync.Mutex
+	interrupted   []WorkerJob
+
 	tracer trace.Tracer
 	meter  metric.Meter
 
 	hooksJobLocked      []HookFunc
 	hooksUnknownJobType []HookFunc
 	hooksJobDone        []HookFunc
+	hooksJobRetried     []ErrorHookFunc
+	hooksJobDiscarded   []ErrorHookFunc
+	hooksJobPanicked    []HookFunc
+	hooksJobTimedOut    []HookFunc
+	hooksLockEmpty      []HookFunc
+	hooksJobExpired     []HookFunc
+
+	errorClassifier ErrorClassifier
 
 	panicStackBufSize int
+	heartbeatInterval time.Duration
+	maxJobDuration    time.Duration
+
+	lockBatchSize        int
+	lockBatchConcurrency int
+	batchHandlers        map[string]batchHandler
+
+	knownTypesOnly bool
+	allowedTypes   []string
+	deniedTypes    []string
+
+	autoscale autoscaleConfig
+
+	// sizeMu guards the pool workers state below, it is separate from mu that guards running state
+	sizeMu sync.Mutex
+	// workers are all the workers created by the pool, first size of them are running when the pool is running
+	workers []*Worker
+	size    int
+	// runCtx is the pool run context, nil when the pool is not running
+	runCtx context.Context
+	// cancels and dones are the cancel functions and finish channels of the workers run loops
+	cancels []context.CancelFunc
+	dones   []chan struct{}
+
+	mSize metric.Int64ObservableGauge
 }
 
 // NewWorkerPool creates a new WorkerPool with count workers using the Client c.
@@ -356,20 +829,23 @@ type WorkerPool struct {
 // Each Worker in the pool default to a poll interval of 5 seconds, which can be
 // overridden by WithPoolPollInterval option. The default queue is the
 // nameless queue "", which can be overridden by WithPoolQueue option.
+//
+// When autoscaling is enabled with WithPoolAutoscale, count is the initial number of workers.
 func NewWorkerPool(c *Client, wm WorkMap, poolSize int, options ...WorkerPoolOption) (*WorkerPool, error) {
 	w := WorkerPool{
-		wm:           wm,
-		interval:     defaultPollInterval,
-		queue:        defaultQueueName,
-		c:            c,
-		id:           RandomStringID(),
-		workers:      make([]*Worker, poolSize),
-		logger:       adapter.NoOpLogger{},
-		pollStrategy: PriorityPollStrategy,
-		tracer:       trace.NewNoopTracerProvider().Tracer("noop"),
-		meter:        noop.NewMeterProvider().Meter("noop"),
+		wm:       wm,
+		interval: defaultPollInterval,
+		queue:    defaultQueueName,
+		c:        c,
+		id:       RandomStringID(),
+		size:     poolSize,
+		logger:   adapter.NoOpLogger{},
+		poller:   PriorityPollStrategy,
+		tracer:   trace.NewNoopTracerProvider().Tracer("noop"),
+		meter:    noop.NewMeterProvider().Meter("noop"),
 
 		panicStackBufSize: defaultPanicStackBufSize,
+		autoscale:         autoscaleConfig{interval: defaultAutoscaleInterval},
 	}
 
 	for _, option := range options {
@@ -378,33 +854,77 @@ func NewWorkerPool(c *Client, wm WorkMap, poolSize int, options ...WorkerPoolOpt
 
 	w.logger = w.logger.With(adapter.F("worker-pool-id", w.id))
 
-	var err error
-	for i := range w.workers {
-		w.workers[i], err = NewWorker(
-			w.c,
-			w.wm,
+	if w.autoscale.enabled {
+		if err := w.autoscale.validate(); err != nil {
+			return nil, err
+		}
+		maxConns := w.maxConns()
+		if err := w.autoscale.validateConns(maxConns); err != nil {
+			return nil, err
+		}
+		w.size = w.autoscale.clamp(w.size, maxConns)
+	}
+
+	for i := 0; i < w.size; i++ {
+		if _, err := w.worker(i); err != nil {
+			return nil, err
+		}
+	}
+
+	return &w, w.initMetrics()
+}
+
+// worker returns pool worker with the given index, creating it if it does not exist yet.
+// Must be called with sizeMu held or before the pool is shared.
+func (w *WorkerPool) worker(idx int) (*Worker, error) {
+	for len(w.workers) <= idx {
+		options := []WorkerOption{
 			WithWorkerPollInterval(w.interval),
 			WithWorkerQueue(w.queue),
-			WithWorkerID(fmt.Sprintf("%s/worker-%d", w.id, i)),
+			WithWorkerID(fmt.Sprintf("%s/worker-%d", w.id, len(w.workers))),
 			WithWorkerLogger(w.logger),
-			WithWorkerPollStrategy(w.pollStrategy),
+			WithWorkerPoller(w.poller),
 			WithWorkerTracer(w.tracer),
 			WithWorkerMeter(w.meter),
 			WithWorkerHooksJobLocked(w.hooksJobLocked...),
 			WithWorkerHooksUnknownJobType(w.hooksUnknownJobType...),
 			WithWorkerHooksJobDone(w.hooksJobDone...),
+			WithWorkerHooksJobRetried(w.hooksJobRetried...),
+			WithWorkerHooksJobDiscarded(w.hooksJobDiscarded...),
+			WithWorkerHooksJobPanicked(w.hooksJobPanicked...),
+			WithWorkerHooksJobTimedOut(w.hooksJobTimedOut...),
+			WithWorkerHooksLockEmpty(w.hooksLockEmpty...),
+			WithWorkerHooksJobExpired(w.hooksJobExpired...),
+			WithWorkerErrorClassifier(w.errorClassifier),
 			WithWorkerPanicStackBufSize(w.panicStackBufSize),
-		)
+			WithWorkerHeartbeat(w.heartbeatInterval),
+			WithWorkerMaxJobDuration(w.maxJobDuration),
+			WithWorkerLockBatch(w.lockBatchSize, w.lockBatchConcurrency),
+			WithWorkerAllowedTypes(w.allowedTypes...),
+			WithWorkerDeniedTypes(w.deniedTypes...),
+		}
+		if w.knownTypesOnly {
+			options = append(options, WithWorkerKnownTypesOnly())
+		}
+		for jobType, h := range w.batchHandlers {
+			options = append(options, WithWorkerBatchWorkFunc(jobType, h.fn, h.maxSize, h.maxWait))
+		}
 
+		worker, err := NewWorker(w.c, w.wm, options...)
 		if err != nil {
 			return nil, fmt.Errorf("could not init worker instance: %w", err)
 		}
 
-		w.workers[i].graceful = w.graceful
-		w.workers[i].gracefulCtx = w.gracefulCtx
+		worker.graceful = w.graceful
+		worker.gracefulCtx = w.gracefulCtx
+		worker.drainTimeout = w.drainTimeout
+
+		w.workers = append(w.workers, worker)
+		w.cancels = append(w.cancels, nil)
+		w.dones = append(w.dones, nil)
 	}
 
-	return &w, nil
+	return w.workers[idx], nil
 }
 
 // Run runs all the Workers in the WorkerPool in own goroutines.
@@ -414,24 +934,187 @@ func (w *WorkerPool) Run(ctx context.Context) error {
 	return RunLock(ctx, w.runGroup, &w.mu, &w.running, w.id)
 }
 
-// WorkOne tries to consume single message from the queue.
-func (w *WorkerPool) WorkOne(ctx context.Context) (didWork bool) {
-	return w.workers[0].WorkOne(ctx)
+// WorkOne tries to consume single message from the queue with the first pool worker.
+// Returns an error when the pool has no workers, e.g. it was resized to zero.
+func (w *WorkerPool) WorkOne(ctx context.Context) (didWork bool, err error) {
+	w.sizeMu.Lock()
+	if w.size == 0 {
+		w.sizeMu.Unlock()
+		return false, errors.New("worker pool has no workers")
+	}
+	worker := w.workers[0]
+	w.sizeMu.Unlock()
+
+	return worker.WorkOne(ctx), nil
+}
+
+// SetSize changes the number of the pool workers at runtime. When the pool is running, new workers are started
+// immediately, and removed ones finish the jobs they are working on and exit. When autoscaling is enabled,
+// size is limited by the autoscaling min and max workers number and autoscaler continues from it.
+func (w *WorkerPool) SetSize(size int) error {
+	if size < 0 {
+		return errors.New("pool size must not be negative")
+	}
+
+	w.sizeMu.Lock()
+	defer w.sizeMu.Unlock()
+
+	if w.autoscale.enabled {
+		size = w.autoscale.clamp(size, w.maxConns())
+	}
+	if size == w.size {
+		return nil
+	}
+
+	w.logger.Info("Worker pool resized", adapter.F("from", w.size), adapter.F("to", size))
+	return w.resize(size)
+}
+
+// SetPollInterval changes poll interval of all the pool workers at runtime, see Worker.SetPollInterval.
+func (w *WorkerPool) SetPollInterval(d time.Duration) {
+	w.sizeMu.Lock()
+	defer w.sizeMu.Unlock()
+
+	w.interval = d
+	for _, worker := range w.workers {
+		worker.SetPollInterval(d)
+	}
+}
+
+// Register adds the job type handler to all the pool workers at runtime, see Worker.Register.
+func (w *WorkerPool) Register(jobType string, fn WorkFunc) {
+	w.sizeMu.Lock()
+	defer w.sizeMu.Unlock()
+
+	w.wm = w.wm.with(jobType, fn)
+	for _, worker := range w.workers {
+		worker.Register(jobType, fn)
+	}
+}
+
+// Size returns the number of the pool workers that are running when the pool is running.
+func (w *WorkerPool) Size() int {
+	w.sizeMu.Lock()
+	defer w.sizeMu.Unlock()
+
+	return w.size
 }
 
-// runGroup starts all the Workers in the WorkerPool in own goroutines
-// managed by errgroup.Group.
+// runGroup starts the Workers in the WorkerPool in own goroutines and waits for all of them to exit.
 func (w *WorkerPool) runGroup(ctx context.Context) error {
 	defer w.logger.Info("Worker pool finished")
 
-	grp, ctx := errgroup.WithContext(ctx)
-	for i := range w.workers {
-		idx := i
-		worker := w.workers[idx]
-		grp.Go(func() error {
-			return worker.Run(setWorkerIdx(ctx, idx))
-		})
+	w.interruptedMu.Lock()
+	w.interrupted = nil
+	w.interruptedMu.Unlock()
+
+	w.sizeMu.Lock()
+	w.runCtx = ctx
+	for i := 0; i < w.size; i++ {
+		w.startWorker(i)
+	}
+	w.sizeMu.Unlock()
+
+	if w.autoscale.enabled {
+		w.runAutoscaler(ctx)
+	} else {
+		<-ctx.Done()
 	}
 
-	return grp.Wait()
+	w.sizeMu.Lock()
+	w.runCtx = nil
+	dones := append([]chan struct{}(nil), w.dones...)
+	w.sizeMu.Unlock()
+
+	for _, done := range dones {
+		if done != nil {
+			<-done
+		}
+	}
+
+	w.interruptedMu.Lock()
+	defer w.interruptedMu.Unlock()
+
+	if len(w.interrupted) > 0 {
+		return &InterruptedJobsError{Jobs: append([]WorkerJob(nil), w.interrupted...)}
+	}
+	return nil
+}
+
+// resize changes the number of running workers, must be called with sizeMu held.
+func (w *WorkerPool) resize(size int) error {
+	for i := w.size; i < size; i++ {
+		if _, err := w.worker(i); err != nil {
+			return err
+		}
+	}
+
+	if w.runCtx != nil {
+		for i := w.size; i < size; i++ {
+			w.startWorker(i)
+		}
+		for i := size; i < w.size; i++ {
+			// worker finishes the job it is working on and exits
+			w.cancels[i]()
+		}
+	}
+
+	w.size = size
+	return nil
+}
+
+// startWorker runs the worker with the given index in own goroutine, must be called with sizeMu held.
+// In case the worker with the same index was stopped, but not finished yet - new run waits for it to finish.
+func (w *WorkerPool) startWorker(idx int) {
+	ctx, cancel := context.WithCancel(setWorkerIdx(w.runCtx, idx))
+	prevDone, done := w.dones[idx], make(chan struct{})
+	w.cancels[idx], w.dones[idx] = cancel, done
+
+	worker := w.workers[idx]
+	go func() {
+		defer close(done)
+		defer cancel()
+
+		if prevDone != nil {
+			<-prevDone
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err := worker.Run(ctx); err != nil {
+			w.logger.Error("Worker pool worker failed", adapter.Err(err), adapter.F("worker-idx", idx))
+
+			var interruptedErr *InterruptedJobsError
+			if errors.As(err, &interruptedErr) {
+				w.interruptedMu.Lock()
+				w.interrupted = append(w.interrupted, interruptedErr.Jobs...)
+				w.interruptedMu.Unlock()
+			}
+		}
+	}()
+}
+
+// runningWorkers returns the workers that are running when the pool is running.
+func (w *WorkerPool) runningWorkers() []*Worker {
+	w.sizeMu.Lock()
+	defer w.sizeMu.Unlock()
+
+	return append([]*Worker(nil), w.workers[:w.size]...)
+}
+
+func (w *WorkerPool) initMetrics() (err error) {
+	if w.mSize, err = w.meter.Int64ObservableGauge(
+		"gue_worker_pool_size",
+		metric.WithDescription("Number of the worker pool workers"),
+		metric.WithUnit("1"),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			o.Observe(int64(w.Size()), metric.WithAttributes(attrQueue.String(w.queue)))
+			return nil
+		}),
+	); err != nil {
+		return fmt.Errorf("could not register mSize metric: %w", err)
+	}
+
+	return nil
 }
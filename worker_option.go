@@ -96,10 +96,101 @@ func WithWorkerHooksJobDone(hooks ...HookFunc) WorkerOption {
 	}
 }
 
+// WithWorkerHooksJobRetried sets hooks that are called when the errored job was re-queued to be retried later.
+// Event has the attempt number and the time job is rescheduled to. Hooks are called from Job.Error after
+// the job changes were committed, including the panicked and unknown type jobs. For the batch jobs hooks are
+// called when the shared batch transaction is committed.
+func WithWorkerHooksJobRetried(hooks ...ErrorHookFunc) WorkerOption {
+	return func(w *Worker) {
+		w.hooksJobRetried = hooks
+	}
+}
+
+// WithWorkerHooksJobDiscarded sets hooks that are called when the errored job was discarded, either because
+// the backoff returned negative duration or because the handler returned ErrDiscardJob error.
+// Event has the attempt number and the discard reason. Hooks are called from Job.Error after the job deletion
+// was committed, see WithWorkerHooksJobRetried.
+func WithWorkerHooksJobDiscarded(hooks ...ErrorHookFunc) WorkerOption {
+	return func(w *Worker) {
+		w.hooksJobDiscarded = hooks
+	}
+}
+
+// WithWorkerHooksJobPanicked sets hooks that are called when the job handler panicked, right before the job
+// is errored. Error field is set to the error with the panic message and stacktrace.
+func WithWorkerHooksJobPanicked(hooks ...HookFunc) WorkerOption {
+	return func(w *Worker) {
+		w.hooksJobPanicked = hooks
+	}
+}
+
+// WithWorkerHooksJobTimedOut sets hooks that are called when the job handler failed with context.DeadlineExceeded
+// error or the job was interrupted by the drain timeout, in the latter case error field is set to ErrJobInterrupted.
+func WithWorkerHooksJobTimedOut(hooks ...HookFunc) WorkerOption {
+	return func(w *Worker) {
+		w.hooksJobTimedOut = hooks
+	}
+}
+
+// WithWorkerHooksJobExpired sets hooks that are called when the job expired before it was worked, see Job.ExpiresAt.
+// Hooks are called after the job was deleted, but before the job transaction is committed, so the job may be
+// dead-lettered using Job.Tx. Error field is not set for this event type.
+func WithWorkerHooksJobExpired(hooks ...HookFunc) WorkerOption {
+	return func(w *Worker) {
+		w.hooksJobExpired = hooks
+	}
+}
+
+// WithWorkerHooksLockEmpty sets hooks that are called when the poll for jobs found nothing to work on.
+// Both job and error fields are not set for this event type.
+func WithWorkerHooksLockEmpty(hooks ...HookFunc) WorkerOption {
+	return func(w *Worker) {
+		w.hooksLockEmpty = hooks
+	}
+}
+
+// WithWorkerErrorClassifier sets the ErrorClassifier applied to the jobs failed within the worker,
+// it overrides the one set to the client with WithClientErrorClassifier.
+func WithWorkerErrorClassifier(classifier ErrorClassifier) WorkerOption {
+	return func(w *Worker) {
+		w.errorClassifier = classifier
+	}
+}
+
 // WithWorkerPollStrategy overrides default poll strategy with given value
 func WithWorkerPollStrategy(s PollStrategy) WorkerOption {
 	return func(w *Worker) {
-		w.pollStrategy = s
+		w.poller = s
+	}
+}
+
+// WithWorkerPoller overrides default poll strategy with the custom Poller that selects and locks the jobs to work on.
+func WithWorkerPoller(p Poller) WorkerOption {
+	return func(w *Worker) {
+		w.poller = p
+	}
+}
+
+// WithWorkerKnownTypesOnly limits the jobs locked by the worker to the job types present in its WorkMap, including
+// the ones added with Worker.Register, so that the jobs of the unknown types are left for the other workers instead
+// of being errored, e.g. during the rolling deploy or with the specialised workers.
+func WithWorkerKnownTypesOnly() WorkerOption {
+	return func(w *Worker) {
+		w.knownTypesOnly = true
+	}
+}
+
+// WithWorkerAllowedTypes limits the jobs locked by the worker to the given job types.
+func WithWorkerAllowedTypes(types ...string) WorkerOption {
+	return func(w *Worker) {
+		w.allowedTypes = types
+	}
+}
+
+// WithWorkerDeniedTypes prevents the worker from locking jobs of the given types.
+func WithWorkerDeniedTypes(types ...string) WorkerOption {
+	return func(w *Worker) {
+		w.deniedTypes = types
 	}
 }
 
@@ -112,6 +203,7 @@ func WithWorkerPollStrategy(s PollStrategy) WorkerOption {
 // when the graceful mode is enabled.
 //
 // Use "handlerCtx" to set up custom handler context. When set to nil - defaults to context.Background().
+// Use WithWorkerDrainTimeout to limit the time worker waits for the Job to finish.
 func WithWorkerGracefulShutdown(handlerCtx func() context.Context) WorkerOption {
 	return func(w *Worker) {
 		w.graceful = true
@@ -119,6 +211,70 @@ func WithWorkerGracefulShutdown(handlerCtx func() context.Context) WorkerOption
 	}
 }
 
+// WithWorkerDrainTimeout enables graceful shutdown mode with the drain deadline - when the worker context is cancelled,
+// worker stops polling for new jobs and waits for the jobs being worked to finish for up to the drain timeout.
+// After that the handler context is cancelled and the jobs which handlers return an error are interrupted - their
+// changes are rolled back and they are released unchanged to be worked again, e.g. by another worker process.
+// Jobs which handlers complete successfully despite the cancellation are finished as usual.
+// Interrupted jobs are reported with InterruptedJobsError returned by Worker.Run.
+//
+// Handler context is set up the same way as in graceful shutdown mode, use WithWorkerGracefulShutdown
+// to set up custom handler context.
+func WithWorkerDrainTimeout(d time.Duration) WorkerOption {
+	return func(w *Worker) {
+		w.graceful = true
+		w.drainTimeout = d
+	}
+}
+
+// WithWorkerHeartbeat enables worker registration in the worker registry - worker stores its ID, host, queue,
+// start time and the job it is working on in the workers table and updates them every heartbeat interval.
+// Registered workers are available with Client.ListWorkers. Registration is removed when the worker stops,
+// registrations of the workers that missed several heartbeats, e.g. crashed, are considered stale and are removed
+// by the other workers. Registration is disabled by default.
+func WithWorkerHeartbeat(interval time.Duration) WorkerOption {
+	return func(w *Worker) {
+		w.heartbeatInterval = interval
+	}
+}
+
+// WithWorkerMaxJobDuration sets the maximum expected job duration, worker that is working on the job for longer
+// than that is considered stalled by the health checks, e.g. when the handler is stuck. Disabled by default,
+// so worker working on the job is always considered progressing.
+func WithWorkerMaxJobDuration(d time.Duration) WorkerOption {
+	return func(w *Worker) {
+		w.maxJobDuration = d
+	}
+}
+
+// WithWorkerLockBatch enables batch locking - on every poll worker locks up to size jobs in a single transaction
+// with Client.LockJobs instead of locking them one by one, that saves DB round trips for the short jobs.
+//
+// When concurrency is 1 or less, batch jobs are worked sequentially and every job is worked within its own savepoint,
+// so changes made by the failed job handler within the Job.Tx are rolled back. Otherwise, up to concurrency batch jobs
+// are worked concurrently, in this case job handlers must not use Job.Tx as the transaction is shared.
+// In both modes jobs are deleted or rescheduled individually, so one failing job does not roll back the others.
+// All the batch jobs stay locked until the whole batch is worked. Batch locking is disabled by default.
+func WithWorkerLockBatch(size, concurrency int) WorkerOption {
+	return func(w *Worker) {
+		w.lockBatchSize = size
+		w.lockBatchConcurrency = concurrency
+	}
+}
+
+// WithWorkerBatchWorkFunc registers the batch handler for the job type - worker locks up to maxSize ready jobs
+// of this type together and passes them to the handler at once. Incomplete batch is worked only after its oldest job
+// waited for maxWait since it became eligible for execution, until then the jobs are left for the next polls,
+// so they are worked up to maxWait plus poll interval late. Jobs of this type are never passed to the WorkMap handler.
+func WithWorkerBatchWorkFunc(jobType string, fn BatchWorkFunc, maxSize int, maxWait time.Duration) WorkerOption {
+	return func(w *Worker) {
+		if w.batchHandlers == nil {
+			w.batchHandlers = make(map[string]batchHandler)
+		}
+		w.batchHandlers[jobType] = batchHandler{fn: fn, maxSize: maxSize, maxWait: maxWait}
+	}
+}
+
 // WithPoolPollInterval overrides default poll interval with the given value.
 // Poll interval is the "sleep" duration if there were no jobs found in the DB.
 func WithPoolPollInterval(d time.Duration) WorkerPoolOption {
@@ -151,7 +307,15 @@ func WithPoolLogger(logger adapter.Logger) WorkerPoolOption {
 // WithPoolPollStrategy overrides default poll strategy with given value
 func WithPoolPollStrategy(s PollStrategy) WorkerPoolOption {
 	return func(w *WorkerPool) {
-		w.pollStrategy = s
+		w.poller = s
+	}
+}
+
+// WithPoolPoller overrides default poll strategy with the custom Poller that selects and locks the jobs to work on,
+// the Poller is shared by all the workers in the pool.
+func WithPoolPoller(p Poller) WorkerPoolOption {
+	return func(w *WorkerPool) {
+		w.poller = p
 	}
 }
 
@@ -190,6 +354,77 @@ func WithPoolHooksJobDone(hooks ...HookFunc) WorkerPoolOption {
 	}
 }
 
+// WithPoolHooksJobRetried calls WithWorkerHooksJobRetried for every worker in the pool.
+func WithPoolHooksJobRetried(hooks ...ErrorHookFunc) WorkerPoolOption {
+	return func(w *WorkerPool) {
+		w.hooksJobRetried = hooks
+	}
+}
+
+// WithPoolHooksJobDiscarded calls WithWorkerHooksJobDiscarded for every worker in the pool.
+func WithPoolHooksJobDiscarded(hooks ...ErrorHookFunc) WorkerPoolOption {
+	return func(w *WorkerPool) {
+		w.hooksJobDiscarded = hooks
+	}
+}
+
+// WithPoolHooksJobPanicked calls WithWorkerHooksJobPanicked for every worker in the pool.
+func WithPoolHooksJobPanicked(hooks ...HookFunc) WorkerPoolOption {
+	return func(w *WorkerPool) {
+		w.hooksJobPanicked = hooks
+	}
+}
+
+// WithPoolHooksJobTimedOut calls WithWorkerHooksJobTimedOut for every worker in the pool.
+func WithPoolHooksJobTimedOut(hooks ...HookFunc) WorkerPoolOption {
+	return func(w *WorkerPool) {
+		w.hooksJobTimedOut = hooks
+	}
+}
+
+// WithPoolHooksJobExpired calls WithWorkerHooksJobExpired for every worker in the pool.
+func WithPoolHooksJobExpired(hooks ...HookFunc) WorkerPoolOption {
+	return func(w *WorkerPool) {
+		w.hooksJobExpired = hooks
+	}
+}
+
+// WithPoolHooksLockEmpty calls WithWorkerHooksLockEmpty for every worker in the pool.
+func WithPoolHooksLockEmpty(hooks ...HookFunc) WorkerPoolOption {
+	return func(w *WorkerPool) {
+		w.hooksLockEmpty = hooks
+	}
+}
+
+// WithPoolErrorClassifier calls WithWorkerErrorClassifier for every worker in the pool.
+func WithPoolErrorClassifier(classifier ErrorClassifier) WorkerPoolOption {
+	return func(w *WorkerPool) {
+		w.errorClassifier = classifier
+	}
+}
+
+// WithPoolKnownTypesOnly limits the jobs locked by every worker in the pool to the job types present
+// in the pool WorkMap, see WithWorkerKnownTypesOnly.
+func WithPoolKnownTypesOnly() WorkerPoolOption {
+	return func(w *WorkerPool) {
+		w.knownTypesOnly = true
+	}
+}
+
+// WithPoolAllowedTypes limits the jobs locked by every worker in the pool to the given job types.
+func WithPoolAllowedTypes(types ...string) WorkerPoolOption {
+	return func(w *WorkerPool) {
+		w.allowedTypes = types
+	}
+}
+
+// WithPoolDeniedTypes prevents every worker in the pool from locking jobs of the given types.
+func WithPoolDeniedTypes(types ...string) WorkerPoolOption {
+	return func(w *WorkerPool) {
+		w.deniedTypes = types
+	}
+}
+
 // WithPoolGracefulShutdown enables graceful shutdown mode for all workers in the pool.
 // See WithWorkerGracefulShutdown for details.
 func WithPoolGracefulShutdown(handlerCtx func() context.Context) WorkerPoolOption {
@@ -199,6 +434,16 @@ func WithPoolGracefulShutdown(handlerCtx func() context.Context) WorkerPoolOptio
 	}
 }
 
+// WithPoolDrainTimeout enables graceful shutdown mode with the drain deadline for all workers in the pool,
+// see WithWorkerDrainTimeout for details. WorkerPool.Run returns only after all the pool workers are drained,
+// jobs interrupted in all the workers are reported with InterruptedJobsError.
+func WithPoolDrainTimeout(d time.Duration) WorkerPoolOption {
+	return func(w *WorkerPool) {
+		w.graceful = true
+		w.drainTimeout = d
+	}
+}
+
 // WithPoolPanicStackBufSize sets max size for the stacktrace buffer for panicking jobs.
 // Default value is 1024 that is enough for most of the cases. Be careful setting buffer suze to the big values
 // as this may affect overall performance.
@@ -207,3 +452,60 @@ func WithPoolPanicStackBufSize(size int) WorkerPoolOption {
 		w.panicStackBufSize = size
 	}
 }
+
+// WithPoolHeartbeat enables worker registration in the worker registry for all the pool workers,
+// see WithWorkerHeartbeat for details.
+func WithPoolHeartbeat(interval time.Duration) WorkerPoolOption {
+	return func(w *WorkerPool) {
+		w.heartbeatInterval = interval
+	}
+}
+
+// WithPoolMaxJobDuration sets the maximum expected job duration for all the pool workers,
+// see WithWorkerMaxJobDuration for details.
+func WithPoolMaxJobDuration(d time.Duration) WorkerPoolOption {
+	return func(w *WorkerPool) {
+		w.maxJobDuration = d
+	}
+}
+
+// WithPoolLockBatch enables batch locking for all the pool workers, see WithWorkerLockBatch for details.
+func WithPoolLockBatch(size, concurrency int) WorkerPoolOption {
+	return func(w *WorkerPool) {
+		w.lockBatchSize = size
+		w.lockBatchConcurrency = concurrency
+	}
+}
+
+// WithPoolBatchWorkFunc registers the batch handler for the job type for all the pool workers,
+// see WithWorkerBatchWorkFunc for details.
+func WithPoolBatchWorkFunc(jobType string, fn BatchWorkFunc, maxSize int, maxWait time.Duration) WorkerPoolOption {
+	return func(w *WorkerPool) {
+		if w.batchHandlers == nil {
+			w.batchHandlers = make(map[string]batchHandler)
+		}
+		w.batchHandlers[jobType] = batchHandler{fn: fn, maxSize: maxSize, maxWait: maxWait}
+	}
+}
+
+// WithPoolAutoscale enables worker pool autoscaling - the number of running workers is changed between min and max
+// based on the number of jobs ready for execution in the queue and the ratio of the workers polls that found no job.
+// Only the jobs the pool workers can lock are counted - jobs of the paused queue or types and the job types
+// the workers are not allowed to lock are skipped.
+// Max workers number is additionally limited by the DB connection pool size when the pool adapter
+// implements adapter.ConnPoolSizer, one connection is left for the other needs, so NewWorkerPool fails when
+// the connection pool has less than min+1 connections. Pool size is reported with the gue_worker_pool_size metric.
+func WithPoolAutoscale(min, max int) WorkerPoolOption {
+	return func(w *WorkerPool) {
+		w.autoscale.enabled = true
+		w.autoscale.min = min
+		w.autoscale.max = max
+	}
+}
+
+// WithPoolAutoscaleInterval overrides default autoscaling evaluation interval (10 seconds) with the given value.
+func WithPoolAutoscaleInterval(d time.Duration) WorkerPoolOption {
+	return func(w *WorkerPool) {
+		w.autoscale.interval = d
+	}
+}
@@ -96,7 +96,34 @@ func TestWithWorkerLogger(t *testing.T) {
 func TestWithWorkerPollStrategy(t *testing.T) {
 	workerWithWorkerPollStrategy, err := NewWorker(nil, dummyWM, WithWorkerPollStrategy(RunAtPollStrategy))
 	require.NoError(t, err)
-	assert.Equal(t, RunAtPollStrategy, workerWithWorkerPollStrategy.pollStrategy)
+	assert.Equal(t, RunAtPollStrategy, workerWithWorkerPollStrategy.poller)
+}
+
+func TestWithWorkerPoller(t *testing.T) {
+	workerWithDefaultPoller, err := NewWorker(nil, dummyWM)
+	require.NoError(t, err)
+	assert.Equal(t, PriorityPollStrategy, workerWithDefaultPoller.poller)
+
+	p := NewTypesRoundRobinPoller(RunAtPollStrategy, "foo", "bar")
+	workerWithPoller, err := NewWorker(nil, dummyWM, WithWorkerPoller(p))
+	require.NoError(t, err)
+	assert.Same(t, p, workerWithPoller.poller)
+}
+
+func TestWithWorkerTypes(t *testing.T) {
+	workerWithDefaultTypes, err := NewWorker(nil, dummyWM)
+	require.NoError(t, err)
+	assert.False(t, workerWithDefaultTypes.knownTypesOnly)
+	assert.Empty(t, workerWithDefaultTypes.allowedTypes)
+	assert.Empty(t, workerWithDefaultTypes.deniedTypes)
+
+	workerWithTypes, err := NewWorker(
+		nil, dummyWM, WithWorkerKnownTypesOnly(), WithWorkerAllowedTypes("foo"), WithWorkerDeniedTypes("bar"),
+	)
+	require.NoError(t, err)
+	assert.True(t, workerWithTypes.knownTypesOnly)
+	assert.Equal(t, []string{"foo"}, workerWithTypes.allowedTypes)
+	assert.Equal(t, []string{"bar"}, workerWithTypes.deniedTypes)
 }
 
 func TestWithWorkerGracefulShutdown(t *testing.T) {
@@ -184,7 +211,31 @@ func TestWithPoolLogger(t *testing.T) {
 func TestWithPoolPollStrategy(t *testing.T) {
 	workerPoolWithPoolPollStrategy, err := NewWorkerPool(nil, dummyWM, 2, WithPoolPollStrategy(RunAtPollStrategy))
 	require.NoError(t, err)
-	assert.Equal(t, RunAtPollStrategy, workerPoolWithPoolPollStrategy.pollStrategy)
+	assert.Equal(t, RunAtPollStrategy, workerPoolWithPoolPollStrategy.poller)
+}
+
+func TestWithPoolPoller(t *testing.T) {
+	p := NewTypesRoundRobinPoller(RunAtPollStrategy, "foo", "bar")
+	workerPoolWithPoller, err := NewWorkerPool(nil, dummyWM, 2, WithPoolPoller(p))
+	require.NoError(t, err)
+	assert.Same(t, p, workerPoolWithPoller.poller)
+
+	for _, worker := range workerPoolWithPoller.workers {
+		assert.Same(t, p, worker.poller)
+	}
+}
+
+func TestWithPoolTypes(t *testing.T) {
+	workerPoolWithTypes, err := NewWorkerPool(
+		nil, dummyWM, 2, WithPoolKnownTypesOnly(), WithPoolAllowedTypes("foo"), WithPoolDeniedTypes("bar"),
+	)
+	require.NoError(t, err)
+
+	for _, worker := range workerPoolWithTypes.workers {
+		assert.True(t, worker.knownTypesOnly)
+		assert.Equal(t, []string{"foo"}, worker.allowedTypes)
+		assert.Equal(t, []string{"bar"}, worker.deniedTypes)
+	}
 }
 
 func TestWithPoolTracer(t *testing.T) {
@@ -392,3 +443,161 @@ func TestWithPoolPanicStackBufSize(t *testing.T) {
 		assert.Equal(t, 12345, w.panicStackBufSize)
 	}
 }
+
+func TestWithWorkerHeartbeat(t *testing.T) {
+	workerWithoutHeartbeat, err := NewWorker(nil, dummyWM)
+	require.NoError(t, err)
+	assert.Nil(t, workerWithoutHeartbeat.registration)
+
+	workerWithHeartbeat, err := NewWorker(nil, dummyWM, WithWorkerHeartbeat(time.Second), WithWorkerQueue("q"))
+	require.NoError(t, err)
+	require.NotNil(t, workerWithHeartbeat.registration)
+	assert.Equal(t, time.Second, workerWithHeartbeat.registration.interval)
+	assert.Equal(t, workerWithHeartbeat.id, workerWithHeartbeat.registration.id)
+	assert.Equal(t, "q", workerWithHeartbeat.registration.queue)
+}
+
+func TestWithPoolHeartbeat(t *testing.T) {
+	poolWithoutHeartbeat, err := NewWorkerPool(nil, dummyWM, 2)
+	require.NoError(t, err)
+	for _, w := range poolWithoutHeartbeat.workers {
+		assert.Nil(t, w.registration)
+	}
+
+	poolWithHeartbeat, err := NewWorkerPool(nil, dummyWM, 2, WithPoolHeartbeat(time.Second))
+	require.NoError(t, err)
+	for _, w := range poolWithHeartbeat.workers {
+		require.NotNil(t, w.registration)
+		assert.Equal(t, w.id, w.registration.id)
+	}
+}
+
+func TestWithWorkerLockBatch(t *testing.T) {
+	workerWithoutBatch, err := NewWorker(nil, dummyWM)
+	require.NoError(t, err)
+	assert.Equal(t, 0, workerWithoutBatch.lockBatchSize)
+
+	workerWithBatch, err := NewWorker(nil, dummyWM, WithWorkerLockBatch(10, 2))
+	require.NoError(t, err)
+	assert.Equal(t, 10, workerWithBatch.lockBatchSize)
+	assert.Equal(t, 2, workerWithBatch.lockBatchConcurrency)
+}
+
+func TestWithPoolLockBatch(t *testing.T) {
+	poolWithBatch, err := NewWorkerPool(nil, dummyWM, 2, WithPoolLockBatch(10, 2))
+	require.NoError(t, err)
+	for _, w := range poolWithBatch.workers {
+		assert.Equal(t, 10, w.lockBatchSize)
+		assert.Equal(t, 2, w.lockBatchConcurrency)
+	}
+}
+
+func TestWithWorkerBatchWorkFunc(t *testing.T) {
+	batchFn := func(ctx context.Context, jobs []*Job) []error { return nil }
+
+	w, err := NewWorker(nil, dummyWM, WithWorkerBatchWorkFunc("b", batchFn, 10, time.Second), WithWorkerBatchWorkFunc("a", batchFn, 5, 0))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, w.batchTypes)
+	assert.Equal(t, 10, w.batchHandlers["b"].maxSize)
+	assert.Equal(t, time.Second, w.batchHandlers["b"].maxWait)
+
+	_, err = NewWorker(nil, dummyWM, WithWorkerBatchWorkFunc("a", batchFn, 0, 0))
+	assert.Error(t, err)
+
+	pool, err := NewWorkerPool(nil, dummyWM, 2, WithPoolBatchWorkFunc("a", batchFn, 5, time.Second))
+	require.NoError(t, err)
+	for _, w := range pool.workers {
+		assert.Equal(t, []string{"a"}, w.batchTypes)
+		assert.Equal(t, 5, w.batchHandlers["a"].maxSize)
+	}
+}
+
+func TestWithWorkerDrainTimeout(t *testing.T) {
+	w, err := NewWorker(nil, dummyWM, WithWorkerDrainTimeout(time.Second))
+	require.NoError(t, err)
+	assert.True(t, w.graceful)
+	assert.Equal(t, time.Second, w.drainTimeout)
+
+	pool, err := NewWorkerPool(nil, dummyWM, 2, WithPoolDrainTimeout(time.Second))
+	require.NoError(t, err)
+	for _, w := range pool.workers {
+		assert.True(t, w.graceful)
+		assert.Equal(t, time.Second, w.drainTimeout)
+	}
+}
+
+func TestWithWorkerMaxJobDuration(t *testing.T) {
+	w, err := NewWorker(nil, dummyWM, WithWorkerMaxJobDuration(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, w.maxJobDuration)
+
+	pool, err := NewWorkerPool(nil, dummyWM, 2, WithPoolMaxJobDuration(time.Minute))
+	require.NoError(t, err)
+	for _, w := range pool.workers {
+		assert.Equal(t, time.Minute, w.maxJobDuration)
+	}
+}
+
+func TestWithPoolLifecycleHooks(t *testing.T) {
+	ctx := context.Background()
+	hook, errorHook := new(dummyHook), new(mockErrorHook)
+
+	pool, err := NewWorkerPool(
+		nil,
+		dummyWM,
+		2,
+		WithPoolHooksJobRetried(errorHook.handler),
+		WithPoolHooksJobDiscarded(errorHook.handler),
+		WithPoolHooksJobPanicked(hook.handler),
+		WithPoolHooksJobTimedOut(hook.handler),
+		WithPoolHooksLockEmpty(hook.handler),
+	)
+	require.NoError(t, err)
+
+	for _, w := range pool.workers {
+		for _, hooks := range [][]HookFunc{w.hooksJobPanicked, w.hooksJobTimedOut, w.hooksLockEmpty} {
+			for _, h := range hooks {
+				h(ctx, nil, nil)
+			}
+		}
+		for _, hooks := range [][]ErrorHookFunc{w.hooksJobRetried, w.hooksJobDiscarded} {
+			for _, h := range hooks {
+				h(ctx, nil, JobErrorEvent{})
+			}
+		}
+	}
+	assert.Equal(t, 6, hook.counter)
+	assert.Equal(t, 4, errorHook.called)
+}
+
+func TestWithPoolErrorClassifier(t *testing.T) {
+	classifier := ClassifyErrorIs(context.DeadlineExceeded, ErrorDecision{Action: ErrorActionDiscard})
+
+	pool, err := NewWorkerPool(nil, dummyWM, 2, WithPoolErrorClassifier(classifier))
+	require.NoError(t, err)
+
+	for _, w := range pool.workers {
+		require.NotNil(t, w.errorClassifier)
+		assert.Equal(t, ErrorActionDiscard, w.errorClassifier(nil, context.DeadlineExceeded).Action)
+
+		// worker classifier overrides the client one
+		j := &Job{errorClassifier: ClassifyErrors()}
+		w.prepareJob(j)
+		assert.Equal(t, ErrorActionDiscard, j.errorClassifier(j, context.DeadlineExceeded).Action)
+	}
+}
+
+func TestWithPoolHooksJobExpired(t *testing.T) {
+	ctx := context.Background()
+	hook := new(dummyHook)
+
+	pool, err := NewWorkerPool(nil, dummyWM, 3, WithPoolHooksJobExpired(hook.handler))
+	require.NoError(t, err)
+
+	for _, w := range pool.workers {
+		for _, h := range w.hooksJobExpired {
+			h(ctx, nil, nil)
+		}
+	}
+	assert.Equal(t, 3, hook.counter)
+}
@@ -225,13 +225,15 @@ func testWorkerPoolWorkOne(t *testing.T, connPool adapter.ConnPool) {
 	)
 	require.NoError(t, err)
 
-	didWork := w.WorkOne(ctx)
+	didWork, err := w.WorkOne(ctx)
+	require.NoError(t, err)
 	assert.False(t, didWork)
 
 	err = c.Enqueue(ctx, &Job{Type: "MyJob"})
 	require.NoError(t, err)
 
-	didWork = w.WorkOne(ctx)
+	didWork, err = w.WorkOne(ctx)
+	require.NoError(t, err)
 	assert.True(t, didWork)
 	assert.True(t, success)
 
@@ -519,6 +521,110 @@ func testWorkerWorkOneTypeNotInMap(t *testing.T, connPool adapter.ConnPool) {
 	assert.Contains(t, j.LastError.String, `unknown job type: "MyJob"`)
 }
 
+func TestWorker_pollQuery(t *testing.T) {
+	ctx := context.Background()
+	batchFn := func(ctx context.Context, jobs []*Job) []error { return nil }
+	handler := func(ctx context.Context, j *Job) error { return nil }
+
+	var queries []PollQuery
+	poller := PollerFunc(func(ctx context.Context, c *Client, q PollQuery) ([]*Job, error) {
+		queries = append(queries, q)
+		return nil, nil
+	})
+
+	w, err := NewWorker(
+		nil,
+		WorkMap{"foo": handler, "bar": handler, "baz": handler},
+		WithWorkerPoller(poller),
+		WithWorkerKnownTypesOnly(),
+		WithWorkerBatchWorkFunc("batch", batchFn, 10, 0),
+		WithWorkerBatchWorkFunc("denied-batch", batchFn, 10, 0),
+		WithWorkerDeniedTypes("baz", "denied-batch"),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"batch"}, w.batchTypes)
+
+	assert.False(t, w.WorkOne(ctx))
+	require.Len(t, queries, 2)
+	assert.Equal(t, PollQuery{Queue: "", Limit: 10, Types: []string{"batch"}}, queries[0])
+	assert.Equal(t, []string{"bar", "foo"}, queries[1].Types)
+	assert.Equal(t, []string{"batch", "baz", "denied-batch"}, queries[1].ExcludeTypes)
+
+	// job types registered at runtime are known as well
+	w.Register("qux", handler)
+	queries = nil
+	assert.False(t, w.WorkOne(ctx))
+	require.Len(t, queries, 2)
+	assert.Equal(t, []string{"bar", "foo", "qux"}, queries[1].Types)
+
+	// allow list limits known types
+	w, err = NewWorker(nil, WorkMap{"foo": handler, "bar": handler}, WithWorkerPoller(poller),
+		WithWorkerKnownTypesOnly(), WithWorkerAllowedTypes("foo", "other"))
+	require.NoError(t, err)
+	queries = nil
+	assert.False(t, w.WorkOne(ctx))
+	require.Len(t, queries, 1)
+	assert.Equal(t, []string{"foo"}, queries[0].Types)
+
+	// nothing is polled when no job type is allowed
+	w, err = NewWorker(nil, WorkMap{"foo": handler}, WithWorkerPoller(poller),
+		WithWorkerKnownTypesOnly(), WithWorkerAllowedTypes("bar"))
+	require.NoError(t, err)
+	queries = nil
+	assert.False(t, w.WorkOne(ctx))
+	assert.Empty(t, queries)
+
+	// allow list without known types only mode
+	w, err = NewWorker(nil, WorkMap{"foo": handler}, WithWorkerPoller(poller), WithWorkerAllowedTypes("bar"))
+	require.NoError(t, err)
+	queries = nil
+	assert.False(t, w.WorkOne(ctx))
+	require.Len(t, queries, 1)
+	assert.Equal(t, PollQuery{Queue: "", Limit: 1, Types: []string{"bar"}}, queries[0])
+}
+
+func TestWorkerWorkOneKnownTypesOnly(t *testing.T) {
+	for name, openFunc := range adapterTesting.AllAdaptersOpenTestPool {
+		t.Run(name, func(t *testing.T) {
+			testWorkerWorkOneKnownTypesOnly(t, openFunc(t))
+		})
+	}
+}
+
+func testWorkerWorkOneKnownTypesOnly(t *testing.T, connPool adapter.ConnPool) {
+	ctx := context.Background()
+
+	c, err := NewClient(connPool)
+	require.NoError(t, err)
+
+	var worked []string
+	wm := WorkMap{
+		"MyJob": func(ctx context.Context, j *Job) error {
+			worked = append(worked, j.Type)
+			return nil
+		},
+	}
+
+	unknownJobTypeHook := new(mockHook)
+	w, err := NewWorker(c, wm, WithWorkerKnownTypesOnly(), WithWorkerHooksUnknownJobType(unknownJobTypeHook.handler))
+	require.NoError(t, err)
+
+	unknown := Job{Type: "OtherJob", Priority: JobPriorityHighest}
+	require.NoError(t, c.Enqueue(ctx, &unknown))
+	require.NoError(t, c.Enqueue(ctx, &Job{Type: "MyJob"}))
+
+	assert.True(t, w.WorkOne(ctx))
+	assert.False(t, w.WorkOne(ctx))
+	assert.Equal(t, []string{"MyJob"}, worked)
+	assert.Equal(t, 0, unknownJobTypeHook.called)
+
+	// job of the unknown type is left untouched for the other workers
+	j, err := c.GetJob(ctx, unknown.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int32(0), j.ErrorCount)
+	assert.False(t, j.LastError.Valid)
+}
+
 // TestWorker_WorkOne_errorHookTx tests that JobDone hooks are running in the same transaction as the errored job
 func TestWorker_WorkOneErrorHookTx(t *testing.T) {
 	for name, openFunc := range adapterTesting.AllAdaptersOpenTestPool {
@@ -691,3 +797,54 @@ func TestNewWorkerPool_GracefulShutdown(t *testing.T) {
 	assert.Equal(t, 0, jobCancelled)
 	assert.Equal(t, numWorkers, jobFinished)
 }
+
+func TestWorkerPool_Reconfigure(t *testing.T) {
+	wm := WorkMap{"MyJob": dummyWM["MyJob"]}
+	wp, err := NewWorkerPool(nil, wm, 2)
+	require.NoError(t, err)
+
+	require.NoError(t, wp.SetSize(4))
+	assert.Equal(t, 4, wp.Size())
+	require.Len(t, wp.workers, 4)
+
+	require.NoError(t, wp.SetSize(1))
+	assert.Equal(t, 1, wp.Size())
+	assert.Len(t, wp.workers, 4)
+	assert.Error(t, wp.SetSize(-1))
+
+	// pool resized to zero has no worker to work the job with
+	require.NoError(t, wp.SetSize(0))
+	_, err = wp.WorkOne(context.Background())
+	assert.Error(t, err)
+
+	wp.SetPollInterval(time.Minute)
+	for _, w := range wp.workers {
+		assert.Equal(t, time.Minute, w.pollInterval())
+	}
+
+	wp.Register("NewJob", func(ctx context.Context, j *Job) error {
+		return nil
+	})
+	for _, w := range wp.workers {
+		_, ok := w.workFunc("NewJob")
+		assert.True(t, ok)
+		_, ok = w.workFunc("MyJob")
+		assert.True(t, ok)
+	}
+	// original work map is not modified
+	assert.Len(t, wm, 1)
+
+	// workers created after reconfiguration get the new configuration as well
+	require.NoError(t, wp.SetSize(5))
+	assert.Equal(t, 5, wp.Size())
+	assert.Equal(t, time.Minute, wp.workers[4].pollInterval())
+	_, ok := wp.workers[4].workFunc("NewJob")
+	assert.True(t, ok)
+
+	c, err := NewClient(nil)
+	require.NoError(t, err)
+	autoscaled, err := NewWorkerPool(c, wm, 2, WithPoolAutoscale(2, 3))
+	require.NoError(t, err)
+	require.NoError(t, autoscaled.SetSize(10))
+	assert.Equal(t, 3, autoscaled.Size())
+}